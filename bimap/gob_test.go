@@ -0,0 +1,40 @@
+package bimap
+
+import (
+	"bytes"
+	"encoding/gob"
+	"testing"
+
+	"github.com/leanovate/gopter"
+	"github.com/leanovate/gopter/gen"
+	"github.com/leanovate/gopter/prop"
+)
+
+func TestGob(t *testing.T) {
+	parameters := gopter.DefaultTestParameters()
+	properties := gopter.NewProperties(parameters)
+	properties.Property("decode(encode(m)) maps both ways", prop.ForAll(
+		func(native map[int]string) bool {
+			m := From(native)
+			var buf bytes.Buffer
+			if err := gob.NewEncoder(&buf).Encode(m); err != nil {
+				return false
+			}
+			var out *Map
+			if err := gob.NewDecoder(&buf).Decode(&out); err != nil {
+				return false
+			}
+			if !out.Equal(m) || out.Inverse().Inverse() != out {
+				return false
+			}
+			ok := true
+			m.Range(func(key, value interface{}) bool {
+				ok = out.Inverse().At(value) == key
+				return ok
+			})
+			return ok && out.Inverse().Equal(m.Inverse())
+		},
+		gen.MapOf(gen.Int(), gen.AlphaString()),
+	))
+	properties.TestingRun(t)
+}
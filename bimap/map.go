@@ -0,0 +1,414 @@
+// Package bimap implements a persistent one-to-one map.
+//
+// A bimap holds a hashmap.Map in each direction, from keys to values
+// and from values to keys. Every operation updates both so they can
+// not fall out of step. Because values are keys of the inverse map
+// each value may be associated with only one key; associating a key
+// with a value that already belongs to another key evicts that pair.
+package bimap // import "jsouthworth.net/go/immutable/bimap"
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"jsouthworth.net/go/dyn"
+	"jsouthworth.net/go/immutable/hashmap"
+)
+
+var errOddElements = errors.New("must supply an even number elements")
+var errTransformSig = errors.New("Transform requires functions: func(t *TMap) *TMap or func(t *TMap)")
+
+// Map is a persistent bidirectional map.
+type Map struct {
+	forward  *hashmap.Map
+	backward *hashmap.Map
+	inverse  *Map
+}
+
+// Empty returns a new empty persistent bimap.
+func Empty() *Map {
+	return newMap(hashmap.Empty(), hashmap.Empty())
+}
+
+// newMap pairs forward and backward into a map and its inverse so
+// that Inverse does not allocate.
+func newMap(forward, backward *hashmap.Map) *Map {
+	m := &Map{forward: forward, backward: backward}
+	m.inverse = &Map{forward: backward, backward: forward, inverse: m}
+	return m
+}
+
+// New converts a list of elements to a persistent bimap by
+// associating them pairwise. Later pairs evict earlier ones that
+// conflict with them. New will panic if the number of elements is not
+// even.
+func New(elems ...interface{}) *Map {
+	if len(elems)%2 != 0 {
+		panic(errOddElements)
+	}
+	out := Empty().AsTransient()
+	for i := 0; i < len(elems); i += 2 {
+		out = out.Assoc(elems[i], elems[i+1])
+	}
+	return out.AsPersistent()
+}
+
+// From will convert many different go types to a bimap. Pairs are
+// associated in the order the source provides them, so for sources
+// that hold duplicate values it is unspecified which pair is kept.
+//
+// *Map:
+//
+//	Returned directly as it is already immutable.
+//
+// *TMap:
+//
+//	AsPersistent is called on it and the result is returned.
+//
+// *hashmap.Map:
+//
+//	Each entry is associated with an empty transient bimap.
+//
+// []hashmap.Entry:
+//
+//	Each entry is associated with an empty transient bimap.
+//
+// []interface{}:
+//
+//	The elements are passed to New.
+//
+// map[kT]vT:
+//
+//	Reflection is used to loop over the entries of the map and associate them.
+//
+// []T:
+//
+//	Reflection is used to convert the slice to []interface{} and then passed to New.
+//
+// Other:
+//
+//	Returns Empty()
+func From(value interface{}) *Map {
+	switch v := value.(type) {
+	case *Map:
+		return v
+	case *TMap:
+		return v.AsPersistent()
+	case *hashmap.Map:
+		return Empty().Transform(func(t *TMap) {
+			v.Range(func(key, value interface{}) {
+				t.Assoc(key, value)
+			})
+		})
+	case []hashmap.Entry:
+		out := Empty().AsTransient()
+		for _, entry := range v {
+			out = out.Assoc(entry.Key(), entry.Value())
+		}
+		return out.AsPersistent()
+	case []interface{}:
+		return New(v...)
+	default:
+		return mapFromReflection(value)
+	}
+}
+
+func mapFromReflection(value interface{}) *Map {
+	v := reflect.ValueOf(value)
+	switch v.Kind() {
+	case reflect.Map:
+		out := Empty().AsTransient()
+		iter := v.MapRange()
+		for iter.Next() {
+			out.Assoc(iter.Key().Interface(), iter.Value().Interface())
+		}
+		return out.AsPersistent()
+	case reflect.Slice:
+		sl := make([]interface{}, v.Len())
+		for i := 0; i < v.Len(); i++ {
+			sl[i] = v.Index(i).Interface()
+		}
+		return New(sl...)
+	default:
+		return Empty()
+	}
+}
+
+// GetByKey returns the value associated with key and whether key is
+// in the map.
+func (m *Map) GetByKey(key interface{}) (value interface{}, exists bool) {
+	return m.forward.Find(key)
+}
+
+// GetByValue returns the key associated with value and whether value
+// is in the map.
+func (m *Map) GetByValue(value interface{}) (key interface{}, exists bool) {
+	return m.backward.Find(value)
+}
+
+// At returns the value associated with the key.
+// If one is not found, nil is returned.
+func (m *Map) At(key interface{}) interface{} {
+	return m.forward.At(key)
+}
+
+// ContainsKey will test if key is in the map.
+func (m *Map) ContainsKey(key interface{}) bool {
+	return m.forward.Contains(key)
+}
+
+// ContainsValue will test if value is in the map.
+func (m *Map) ContainsValue(value interface{}) bool {
+	return m.backward.Contains(value)
+}
+
+// Assoc associates value with key. Any pair already holding key or
+// value is removed first so the map stays one-to-one. If the pair is
+// already in the map, m is returned.
+func (m *Map) Assoc(key, value interface{}) *Map {
+	if v, ok := m.forward.Find(key); ok && dyn.Equal(v, value) {
+		return m
+	}
+	return m.Transform(func(t *TMap) {
+		t.Assoc(key, value)
+	})
+}
+
+// Conj takes a value that must be a hashmap.Entry. Conj implements a
+// generic mechanism for building collections.
+func (m *Map) Conj(value interface{}) interface{} {
+	entry := value.(hashmap.Entry)
+	return m.Assoc(entry.Key(), entry.Value())
+}
+
+// DeleteKey removes key and its value from the map. If key is not in
+// the map, m is returned.
+func (m *Map) DeleteKey(key interface{}) *Map {
+	value, ok := m.forward.Find(key)
+	if !ok {
+		return m
+	}
+	return newMap(m.forward.Delete(key), m.backward.Delete(value))
+}
+
+// DeleteValue removes value and its key from the map. If value is not
+// in the map, m is returned.
+func (m *Map) DeleteValue(value interface{}) *Map {
+	return m.inverse.DeleteKey(value).inverse
+}
+
+// Length returns the number of pairs in the map.
+func (m *Map) Length() int {
+	return m.forward.Length()
+}
+
+// Inverse returns the map with its keys and values swapped. Inverse
+// takes constant time and does not allocate.
+func (m *Map) Inverse() *Map {
+	return m.inverse
+}
+
+// Keys returns the map from keys to values.
+func (m *Map) Keys() *hashmap.Map {
+	return m.forward
+}
+
+// Values returns the map from values to keys.
+func (m *Map) Values() *hashmap.Map {
+	return m.backward
+}
+
+// Range calls do on each pair in the map. It accepts the same
+// functions as hashmap.Map.Range.
+func (m *Map) Range(do interface{}) {
+	m.forward.Range(do)
+}
+
+// Reduce reduces the pairs of the map. It accepts the same functions
+// as hashmap.Map.Reduce.
+func (m *Map) Reduce(fn interface{}, init interface{}) interface{} {
+	return m.forward.Reduce(fn, init)
+}
+
+// String returns a string representation of the map.
+func (m *Map) String() string {
+	var b strings.Builder
+	fmt.Fprint(&b, "{ ")
+	m.forward.Range(func(entry hashmap.Entry) {
+		fmt.Fprintf(&b, "%s ", entry)
+	})
+	fmt.Fprint(&b, "}")
+	return b.String()
+}
+
+// Equal tests if two bimaps hold the same pairs.
+func (m *Map) Equal(o interface{}) bool {
+	other, ok := o.(*Map)
+	if !ok {
+		return ok
+	}
+	return m.forward.Equal(other.forward)
+}
+
+// Apply takes an arbitrary number of arguments and returns the
+// value At the first argument.  Apply allows map to be called
+// as a function by the 'dyn' library.
+func (m *Map) Apply(args ...interface{}) interface{} {
+	return m.At(args[0])
+}
+
+// AsTransient will return a mutable copy on write version of the map.
+func (m *Map) AsTransient() *TMap {
+	return &TMap{
+		forward:  m.forward.AsTransient(),
+		backward: m.backward.AsTransient(),
+	}
+}
+
+// MakeTransient is a generic version of AsTransient.
+func (m *Map) MakeTransient() interface{} {
+	return m.AsTransient()
+}
+
+// Transform takes a set of actions and performs them
+// on the persistent map. It does this by making a transient
+// map and calling each action on it, then converting it back
+// to a persistent map.
+// Each action may be a func(*TMap) *TMap, whose result is passed to
+// the following action unless it is nil, or a func(*TMap).
+// Transform will panic if given any other type.
+func (m *Map) Transform(actions ...interface{}) *Map {
+	out := m.AsTransient()
+	for _, action := range actions {
+		switch fn := action.(type) {
+		case func(*TMap) *TMap:
+			if next := fn(out); next != nil {
+				out = next
+			}
+		case func(*TMap):
+			fn(out)
+		default:
+			panic(errTransformSig)
+		}
+	}
+	return out.AsPersistent()
+}
+
+// TMap is a transient version of a bimap. Changes made to a transient
+// map occur as mutations and do not affect the persistent map it was
+// made from. Both directions are updated together by every operation.
+type TMap struct {
+	forward  *hashmap.TMap
+	backward *hashmap.TMap
+}
+
+// GetByKey returns the value associated with key and whether key is
+// in the map.
+func (m *TMap) GetByKey(key interface{}) (value interface{}, exists bool) {
+	return m.forward.Find(key)
+}
+
+// GetByValue returns the key associated with value and whether value
+// is in the map.
+func (m *TMap) GetByValue(value interface{}) (key interface{}, exists bool) {
+	return m.backward.Find(value)
+}
+
+// At returns the value associated with the key.
+// If one is not found, nil is returned.
+func (m *TMap) At(key interface{}) interface{} {
+	return m.forward.At(key)
+}
+
+// ContainsKey will test if key is in the map.
+func (m *TMap) ContainsKey(key interface{}) bool {
+	return m.forward.Contains(key)
+}
+
+// ContainsValue will test if value is in the map.
+func (m *TMap) ContainsValue(value interface{}) bool {
+	return m.backward.Contains(value)
+}
+
+// Assoc associates value with key. Any pair already holding key or
+// value is removed first so the map stays one-to-one. The transient
+// map is modified and then returned.
+func (m *TMap) Assoc(key, value interface{}) *TMap {
+	m.DeleteKey(key)
+	m.DeleteValue(value)
+	m.forward = m.forward.Assoc(key, value)
+	m.backward = m.backward.Assoc(value, key)
+	return m
+}
+
+// Conj takes a value that must be a hashmap.Entry. Conj implements a
+// generic mechanism for building collections.
+func (m *TMap) Conj(value interface{}) interface{} {
+	entry := value.(hashmap.Entry)
+	return m.Assoc(entry.Key(), entry.Value())
+}
+
+// DeleteKey removes key and its value from the map. The transient map
+// is modified and then returned.
+func (m *TMap) DeleteKey(key interface{}) *TMap {
+	if value, ok := m.forward.Find(key); ok {
+		m.forward = m.forward.Delete(key)
+		m.backward = m.backward.Delete(value)
+	}
+	return m
+}
+
+// DeleteValue removes value and its key from the map. The transient
+// map is modified and then returned.
+func (m *TMap) DeleteValue(value interface{}) *TMap {
+	if key, ok := m.backward.Find(value); ok {
+		m.backward = m.backward.Delete(value)
+		m.forward = m.forward.Delete(key)
+	}
+	return m
+}
+
+// Length returns the number of pairs in the map.
+func (m *TMap) Length() int {
+	return m.forward.Length()
+}
+
+// Range calls do on each pair in the map. It accepts the same
+// functions as hashmap.TMap.Range.
+func (m *TMap) Range(do interface{}) {
+	m.forward.Range(do)
+}
+
+// String returns a string representation of the map.
+func (m *TMap) String() string {
+	var b strings.Builder
+	fmt.Fprint(&b, "{ ")
+	m.forward.Range(func(entry hashmap.Entry) {
+		fmt.Fprintf(&b, "%s ", entry)
+	})
+	fmt.Fprint(&b, "}")
+	return b.String()
+}
+
+// Equal tests if two transient bimaps hold the same pairs.
+func (m *TMap) Equal(o interface{}) bool {
+	other, ok := o.(*TMap)
+	if !ok {
+		return ok
+	}
+	return m.forward.Equal(other.forward)
+}
+
+// AsPersistent will transform this transient map into a persistent
+// map. Once this occurs any additional actions on the transient map
+// will fail.
+func (m *TMap) AsPersistent() *Map {
+	return newMap(m.forward.AsPersistent(), m.backward.AsPersistent())
+}
+
+// MakePersistent is a generic version of AsPersistent.
+func (m *TMap) MakePersistent() interface{} {
+	return m.AsPersistent()
+}
@@ -0,0 +1,167 @@
+package bimap
+
+import (
+	"testing"
+
+	"github.com/leanovate/gopter"
+	"github.com/leanovate/gopter/gen"
+	"github.com/leanovate/gopter/prop"
+	"jsouthworth.net/go/immutable/hashmap"
+)
+
+// op is an Assoc of key and value, a DeleteKey of key when delKey is
+// set or a DeleteValue of value when delValue is set.
+type op struct {
+	key, value       int
+	delKey, delValue bool
+}
+
+var genOps = gen.SliceOf(gopter.CombineGens(
+	gen.IntRange(0, 20),
+	gen.IntRange(0, 20),
+	gen.IntRange(0, 5),
+).Map(func(vs []interface{}) op {
+	kind := vs[2].(int)
+	return op{
+		key:      vs[0].(int),
+		value:    vs[1].(int),
+		delKey:   kind == 0,
+		delValue: kind == 1,
+	}
+}))
+
+func model(ops []op) map[int]int {
+	out := map[int]int{}
+	for _, o := range ops {
+		for k, v := range out {
+			if (o.delKey || !o.delValue) && k == o.key ||
+				(o.delValue || !o.delKey) && v == o.value {
+				delete(out, k)
+			}
+		}
+		if !o.delKey && !o.delValue {
+			out[o.key] = o.value
+		}
+	}
+	return out
+}
+
+func consistent(m *Map, want map[int]int) bool {
+	if m.Length() != len(want) || m.Inverse().Length() != len(want) {
+		return false
+	}
+	for k, v := range want {
+		gotV, okV := m.GetByKey(k)
+		gotK, okK := m.GetByValue(v)
+		invV, okI := m.Inverse().GetByValue(k)
+		if !okV || !okK || !okI || gotV != v || gotK != k || invV != v {
+			return false
+		}
+	}
+	return true
+}
+
+func TestMap(t *testing.T) {
+	parameters := gopter.DefaultTestParameters()
+	properties := gopter.NewProperties(parameters)
+	properties.Property("persistent operations stay one-to-one", prop.ForAll(
+		func(ops []op) bool {
+			m := Empty()
+			for _, o := range ops {
+				switch {
+				case o.delKey:
+					m = m.DeleteKey(o.key)
+				case o.delValue:
+					m = m.DeleteValue(o.value)
+				default:
+					m = m.Assoc(o.key, o.value)
+				}
+			}
+			return consistent(m, model(ops))
+		},
+		genOps,
+	))
+	properties.Property("transient operations stay one-to-one", prop.ForAll(
+		func(ops []op) bool {
+			t := Empty().AsTransient()
+			for _, o := range ops {
+				switch {
+				case o.delKey:
+					t.DeleteKey(o.key)
+				case o.delValue:
+					t.DeleteValue(o.value)
+				default:
+					t.Assoc(o.key, o.value)
+				}
+			}
+			return consistent(t.AsPersistent(), model(ops))
+		},
+		genOps,
+	))
+	properties.TestingRun(t)
+}
+
+func TestAssocEvicts(t *testing.T) {
+	m := New(1, "a", 2, "b")
+	m = m.Assoc(1, "b")
+	if m.Length() != 1 || m.At(1) != "b" || m.ContainsKey(2) || m.ContainsValue("a") {
+		t.Fatal("expected conflicting pairs to be evicted", m)
+	}
+	if m.Assoc(1, "b") != m {
+		t.Fatal("expected an unchanged map")
+	}
+}
+
+func TestInverse(t *testing.T) {
+	m := New(1, "a", 2, "b")
+	inv := m.Inverse()
+	if inv.Inverse() != m || m.Inverse() != inv {
+		t.Fatal("expected Inverse to be constant")
+	}
+	if inv.At("a") != 1 || !inv.Equal(New("a", 1, "b", 2)) {
+		t.Fatal("unexpected inverse", inv)
+	}
+	if !inv.Keys().Equal(m.Values()) {
+		t.Fatal("expected the inverse to share the maps")
+	}
+}
+
+func TestFrom(t *testing.T) {
+	want := New(1, "a", 2, "b")
+	tests := []interface{}{
+		hashmap.New(1, "a", 2, "b"),
+		[]hashmap.Entry{hashmap.EntryNew(1, "a"), hashmap.EntryNew(2, "b")},
+		[]interface{}{1, "a", 2, "b"},
+		map[int]string{1: "a", 2: "b"},
+		want.AsTransient(),
+	}
+	for _, test := range tests {
+		if got := From(test); !got.Equal(want) {
+			t.Fatalf("From(%T) = %v", test, got)
+		}
+	}
+	if From(want) != want || From(1).Length() != 0 {
+		t.Fatal("unexpected From")
+	}
+}
+
+func TestPanics(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		do   func()
+	}{
+		{"New", errOddElements, func() { New(1) }},
+		{"Transform", errTransformSig, func() { Empty().Transform(1) }},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			defer func() {
+				if r := recover(); r != test.err {
+					t.Fatal("expected", test.err, "got", r)
+				}
+			}()
+			test.do()
+		})
+	}
+}
@@ -0,0 +1,87 @@
+// Package compare provides helpers for building the comparison
+// functions used to order the keys of treemap and the elements of
+// treeset.
+//
+// A comparison function returns a negative number when a orders
+// before b, zero when they are equivalent and a positive number when
+// a orders after b. The helpers compose: for example a map ordered by
+// descending age and then ascending name may be created with
+//
+//	treemap.Empty(treemap.Compare(compare.Lexicographic(
+//		compare.Reverse(compare.ByField(age, dyn.Compare)),
+//		compare.ByField(name, dyn.Compare),
+//	)))
+package compare // import "jsouthworth.net/go/immutable/compare"
+
+import "reflect"
+
+// Func is the signature shared by all comparison functions.
+type Func = func(a, b interface{}) int
+
+// Reverse returns a comparison function that orders values in the
+// opposite order to cmp.
+func Reverse(cmp Func) Func {
+	return func(a, b interface{}) int {
+		return cmp(b, a)
+	}
+}
+
+// Lexicographic returns a comparison function that orders values by
+// the first of cmps that does not consider them equivalent. Values
+// are equivalent only if every one of cmps considers them so.
+func Lexicographic(cmps ...Func) Func {
+	return func(a, b interface{}) int {
+		for _, cmp := range cmps {
+			if c := cmp(a, b); c != 0 {
+				return c
+			}
+		}
+		return 0
+	}
+}
+
+// ByField returns a comparison function that orders values by
+// comparing the result of calling extract on each of them with cmp.
+func ByField(extract func(v interface{}) interface{}, cmp Func) Func {
+	return func(a, b interface{}) int {
+		return cmp(extract(a), extract(b))
+	}
+}
+
+// NullsFirst returns a comparison function that orders nil values
+// before all others and otherwise uses cmp. Both untyped nil and nil
+// pointers, maps, slices, channels and functions are treated as nil.
+func NullsFirst(cmp Func) Func {
+	return func(a, b interface{}) int {
+		switch an, bn := isNil(a), isNil(b); {
+		case an && bn:
+			return 0
+		case an:
+			return -1
+		case bn:
+			return 1
+		default:
+			return cmp(a, b)
+		}
+	}
+}
+
+// NullsLast returns a comparison function that orders nil values
+// after all others and otherwise uses cmp.
+func NullsLast(cmp Func) Func {
+	return Reverse(NullsFirst(Reverse(cmp)))
+}
+
+func isNil(v interface{}) bool {
+	if v == nil {
+		return true
+	}
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Ptr, reflect.Map, reflect.Slice, reflect.Chan,
+		reflect.Func, reflect.Interface:
+		return rv.IsNil()
+	default:
+		return false
+	}
+}
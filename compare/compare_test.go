@@ -0,0 +1,99 @@
+package compare
+
+import (
+	"testing"
+
+	"github.com/leanovate/gopter"
+	"github.com/leanovate/gopter/gen"
+	"github.com/leanovate/gopter/prop"
+	"jsouthworth.net/go/dyn"
+)
+
+type person struct {
+	name string
+	age  int
+}
+
+func sign(c int) int {
+	switch {
+	case c < 0:
+		return -1
+	case c > 0:
+		return 1
+	default:
+		return 0
+	}
+}
+
+func TestReverse(t *testing.T) {
+	parameters := gopter.DefaultTestParameters()
+	properties := gopter.NewProperties(parameters)
+	properties.Property("Reverse(cmp)(a, b) == cmp(b, a)", prop.ForAll(
+		func(a, b int) bool {
+			return sign(Reverse(dyn.Compare)(a, b)) ==
+				sign(dyn.Compare(b, a))
+		},
+		gen.Int(),
+		gen.Int(),
+	))
+	properties.TestingRun(t)
+}
+
+func TestLexicographic(t *testing.T) {
+	byAge := ByField(func(v interface{}) interface{} {
+		return v.(person).age
+	}, dyn.Compare)
+	byName := ByField(func(v interface{}) interface{} {
+		return v.(person).name
+	}, dyn.Compare)
+	cmp := Lexicographic(Reverse(byAge), byName)
+	parameters := gopter.DefaultTestParameters()
+	properties := gopter.NewProperties(parameters)
+	properties.Property("orders by the first differing field", prop.ForAll(
+		func(an, bn string, aa, ba int) bool {
+			a, b := person{an, aa}, person{bn, ba}
+			expected := sign(dyn.Compare(ba, aa))
+			if expected == 0 {
+				expected = sign(dyn.Compare(an, bn))
+			}
+			return sign(cmp(a, b)) == expected
+		},
+		gen.AlphaString(),
+		gen.AlphaString(),
+		gen.IntRange(0, 3),
+		gen.IntRange(0, 3),
+	))
+	properties.TestingRun(t)
+	if Lexicographic()(1, 2) != 0 {
+		t.Fatal("empty Lexicographic should consider all values equivalent")
+	}
+}
+
+func TestNulls(t *testing.T) {
+	var nilPtr *person
+	first := NullsFirst(func(a, b interface{}) int {
+		return dyn.Compare(a.(*person).age, b.(*person).age)
+	})
+	last := NullsLast(func(a, b interface{}) int {
+		return dyn.Compare(a.(*person).age, b.(*person).age)
+	})
+	young, old := &person{age: 1}, &person{age: 2}
+	tests := []struct {
+		name string
+		got  int
+		want int
+	}{
+		{"first nil nil", first(nil, nilPtr), 0},
+		{"first nil value", first(nil, young), -1},
+		{"first value nil", first(young, nilPtr), 1},
+		{"first values", first(young, old), -1},
+		{"last nil value", last(nilPtr, young), 1},
+		{"last value nil", last(young, nil), -1},
+		{"last values", last(old, young), 1},
+	}
+	for _, test := range tests {
+		if sign(test.got) != test.want {
+			t.Errorf("%s: expected %d, got %d", test.name, test.want, test.got)
+		}
+	}
+}
@@ -0,0 +1,495 @@
+// Package deque implements a persistent double-ended queue.
+package deque // import "jsouthworth.net/go/immutable/deque"
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"jsouthworth.net/go/dyn"
+	"jsouthworth.net/go/immutable/vector"
+	"jsouthworth.net/go/seq"
+)
+
+var errOutOfBounds = errors.New("index out of bounds")
+var errRangeSig = errors.New("Range requires a function: func(v vT) bool or func(v vT)")
+var errReduceSig = errors.New("Reduce requires a function: func(init iT, v vT) oT")
+var errTransformSig = errors.New("Transform requires functions: func(t *TDeque) *TDeque or func(t *TDeque)")
+var errTafterP = errors.New("transient used after persistent call")
+
+// Deque is a persistent double-ended queue.
+//
+// The deque is a 2-3 finger tree annotated with the size of each
+// subtree. Pushes and pops at either end take amortized constant time
+// and logarithmic time in the worst case, so popping an old version
+// of the deque again is never slower than that. At takes logarithmic
+// time.
+type Deque struct {
+	tree *ftree
+}
+
+var empty = Deque{}
+
+// Empty returns the empty deque.
+func Empty() *Deque {
+	return &empty
+}
+
+// New returns a deque holding elems from front to back.
+func New(elems ...interface{}) *Deque {
+	out := Empty().AsTransient()
+	for _, elem := range elems {
+		out = out.PushBack(elem)
+	}
+	return out.AsPersistent()
+}
+
+// From will convert many go types to a deque. Converting some types
+// is more efficient than others and the mechanisms are described
+// below.
+//
+// *Deque:
+//
+//	Used directly as it is already immutable.
+//
+// *TDeque:
+//
+//	AsPersistent is called on the value and the result used for the deque.
+//
+// Other:
+//
+//	The value is converted with vector.From and the vector's
+//	elements become the elements of the deque from front to back.
+func From(value interface{}) *Deque {
+	switch v := value.(type) {
+	case *Deque:
+		return v
+	case *TDeque:
+		return v.AsPersistent()
+	default:
+		out := Empty().AsTransient()
+		vector.From(value).Range(func(_ int, elem interface{}) {
+			out = out.PushBack(elem)
+		})
+		return out.AsPersistent()
+	}
+}
+
+// PushFront returns a new deque with the element at the front.
+func (d *Deque) PushFront(elem interface{}) *Deque {
+	return &Deque{
+		tree: d.tree.pushFront(elem, 0),
+	}
+}
+
+// PushBack returns a new deque with the element at the back.
+func (d *Deque) PushBack(elem interface{}) *Deque {
+	return &Deque{
+		tree: d.tree.pushBack(elem, 0),
+	}
+}
+
+// Conj returns a new deque with the element at the back.
+// Conj implements a generic mechanism for building collections.
+func (d *Deque) Conj(elem interface{}) interface{} {
+	return d.PushBack(elem)
+}
+
+// PopFront returns a new deque without the front element. Popping an
+// empty deque returns the empty deque.
+func (d *Deque) PopFront() *Deque {
+	if d.Length() <= 1 {
+		return Empty()
+	}
+	_, tree := d.tree.popFront(0)
+	return &Deque{
+		tree: tree,
+	}
+}
+
+// PopBack returns a new deque without the back element. Popping an
+// empty deque returns the empty deque.
+func (d *Deque) PopBack() *Deque {
+	if d.Length() <= 1 {
+		return Empty()
+	}
+	_, tree := d.tree.popBack(0)
+	return &Deque{
+		tree: tree,
+	}
+}
+
+// PeekFront returns the front element of the deque, or nil if the
+// deque is empty.
+func (d *Deque) PeekFront() interface{} {
+	return d.tree.peekFront()
+}
+
+// PeekBack returns the back element of the deque, or nil if the
+// deque is empty.
+func (d *Deque) PeekBack() interface{} {
+	return d.tree.peekBack()
+}
+
+// At returns the element at index i counting from the front. At will
+// panic if i is out of bounds.
+func (d *Deque) At(i int) interface{} {
+	return at(d.tree, i)
+}
+
+func at(tree *ftree, i int) interface{} {
+	if i < 0 || i >= tree.length() {
+		panic(errOutOfBounds)
+	}
+	return tree.at(i, 0)
+}
+
+// Length returns the number of elements in the deque.
+func (d *Deque) Length() int {
+	return d.tree.length()
+}
+
+// Range calls the passed in function on each element of the deque
+// from front to back. The function passed in may be of many types:
+//
+// func(value interface{}) bool:
+//
+//	Takes a value of any type and returns if the loop should continue.
+//	Useful to avoid reflection where not needed and to support
+//	heterogenous deques.
+//
+// func(value interface{})
+//
+//	Takes a value of any type.
+//	Useful to avoid reflection where not needed and to support
+//	heterogenous deques.
+//
+// func(value T) bool:
+//
+//	Takes a value of the type of element stored in the deque and
+//	returns if the loop should continue. Useful for homogeneous deques.
+//	Is called with reflection and will panic if the type is incorrect.
+//
+// func(value T)
+//
+//	Takes a value of the type of element stored in the deque and
+//	returns if the loop should continue. Useful for homogeneous deques.
+//	Is called with reflection and will panic if the type is incorrect.
+//
+// Range will panic if passed anything that doesn't match one of these signatures
+func (d *Deque) Range(do interface{}) {
+	// NOTE: Update other functions using the same pattern
+	//       when modifying the below.
+	//       This code is inlined to avoid heap allocation of
+	//       the closure.
+	var f func(value interface{}) bool
+	switch fn := do.(type) {
+	case func(value interface{}) bool:
+		f = fn
+	case func(value interface{}):
+		f = func(value interface{}) bool {
+			fn(value)
+			return true
+		}
+	default:
+		f = genRangeFunc(do)
+	}
+	d.tree.each(0, f)
+}
+
+func genRangeFunc(do interface{}) func(value interface{}) bool {
+	rv := reflect.ValueOf(do)
+	if rv.Kind() != reflect.Func {
+		panic(errRangeSig)
+	}
+	rt := rv.Type()
+	if rt.NumIn() != 1 || rt.NumOut() > 1 {
+		panic(errRangeSig)
+	}
+	if rt.NumOut() == 1 &&
+		rt.Out(0).Kind() != reflect.Bool {
+		panic(errRangeSig)
+	}
+	return func(value interface{}) bool {
+		out := dyn.Apply(do, value)
+		if out != nil {
+			return out.(bool)
+		}
+		return true
+	}
+}
+
+// Reduce is a fast mechanism for reducing a Deque from front to
+// back. Reduce can take the following types as the fn:
+//
+// func(init interface{}, value interface{}) interface{}
+// func(init iT, v vT) oT
+//
+// Reduce will panic if given any other function type.
+func (d *Deque) Reduce(fn interface{}, init interface{}) interface{} {
+	// NOTE: Update other functions using the same pattern
+	//       when modifying the below.
+	//       This code is inlined to avoid heap allocation of
+	//       the closure.
+	var rFn func(r, v interface{}) interface{}
+	switch f := fn.(type) {
+	case func(res, val interface{}) interface{}:
+		rFn = f
+	default:
+		rFn = genReduceFunc(fn)
+	}
+	res := init
+	d.tree.each(0, func(v interface{}) bool {
+		res = rFn(res, v)
+		return true
+	})
+	return res
+}
+
+func genReduceFunc(fn interface{}) func(r, v interface{}) interface{} {
+	rv := reflect.ValueOf(fn)
+	if rv.Kind() != reflect.Func {
+		panic(errReduceSig)
+	}
+	rt := rv.Type()
+	if rt.NumIn() != 2 {
+		panic(errReduceSig)
+	}
+	if rt.NumOut() != 1 {
+		panic(errReduceSig)
+	}
+	return func(r, v interface{}) interface{} {
+		return dyn.Apply(fn, r, v)
+	}
+}
+
+// Seq returns a representation of the deque as a sequence of its
+// elements from front to back.
+func (d *Deque) Seq() seq.Sequence {
+	if d.Length() == 0 {
+		return nil
+	}
+	return &dequeSequence{
+		deque: d,
+	}
+}
+
+// String returns a representation of the deque as a string.
+func (d *Deque) String() string {
+	b := new(strings.Builder)
+	fmt.Fprint(b, "[ ")
+	d.Range(func(item interface{}) {
+		fmt.Fprintf(b, "%v ", item)
+	})
+	fmt.Fprint(b, "]")
+	return b.String()
+}
+
+// Equal tests if two deques are Equal by comparing the elements of
+// each in order. Equal implements the Equaler which allows for deep
+// comparisons.
+func (d *Deque) Equal(o interface{}) bool {
+	other, ok := o.(*Deque)
+	if !ok || d.Length() != other.Length() {
+		return false
+	}
+	s := other.Seq()
+	return d.tree.each(0, func(v interface{}) bool {
+		equal := dyn.Equal(v, s.First())
+		s = s.Next()
+		return equal
+	})
+}
+
+// Apply takes an arbitrary number of arguments and returns the
+// value At the first argument. Apply allows deque to be called
+// as a function by the 'dyn' library.
+func (d *Deque) Apply(args ...interface{}) interface{} {
+	idx := args[0].(int)
+	return d.At(idx)
+}
+
+// AsTransient will return a mutable version of the deque.
+func (d *Deque) AsTransient() *TDeque {
+	return &TDeque{
+		tree: d.tree,
+	}
+}
+
+// MakeTransient is a generic version of AsTransient.
+func (d *Deque) MakeTransient() interface{} {
+	return d.AsTransient()
+}
+
+// Transform takes a set of actions and performs them
+// on the persistent deque. It does this by making a transient
+// deque and calling each action on it, then converting it back
+// to a persistent deque.
+// Each action may be a func(*TDeque) *TDeque, whose result is passed to
+// the following action unless it is nil, or a func(*TDeque).
+// Transform will panic if given any other type.
+func (d *Deque) Transform(actions ...interface{}) *Deque {
+	out := d.AsTransient()
+	for _, action := range actions {
+		switch fn := action.(type) {
+		case func(*TDeque) *TDeque:
+			if next := fn(out); next != nil {
+				out = next
+			}
+		case func(*TDeque):
+			fn(out)
+		default:
+			panic(errTransformSig)
+		}
+	}
+	return out.AsPersistent()
+}
+
+type dequeSequence struct {
+	deque *Deque
+}
+
+func (s *dequeSequence) First() interface{} {
+	return s.deque.PeekFront()
+}
+
+func (s *dequeSequence) Next() seq.Sequence {
+	return s.deque.PopFront().Seq()
+}
+
+func (s *dequeSequence) String() string {
+	return seq.ConvertToString(s)
+}
+
+// TDeque is a transient version of a deque. Changes made to a
+// transient deque occur as mutations and do not affect the persistent
+// deque it was made from.
+type TDeque struct {
+	tree *ftree
+	done bool
+}
+
+// PushFront places an element at the front of the deque. d is returned.
+func (d *TDeque) PushFront(elem interface{}) *TDeque {
+	d.ensureEditable()
+	d.tree = d.tree.pushFront(elem, 0)
+	return d
+}
+
+// PushBack places an element at the back of the deque. d is returned.
+func (d *TDeque) PushBack(elem interface{}) *TDeque {
+	d.ensureEditable()
+	d.tree = d.tree.pushBack(elem, 0)
+	return d
+}
+
+// Conj places an element at the back of the deque.
+// Conj implements a generic mechanism for building collections.
+func (d *TDeque) Conj(elem interface{}) interface{} {
+	return d.PushBack(elem)
+}
+
+// PopFront removes the front element of the deque. d is returned.
+func (d *TDeque) PopFront() *TDeque {
+	d.ensureEditable()
+	if d.tree != nil {
+		_, d.tree = d.tree.popFront(0)
+	}
+	return d
+}
+
+// PopBack removes the back element of the deque. d is returned.
+func (d *TDeque) PopBack() *TDeque {
+	d.ensureEditable()
+	if d.tree != nil {
+		_, d.tree = d.tree.popBack(0)
+	}
+	return d
+}
+
+// PeekFront returns the front element of the deque, or nil if the
+// deque is empty.
+func (d *TDeque) PeekFront() interface{} {
+	d.ensureEditable()
+	return d.tree.peekFront()
+}
+
+// PeekBack returns the back element of the deque, or nil if the
+// deque is empty.
+func (d *TDeque) PeekBack() interface{} {
+	d.ensureEditable()
+	return d.tree.peekBack()
+}
+
+// At returns the element at index i counting from the front. At will
+// panic if i is out of bounds.
+func (d *TDeque) At(i int) interface{} {
+	d.ensureEditable()
+	return at(d.tree, i)
+}
+
+// Length returns the number of elements in the deque.
+func (d *TDeque) Length() int {
+	d.ensureEditable()
+	return d.tree.length()
+}
+
+// Range calls the passed in function on each element of the deque
+// from front to back. The function may be of any of the types
+// accepted by Deque.Range.
+func (d *TDeque) Range(do interface{}) {
+	// NOTE: Update other functions using the same pattern
+	//       when modifying the below.
+	//       This code is inlined to avoid heap allocation of
+	//       the closure.
+	var f func(value interface{}) bool
+	switch fn := do.(type) {
+	case func(value interface{}) bool:
+		f = fn
+	case func(value interface{}):
+		f = func(value interface{}) bool {
+			fn(value)
+			return true
+		}
+	default:
+		f = genRangeFunc(do)
+	}
+	d.ensureEditable()
+	d.tree.each(0, f)
+}
+
+// String returns a representation of the deque as a string.
+func (d *TDeque) String() string {
+	b := new(strings.Builder)
+	fmt.Fprint(b, "[ ")
+	d.Range(func(item interface{}) {
+		fmt.Fprintf(b, "%v ", item)
+	})
+	fmt.Fprint(b, "]")
+	return b.String()
+}
+
+// AsPersistent returns an immutable version of the deque. Any
+// transient operations performed after this will cause a panic.
+func (d *TDeque) AsPersistent() *Deque {
+	d.ensureEditable()
+	d.done = true
+	if d.tree == nil {
+		return Empty()
+	}
+	return &Deque{
+		tree: d.tree,
+	}
+}
+
+// MakePersistent is a generic version of AsPersistent.
+func (d *TDeque) MakePersistent() interface{} {
+	return d.AsPersistent()
+}
+
+func (d *TDeque) ensureEditable() {
+	if d.done {
+		panic(errTafterP)
+	}
+}
@@ -0,0 +1,239 @@
+package deque
+
+import (
+	"testing"
+
+	"github.com/leanovate/gopter"
+	"github.com/leanovate/gopter/gen"
+	"github.com/leanovate/gopter/prop"
+	"jsouthworth.net/go/dyn"
+	"jsouthworth.net/go/seq"
+)
+
+const (
+	opPushFront = iota
+	opPushBack
+	opPopFront
+	opPopBack
+	numOps
+)
+
+// applyOps runs ops against a persistent deque, a transient deque and
+// a slice model and reports whether they agree after every step.
+func applyOps(ops []int) bool {
+	d := Empty()
+	t := Empty().AsTransient()
+	var model []int
+	for i, op := range ops {
+		switch op {
+		case opPushFront:
+			d = d.PushFront(i)
+			t = t.PushFront(i)
+			model = append([]int{i}, model...)
+		case opPushBack:
+			d = d.PushBack(i)
+			t = t.PushBack(i)
+			model = append(model, i)
+		case opPopFront:
+			d = d.PopFront()
+			t = t.PopFront()
+			if len(model) > 0 {
+				model = model[1:]
+			}
+		case opPopBack:
+			d = d.PopBack()
+			t = t.PopBack()
+			if len(model) > 0 {
+				model = model[:len(model)-1]
+			}
+		}
+		if !matches(d, model) || t.Length() != len(model) {
+			return false
+		}
+		if len(model) > 0 &&
+			(t.PeekFront() != model[0] ||
+				t.PeekBack() != model[len(model)-1]) {
+			return false
+		}
+	}
+	return t.AsPersistent().Equal(d)
+}
+
+func matches(d *Deque, model []int) bool {
+	if d.Length() != len(model) {
+		return false
+	}
+	if len(model) == 0 {
+		return d.PeekFront() == nil && d.PeekBack() == nil
+	}
+	if d.PeekFront() != model[0] || d.PeekBack() != model[len(model)-1] {
+		return false
+	}
+	for i, v := range model {
+		if d.At(i) != v {
+			return false
+		}
+	}
+	return true
+}
+
+func TestDequeOperations(t *testing.T) {
+	parameters := gopter.DefaultTestParameters()
+	properties := gopter.NewProperties(parameters)
+	properties.Property("deque matches a slice model", prop.ForAll(
+		applyOps,
+		gen.SliceOf(gen.IntRange(0, numOps-1)),
+	))
+	properties.Property("popping one end drains the other", prop.ForAll(
+		func(n int) bool {
+			d := Empty()
+			for i := 0; i < n; i++ {
+				d = d.PushFront(i)
+			}
+			for i := 0; i < n; i++ {
+				if d.PeekBack() != i {
+					return false
+				}
+				d = d.PopBack()
+			}
+			return d == Empty()
+		},
+		gen.IntRange(0, 500),
+	))
+	properties.Property("persistent versions are unchanged", prop.ForAll(
+		func(n int) bool {
+			d := New()
+			for i := 0; i < n; i++ {
+				d = d.PushBack(i)
+			}
+			popped := d.PopFront().PopBack()
+			return d.Length() == n && (n < 2 || popped.Length() == n-2) &&
+				(n == 0 || d.PeekFront() == 0 && d.PeekBack() == n-1)
+		},
+		gen.IntRange(0, 500),
+	))
+	properties.Property("long runs match a slice model", prop.ForAll(
+		applyOps,
+		gen.SliceOfN(3000, gen.IntRange(0, numOps-1)),
+	))
+	properties.TestingRun(t)
+}
+
+func TestDequeReusedVersion(t *testing.T) {
+	d := Empty()
+	for i := 0; i < 10000; i++ {
+		d = d.PushFront(i)
+	}
+	// Every element was pushed onto the front, so popping the back
+	// of this version again must not divide the deque again.
+	allocs := testing.AllocsPerRun(100, func() {
+		if d.PopBack().PeekBack() != 1 {
+			t.Fatal("unexpected back element")
+		}
+	})
+	if allocs > 64 {
+		t.Fatal("expected PopBack to take logarithmic time, allocations:", allocs)
+	}
+	for i := 0; i < 1000; i++ {
+		if p := d.PopBack(); p.Length() != 9999 || p.PeekBack() != 1 {
+			t.Fatal("unexpected deque after popping an old version", p.Length())
+		}
+	}
+}
+
+func TestTransientAfterPersistent(t *testing.T) {
+	tr := New(1, 2).AsTransient()
+	tr.AsPersistent()
+	defer func() {
+		if r := recover(); r != errTafterP {
+			t.Fatal("expected errTafterP got", r)
+		}
+	}()
+	tr.PushBack(3)
+}
+
+func TestDequeRangeReduceSeq(t *testing.T) {
+	d := New(2, 3).PushFront(1).PushBack(4)
+	var got []int
+	d.Range(func(v int) {
+		got = append(got, v)
+	})
+	if len(got) != 4 || got[0] != 1 || got[3] != 4 {
+		t.Fatal("unexpected range order", got)
+	}
+	sum := d.Reduce(func(res, v int) int {
+		return res + v
+	}, 0)
+	if sum != 10 {
+		t.Fatal("unexpected reduction", sum)
+	}
+	sum = seq.Reduce(func(res, v interface{}) interface{} {
+		return res.(int) + v.(int)
+	}, 0, d.Seq())
+	if sum != 10 {
+		t.Fatal("unexpected sequence reduction", sum)
+	}
+	if d.String() != "[ 1 2 3 4 ]" {
+		t.Fatal("unexpected string", d.String())
+	}
+	if Empty().Seq() != nil {
+		t.Fatal("expected nil sequence for empty deque")
+	}
+}
+
+func TestDequeEqual(t *testing.T) {
+	a := New(1, 2, 3)
+	b := Empty().PushFront(2).PushFront(1).PushBack(3)
+	if !dyn.Equal(a, b) {
+		t.Fatal("deques should have been equal")
+	}
+	if a.Equal(New(3, 2, 1)) || a.Equal(New(1, 2)) {
+		t.Fatal("deques should not have been equal")
+	}
+}
+
+func TestDequeFrom(t *testing.T) {
+	d := From([]int{1, 2, 3})
+	if d.Length() != 3 || d.At(0) != 1 || d.At(2) != 3 {
+		t.Fatal("unexpected deque from slice", d)
+	}
+	if From(d) != d {
+		t.Fatal("From should return a deque unchanged")
+	}
+	if From(d.AsTransient()).Length() != 3 {
+		t.Fatal("From should make a transient persistent")
+	}
+}
+
+func TestDequeAtOutOfBounds(t *testing.T) {
+	defer func() {
+		if r := recover(); r != errOutOfBounds {
+			t.Fatal("expected errOutOfBounds got", r)
+		}
+	}()
+	New(1, 2).At(2)
+}
+
+func TestTransformForms(t *testing.T) {
+	d := New(1, 2, 3)
+	got := d.Transform(
+		func(t *TDeque) *TDeque { return t.PopFront().PushBack(4) },
+		func(t *TDeque) { t.PopBack().PushFront(0) },
+		func(t *TDeque) *TDeque {
+			t.PushBack(5)
+			return nil
+		},
+	)
+	if !got.Equal(New(0, 2, 3, 5)) {
+		t.Fatal("unexpected deque", got)
+	}
+	if !d.Equal(New(1, 2, 3)) {
+		t.Fatal("transform changed the original", d)
+	}
+	defer func() {
+		if r := recover(); r != errTransformSig {
+			t.Fatal("expected errTransformSig got", r)
+		}
+	}()
+	d.Transform(func(t *TDeque) interface{} { return t.PeekFront() })
+}
@@ -0,0 +1,246 @@
+package deque
+
+// ftree is a 2-3 finger tree annotated with sizes. At depth 0 the
+// items of the tree are the elements of the deque; at each greater
+// depth they are nodes holding two or three items of the depth below.
+// The front and back digits hold between one and four items each
+// and the middle is a tree of the next depth. A nil tree is empty and
+// a tree without a back digit holds the single item in its front.
+//
+// Digits and nodes are never modified once made so they are shared
+// freely between trees.
+type ftree struct {
+	size   int
+	front  []interface{}
+	middle *ftree
+	back   []interface{}
+}
+
+type node struct {
+	size  int
+	items []interface{}
+}
+
+func itemSize(item interface{}, depth int) int {
+	if depth == 0 {
+		return 1
+	}
+	return item.(*node).size
+}
+
+func digitSize(items []interface{}, depth int) int {
+	var size int
+	for _, item := range items {
+		size += itemSize(item, depth)
+	}
+	return size
+}
+
+func newNode(depth int, items ...interface{}) *node {
+	return &node{
+		size:  digitSize(items, depth),
+		items: items,
+	}
+}
+
+func single(item interface{}, depth int) *ftree {
+	return &ftree{
+		size:  itemSize(item, depth),
+		front: []interface{}{item},
+	}
+}
+
+func deep(front []interface{}, middle *ftree, back []interface{}, depth int) *ftree {
+	return &ftree{
+		size:   digitSize(front, depth) + middle.length() + digitSize(back, depth),
+		front:  front,
+		middle: middle,
+		back:   back,
+	}
+}
+
+// prepend returns a new digit holding item followed by items.
+func prepend(item interface{}, items []interface{}) []interface{} {
+	out := make([]interface{}, len(items)+1)
+	out[0] = item
+	copy(out[1:], items)
+	return out
+}
+
+// appendItem returns a new digit holding items followed by item.
+func appendItem(items []interface{}, item interface{}) []interface{} {
+	out := make([]interface{}, len(items)+1)
+	copy(out, items)
+	out[len(items)] = item
+	return out
+}
+
+func (t *ftree) length() int {
+	if t == nil {
+		return 0
+	}
+	return t.size
+}
+
+func (t *ftree) pushFront(item interface{}, depth int) *ftree {
+	switch {
+	case t == nil:
+		return single(item, depth)
+	case t.back == nil:
+		return deep([]interface{}{item}, nil, t.front, depth)
+	case len(t.front) < 4:
+		return deep(prepend(item, t.front), t.middle, t.back, depth)
+	}
+	f := t.front
+	return deep([]interface{}{item, f[0]},
+		t.middle.pushFront(newNode(depth, f[1], f[2], f[3]), depth+1),
+		t.back, depth)
+}
+
+func (t *ftree) pushBack(item interface{}, depth int) *ftree {
+	switch {
+	case t == nil:
+		return single(item, depth)
+	case t.back == nil:
+		return deep(t.front, nil, []interface{}{item}, depth)
+	case len(t.back) < 4:
+		return deep(t.front, t.middle, appendItem(t.back, item), depth)
+	}
+	b := t.back
+	return deep(t.front,
+		t.middle.pushBack(newNode(depth, b[0], b[1], b[2]), depth+1),
+		[]interface{}{b[3], item}, depth)
+}
+
+// popFront returns the first item of a non-empty tree and the tree
+// without it.
+func (t *ftree) popFront(depth int) (interface{}, *ftree) {
+	head := t.front[0]
+	switch {
+	case t.back == nil:
+		return head, nil
+	case len(t.front) > 1:
+		return head, deep(t.front[1:], t.middle, t.back, depth)
+	case t.middle == nil:
+		return head, fromDigit(t.back, depth)
+	}
+	n, middle := t.middle.popFront(depth + 1)
+	return head, deep(n.(*node).items, middle, t.back, depth)
+}
+
+// popBack returns the last item of a non-empty tree and the tree
+// without it.
+func (t *ftree) popBack(depth int) (interface{}, *ftree) {
+	if t.back == nil {
+		return t.front[0], nil
+	}
+	last := len(t.back) - 1
+	tail := t.back[last]
+	switch {
+	case last > 0:
+		return tail, deep(t.front, t.middle, t.back[:last], depth)
+	case t.middle == nil:
+		return tail, fromDigit(t.front, depth)
+	}
+	n, middle := t.middle.popBack(depth + 1)
+	return tail, deep(t.front, middle, n.(*node).items, depth)
+}
+
+func fromDigit(items []interface{}, depth int) *ftree {
+	var out *ftree
+	for _, item := range items {
+		out = out.pushBack(item, depth)
+	}
+	return out
+}
+
+func (t *ftree) peekFront() interface{} {
+	if t == nil {
+		return nil
+	}
+	return t.front[0]
+}
+
+func (t *ftree) peekBack() interface{} {
+	switch {
+	case t == nil:
+		return nil
+	case t.back == nil:
+		return t.front[0]
+	default:
+		return t.back[len(t.back)-1]
+	}
+}
+
+// at returns the element at index i of the tree. The size of each
+// item is skipped over so only one path from the top of the tree to
+// the element is walked.
+func (t *ftree) at(i, depth int) interface{} {
+	for _, item := range t.front {
+		if n := itemSize(item, depth); i >= n {
+			i -= n
+			continue
+		}
+		return itemAt(item, i, depth)
+	}
+	if i < t.middle.length() {
+		return t.middle.at(i, depth+1)
+	}
+	i -= t.middle.length()
+	for _, item := range t.back {
+		if n := itemSize(item, depth); i >= n {
+			i -= n
+			continue
+		}
+		return itemAt(item, i, depth)
+	}
+	panic(errOutOfBounds)
+}
+
+func itemAt(item interface{}, i, depth int) interface{} {
+	for ; depth > 0; depth-- {
+		for _, child := range item.(*node).items {
+			n := itemSize(child, depth-1)
+			if i < n {
+				item = child
+				break
+			}
+			i -= n
+		}
+	}
+	return item
+}
+
+// each calls f on the elements of the tree from front to back and
+// reports whether every call returned true.
+func (t *ftree) each(depth int, f func(value interface{}) bool) bool {
+	if t == nil {
+		return true
+	}
+	for _, item := range t.front {
+		if !eachItem(item, depth, f) {
+			return false
+		}
+	}
+	if !t.middle.each(depth+1, f) {
+		return false
+	}
+	for _, item := range t.back {
+		if !eachItem(item, depth, f) {
+			return false
+		}
+	}
+	return true
+}
+
+func eachItem(item interface{}, depth int, f func(value interface{}) bool) bool {
+	if depth == 0 {
+		return f(item)
+	}
+	for _, child := range item.(*node).items {
+		if !eachItem(child, depth-1, f) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,56 @@
+package deque
+
+import (
+	"encoding/gob"
+	"errors"
+
+	"jsouthworth.net/go/immutable/internal/gobenc"
+)
+
+var errDecodeEmpty = errors.New("can not decode into the shared empty deque")
+
+func init() {
+	gob.Register(&Deque{})
+}
+
+// GobEncode encodes the deque for encoding/gob as its length
+// followed by its elements from front to back. Elements held in
+// interfaces must be of types registered with gob.Register.
+func (d *Deque) GobEncode() ([]byte, error) {
+	return gobenc.EncodeElems(func(yield func(interface{}) bool) {
+		d.Range(func(elem interface{}) bool {
+			return yield(elem)
+		})
+	})
+}
+
+// GobDecode replaces the contents of the deque with data produced by
+// GobEncode. GobDecode overwrites the deque in place, so it must only
+// be used on a deque that is not shared, such as the one
+// encoding/gob allocates when decoding into a nil *Deque.
+func (d *Deque) GobDecode(data []byte) error {
+	if d == Empty() {
+		return errDecodeEmpty
+	}
+	out := Empty().AsTransient()
+	err := gobenc.DecodeElems(data, func(elem interface{}) {
+		out = out.PushBack(elem)
+	})
+	if err != nil {
+		return err
+	}
+	*d = *out.AsPersistent()
+	return nil
+}
+
+// MarshalBinary implements encoding.BinaryMarshaler with the
+// encoding used by GobEncode.
+func (d *Deque) MarshalBinary() ([]byte, error) {
+	return d.GobEncode()
+}
+
+// UnmarshalBinary implements encoding.BinaryUnmarshaler. It is the
+// same as GobDecode.
+func (d *Deque) UnmarshalBinary(data []byte) error {
+	return d.GobDecode(data)
+}
@@ -0,0 +1,54 @@
+package deque
+
+import (
+	"testing"
+
+	"github.com/leanovate/gopter"
+	"github.com/leanovate/gopter/gen"
+	"github.com/leanovate/gopter/prop"
+)
+
+func TestGob(t *testing.T) {
+	parameters := gopter.DefaultTestParameters()
+	properties := gopter.NewProperties(parameters)
+	properties.Property("decode(encode(d)) keeps both ends", prop.ForAll(
+		func(front, back []int) bool {
+			d := Empty()
+			for _, elem := range front {
+				d = d.PushFront(elem)
+			}
+			for _, elem := range back {
+				d = d.PushBack(elem)
+			}
+			b, err := d.MarshalBinary()
+			if err != nil {
+				return false
+			}
+			out := new(Deque)
+			if err := out.UnmarshalBinary(b); err != nil {
+				return false
+			}
+			if !out.Equal(d) || out.Length() != d.Length() {
+				return false
+			}
+			for i := 0; i < d.Length(); i++ {
+				if out.At(i) != d.At(i) {
+					return false
+				}
+			}
+			out = out.PushFront(-1).PushBack(-2)
+			return out.PeekFront() == -1 && out.PeekBack() == -2 &&
+				out.Length() == d.Length()+2
+		},
+		gen.SliceOf(gen.Int()),
+		gen.SliceOf(gen.Int()),
+	))
+	properties.TestingRun(t)
+}
+
+func TestGobDecodeEmpty(t *testing.T) {
+	b, _ := New(1).MarshalBinary()
+	if err := Empty().UnmarshalBinary(b); err != errDecodeEmpty {
+		t.Fatal("expected decoding into Empty() to fail", err)
+	}
+}
@@ -0,0 +1,559 @@
+// Package hashbag implements a persistent bag, or multiset, on top
+// of hashmap.
+//
+// A bag is a set that remembers how many times each element was
+// added. The count of every element is kept in a hashmap.Map so
+// adding, removing and counting an element take the same time as the
+// corresponding map operations.
+package hashbag // import "jsouthworth.net/go/immutable/hashbag"
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"jsouthworth.net/go/dyn"
+	"jsouthworth.net/go/immutable/hashmap"
+	"jsouthworth.net/go/immutable/hashset"
+	"jsouthworth.net/go/seq"
+)
+
+var errRangeSig = errors.New("Range requires a function: func(v vT, count int) bool or func(v vT, count int)")
+var errTransformSig = errors.New("Transform requires functions: func(t *TBag) *TBag or func(t *TBag)")
+var errNegativeCount = errors.New("count must not be negative")
+
+// Bag is a persistent unordered multiset.
+type Bag struct {
+	counts *hashmap.Map
+	total  int
+}
+
+// Entry is an element of a bag along with the number of times it
+// occurs in the bag.
+type Entry interface {
+	Elem() interface{}
+	Count() int
+}
+
+type entry struct {
+	elem  interface{}
+	count int
+}
+
+func (e entry) Elem() interface{} {
+	return e.elem
+}
+
+func (e entry) Count() int {
+	return e.count
+}
+
+func (e entry) String() string {
+	return fmt.Sprintf("%v:%d", e.elem, e.count)
+}
+
+// Empty returns the empty bag.
+func Empty() *Bag {
+	return &Bag{
+		counts: hashmap.Empty(),
+	}
+}
+
+// New returns a bag containing the supplied elements. Elements that
+// are supplied more than once are counted each time.
+func New(elems ...interface{}) *Bag {
+	out := Empty().AsTransient()
+	for _, elem := range elems {
+		out = out.Add(elem)
+	}
+	return out.AsPersistent()
+}
+
+// From will convert many different go types to a bag.
+//
+// *Bag:
+//
+//	Returned directly as it is already immutable.
+//
+// *TBag:
+//
+//	AsPersistent is called on it and the result is returned.
+//
+// []interface{}:
+//
+//	The elements are passed to New.
+//
+// seq.Sequence:
+//
+//	Each element of the sequence is added to the bag.
+//
+// seq.Seqable:
+//
+//	Each element of the sequence returned by Seq is added to the bag.
+//
+// []T:
+//
+//	Reflection is used to add each element of the slice to the bag.
+//
+// Other:
+//
+//	Returns Empty()
+func From(value interface{}) *Bag {
+	switch v := value.(type) {
+	case *Bag:
+		return v
+	case *TBag:
+		return v.AsPersistent()
+	case []interface{}:
+		return New(v...)
+	case seq.Seqable:
+		return bagFromSequence(v.Seq())
+	case seq.Sequence:
+		return bagFromSequence(v)
+	default:
+		return bagFromReflection(value)
+	}
+}
+
+func bagFromSequence(coll seq.Sequence) *Bag {
+	out := Empty().AsTransient()
+	for s := coll; s != nil; s = s.Next() {
+		out = out.Add(s.First())
+	}
+	return out.AsPersistent()
+}
+
+func bagFromReflection(value interface{}) *Bag {
+	v := reflect.ValueOf(value)
+	if v.Kind() != reflect.Slice {
+		return Empty()
+	}
+	out := Empty().AsTransient()
+	for i := 0; i < v.Len(); i++ {
+		out = out.Add(v.Index(i).Interface())
+	}
+	return out.AsPersistent()
+}
+
+// Add returns a bag with one more occurrence of elem.
+func (b *Bag) Add(elem interface{}) *Bag {
+	return b.AddN(elem, 1)
+}
+
+// AddN returns a bag with n more occurrences of elem. AddN will panic
+// if n is negative.
+func (b *Bag) AddN(elem interface{}, n int) *Bag {
+	switch {
+	case n < 0:
+		panic(errNegativeCount)
+	case n == 0:
+		return b
+	}
+	return &Bag{
+		counts: b.counts.Assoc(elem, b.Count(elem)+n),
+		total:  b.total + n,
+	}
+}
+
+// Conj returns a bag with one more occurrence of elem. Conj
+// implements a generic mechanism for building collections.
+func (b *Bag) Conj(elem interface{}) interface{} {
+	return b.Add(elem)
+}
+
+// Remove returns a bag with one fewer occurrence of elem. If elem is
+// not in the bag, b is returned.
+func (b *Bag) Remove(elem interface{}) *Bag {
+	count := b.Count(elem)
+	switch count {
+	case 0:
+		return b
+	case 1:
+		return &Bag{
+			counts: b.counts.Delete(elem),
+			total:  b.total - 1,
+		}
+	default:
+		return &Bag{
+			counts: b.counts.Assoc(elem, count-1),
+			total:  b.total - 1,
+		}
+	}
+}
+
+// RemoveAll returns a bag without any occurrences of elem.
+func (b *Bag) RemoveAll(elem interface{}) *Bag {
+	count := b.Count(elem)
+	if count == 0 {
+		return b
+	}
+	return &Bag{
+		counts: b.counts.Delete(elem),
+		total:  b.total - count,
+	}
+}
+
+// Count returns the number of times elem occurs in the bag.
+func (b *Bag) Count(elem interface{}) int {
+	count, ok := b.counts.Find(elem)
+	if !ok {
+		return 0
+	}
+	return count.(int)
+}
+
+// Contains returns true if elem occurs in the bag at least once.
+func (b *Bag) Contains(elem interface{}) bool {
+	return b.counts.Contains(elem)
+}
+
+// Total returns the number of elements in the bag, counting each
+// occurrence.
+func (b *Bag) Total() int {
+	return b.total
+}
+
+// Length returns the number of distinct elements in the bag.
+func (b *Bag) Length() int {
+	return b.counts.Length()
+}
+
+// Distinct returns the set of elements that occur in the bag.
+func (b *Bag) Distinct() *hashset.Set {
+	return hashset.Empty().Transform(func(t *hashset.TSet) {
+		b.counts.Range(func(elem, _ interface{}) {
+			t.Add(elem)
+		})
+	})
+}
+
+// MostCommon returns the n elements that occur most often in the bag
+// ordered from the most common. Elements with equal counts are
+// returned in an unspecified order. If n is larger than the number of
+// distinct elements all of them are returned.
+func (b *Bag) MostCommon(n int) []Entry {
+	if n <= 0 {
+		return nil
+	}
+	all := make([]Entry, 0, b.Length())
+	b.counts.Range(func(elem, count interface{}) {
+		all = append(all, entry{elem: elem, count: count.(int)})
+	})
+	sort.Slice(all, func(i, j int) bool {
+		return all[i].Count() > all[j].Count()
+	})
+	if n < len(all) {
+		all = all[:n]
+	}
+	return all
+}
+
+// Union returns a bag where each element occurs as many times as it
+// does in whichever of b and other holds more of it.
+func (b *Bag) Union(other *Bag) *Bag {
+	return b.Transform(func(t *TBag) {
+		other.counts.Range(func(elem, count interface{}) {
+			if count.(int) > t.Count(elem) {
+				t.setCount(elem, count.(int))
+			}
+		})
+	})
+}
+
+// Intersection returns a bag where each element occurs as many times
+// as it does in whichever of b and other holds less of it.
+func (b *Bag) Intersection(other *Bag) *Bag {
+	small, large := b, other
+	if small.Length() > large.Length() {
+		small, large = large, small
+	}
+	return Empty().Transform(func(t *TBag) {
+		small.counts.Range(func(elem, count interface{}) {
+			n := count.(int)
+			if m := large.Count(elem); m < n {
+				n = m
+			}
+			t.AddN(elem, n)
+		})
+	})
+}
+
+// Sum returns a bag where each element occurs as many times as it
+// does in b and other together.
+func (b *Bag) Sum(other *Bag) *Bag {
+	return b.Transform(func(t *TBag) {
+		other.counts.Range(func(elem, count interface{}) {
+			t.AddN(elem, count.(int))
+		})
+	})
+}
+
+// Range calls the passed in function on each distinct element of the
+// bag along with the number of times it occurs. The function passed
+// in may be of many types:
+//
+// func(elem interface{}, count int) bool:
+//
+//	Takes an element of any type and its count and returns if the
+//	loop should continue. Useful to avoid reflection where not
+//	needed and to support heterogenous bags.
+//
+// func(elem interface{}, count int)
+//
+//	Takes an element of any type and its count.
+//	Useful to avoid reflection where not needed and to support
+//	heterogenous bags.
+//
+// func(elem T, count int) bool:
+//
+//	Takes an element of the type stored in the bag and its count
+//	and returns if the loop should continue.
+//	Is called with reflection and will panic if the type is incorrect.
+//
+// func(elem T, count int)
+//
+//	Takes an element of the type stored in the bag and its count.
+//	Is called with reflection and will panic if the type is incorrect.
+//
+// Range will panic if passed anything that doesn't match one of these signatures
+func (b *Bag) Range(do interface{}) {
+	// NOTE: Update other functions using the same pattern
+	//       when modifying the below.
+	//       This code is inlined to avoid heap allocation of
+	//       the closure.
+	var f func(elem, count interface{}) bool
+	switch fn := do.(type) {
+	case func(elem interface{}, count int) bool:
+		f = func(elem, count interface{}) bool {
+			return fn(elem, count.(int))
+		}
+	case func(elem interface{}, count int):
+		f = func(elem, count interface{}) bool {
+			fn(elem, count.(int))
+			return true
+		}
+	default:
+		f = genRangeFunc(do)
+	}
+	b.counts.Range(f)
+}
+
+func genRangeFunc(do interface{}) func(elem, count interface{}) bool {
+	rv := reflect.ValueOf(do)
+	if rv.Kind() != reflect.Func {
+		panic(errRangeSig)
+	}
+	rt := rv.Type()
+	if rt.NumIn() != 2 || rt.NumOut() > 1 {
+		panic(errRangeSig)
+	}
+	if rt.NumOut() == 1 &&
+		rt.Out(0).Kind() != reflect.Bool {
+		panic(errRangeSig)
+	}
+	return func(elem, count interface{}) bool {
+		out := dyn.Apply(do, elem, count)
+		if out != nil {
+			return out.(bool)
+		}
+		return true
+	}
+}
+
+// Seq returns a sequence of the elements of the bag in which each
+// element occurs as many times as it does in the bag.
+func (b *Bag) Seq() seq.Sequence {
+	return bagSeqNew(b.counts.Seq())
+}
+
+// String returns a representation of the bag as a string.
+func (b *Bag) String() string {
+	var sb strings.Builder
+	fmt.Fprint(&sb, "{ ")
+	b.counts.Range(func(elem, count interface{}) {
+		fmt.Fprintf(&sb, "%v:%v ", elem, count)
+	})
+	fmt.Fprint(&sb, "}")
+	return sb.String()
+}
+
+// Equal returns true if o is a bag in which every element occurs as
+// many times as it does in b.
+func (b *Bag) Equal(o interface{}) bool {
+	other, ok := o.(*Bag)
+	if !ok {
+		return ok
+	}
+	return b.total == other.total && b.counts.Equal(other.counts)
+}
+
+// AsTransient returns a transient bag that shares structure with
+// the persistent bag.
+func (b *Bag) AsTransient() *TBag {
+	return &TBag{
+		counts: b.counts.AsTransient(),
+		total:  b.total,
+	}
+}
+
+// MakeTransient is a generic version of AsTransient.
+func (b *Bag) MakeTransient() interface{} {
+	return b.AsTransient()
+}
+
+// Transform takes a set of actions and performs them
+// on the persistent bag. It does this by making a transient
+// bag and calling each action on it, then converting it back
+// to a persistent bag.
+// Each action may be a func(*TBag) *TBag, whose result is passed to
+// the following action unless it is nil, or a func(*TBag).
+// Transform will panic if given any other type.
+func (b *Bag) Transform(actions ...interface{}) *Bag {
+	out := b.AsTransient()
+	for _, action := range actions {
+		switch fn := action.(type) {
+		case func(*TBag) *TBag:
+			if next := fn(out); next != nil {
+				out = next
+			}
+		case func(*TBag):
+			fn(out)
+		default:
+			panic(errTransformSig)
+		}
+	}
+	return out.AsPersistent()
+}
+
+type bagSeq struct {
+	entries seq.Sequence
+	// left is the number of occurrences of the current element
+	// still to be returned, including this one.
+	left int
+}
+
+func bagSeqNew(entries seq.Sequence) seq.Sequence {
+	if entries == nil {
+		return nil
+	}
+	return &bagSeq{
+		entries: entries,
+		left:    entries.First().(hashmap.Entry).Value().(int),
+	}
+}
+
+func (s *bagSeq) First() interface{} {
+	return s.entries.First().(hashmap.Entry).Key()
+}
+
+func (s *bagSeq) Next() seq.Sequence {
+	if s.left > 1 {
+		return &bagSeq{
+			entries: s.entries,
+			left:    s.left - 1,
+		}
+	}
+	return bagSeqNew(s.entries.Next())
+}
+
+func (s *bagSeq) String() string {
+	return seq.ConvertToString(s)
+}
+
+// TBag is a transient version of a bag. Changes made to a transient
+// bag occur as mutations and do not affect the persistent bag it was
+// made from. Transient bags are useful when counting many elements
+// at once.
+type TBag struct {
+	counts *hashmap.TMap
+	total  int
+}
+
+// Add adds one occurrence of elem to the bag. b is returned.
+func (b *TBag) Add(elem interface{}) *TBag {
+	return b.AddN(elem, 1)
+}
+
+// AddN adds n occurrences of elem to the bag. b is returned. AddN will
+// panic if n is negative.
+func (b *TBag) AddN(elem interface{}, n int) *TBag {
+	switch {
+	case n < 0:
+		panic(errNegativeCount)
+	case n == 0:
+		return b
+	}
+	b.setCount(elem, b.Count(elem)+n)
+	return b
+}
+
+// Conj adds one occurrence of elem to the bag. Conj implements a
+// generic mechanism for building collections.
+func (b *TBag) Conj(elem interface{}) interface{} {
+	return b.Add(elem)
+}
+
+// Remove removes one occurrence of elem from the bag. b is returned.
+func (b *TBag) Remove(elem interface{}) *TBag {
+	if count := b.Count(elem); count > 0 {
+		b.setCount(elem, count-1)
+	}
+	return b
+}
+
+// RemoveAll removes every occurrence of elem from the bag. b is
+// returned.
+func (b *TBag) RemoveAll(elem interface{}) *TBag {
+	b.setCount(elem, 0)
+	return b
+}
+
+func (b *TBag) setCount(elem interface{}, count int) {
+	b.total += count - b.Count(elem)
+	if count == 0 {
+		b.counts = b.counts.Delete(elem)
+		return
+	}
+	b.counts = b.counts.Assoc(elem, count)
+}
+
+// Count returns the number of times elem occurs in the bag.
+func (b *TBag) Count(elem interface{}) int {
+	count, ok := b.counts.Find(elem)
+	if !ok {
+		return 0
+	}
+	return count.(int)
+}
+
+// Contains returns true if elem occurs in the bag at least once.
+func (b *TBag) Contains(elem interface{}) bool {
+	return b.counts.Contains(elem)
+}
+
+// Total returns the number of elements in the bag, counting each
+// occurrence.
+func (b *TBag) Total() int {
+	return b.total
+}
+
+// Length returns the number of distinct elements in the bag.
+func (b *TBag) Length() int {
+	return b.counts.Length()
+}
+
+// AsPersistent returns an immutable version of the bag. Any
+// transient operations performed after this will cause a panic.
+func (b *TBag) AsPersistent() *Bag {
+	return &Bag{
+		counts: b.counts.AsPersistent(),
+		total:  b.total,
+	}
+}
+
+// MakePersistent is a generic version of AsPersistent.
+func (b *TBag) MakePersistent() interface{} {
+	return b.AsPersistent()
+}
@@ -0,0 +1,174 @@
+package hashbag
+
+import (
+	"testing"
+
+	"github.com/leanovate/gopter"
+	"github.com/leanovate/gopter/gen"
+	"github.com/leanovate/gopter/prop"
+	"jsouthworth.net/go/immutable/hashset"
+	"jsouthworth.net/go/immutable/vector"
+)
+
+func countModel(xs []int) map[int]int {
+	out := map[int]int{}
+	for _, x := range xs {
+		out[x]++
+	}
+	return out
+}
+
+func matchesModel(b *Bag, want map[int]int) bool {
+	total := 0
+	for x, n := range want {
+		if b.Count(x) != n || !b.Contains(x) {
+			return false
+		}
+		total += n
+	}
+	seen := 0
+	for s := b.Seq(); s != nil; s = s.Next() {
+		if want[s.First().(int)] == 0 {
+			return false
+		}
+		seen++
+	}
+	return b.Total() == total && b.Length() == len(want) && seen == total
+}
+
+func TestBag(t *testing.T) {
+	parameters := gopter.DefaultTestParameters()
+	properties := gopter.NewProperties(parameters)
+	xsGen := gen.SliceOf(gen.IntRange(0, 20))
+	properties.Property("New counts each element", prop.ForAll(
+		func(xs []int) bool {
+			return matchesModel(From(xs), countModel(xs))
+		},
+		xsGen,
+	))
+	properties.Property("Remove undoes Add", prop.ForAll(
+		func(xs []int, x int) bool {
+			b := From(xs)
+			return b.Add(x).Remove(x).Equal(b) &&
+				b.AddN(x, 3).RemoveAll(x).Equal(b.RemoveAll(x))
+		},
+		xsGen, gen.IntRange(0, 20),
+	))
+	properties.Property("transient matches persistent", prop.ForAll(
+		func(xs, ys []int) bool {
+			b := From(xs)
+			p := b
+			for _, y := range ys {
+				p = p.Remove(y)
+			}
+			tr := b.AsTransient()
+			for _, y := range ys {
+				tr.Remove(y)
+			}
+			return tr.AsPersistent().Equal(p) && tr.Total() == p.Total()
+		},
+		xsGen, xsGen,
+	))
+	properties.Property("Union, Intersection and Sum", prop.ForAll(
+		func(xs, ys []int) bool {
+			a, b := From(xs), From(ys)
+			am, bm := countModel(xs), countModel(ys)
+			union, inter, sum := map[int]int{}, map[int]int{}, map[int]int{}
+			for x, n := range am {
+				union[x], sum[x] = n, n
+				if m := bm[x]; m > 0 {
+					inter[x] = n
+					if m < n {
+						inter[x] = m
+					}
+				}
+			}
+			for x, m := range bm {
+				if m > union[x] {
+					union[x] = m
+				}
+				sum[x] += m
+			}
+			return matchesModel(a.Union(b), union) &&
+				matchesModel(a.Intersection(b), inter) &&
+				matchesModel(a.Sum(b), sum)
+		},
+		xsGen, xsGen,
+	))
+	properties.Property("Distinct holds each element once", prop.ForAll(
+		func(xs []int) bool {
+			return From(xs).Distinct().Equal(hashset.From(xs))
+		},
+		xsGen,
+	))
+	properties.TestingRun(t)
+}
+
+func TestMostCommon(t *testing.T) {
+	b := New("a", "b", "b", "c", "c", "c")
+	got := b.MostCommon(2)
+	if len(got) != 2 ||
+		got[0].Elem() != "c" || got[0].Count() != 3 ||
+		got[1].Elem() != "b" || got[1].Count() != 2 {
+		t.Fatal("unexpected most common", got)
+	}
+	if got := b.MostCommon(10); len(got) != 3 {
+		t.Fatal("expected every element", got)
+	}
+	if got := b.MostCommon(0); got != nil {
+		t.Fatal("expected nothing", got)
+	}
+}
+
+func TestRange(t *testing.T) {
+	b := New(1, 1, 2)
+	total := 0
+	b.Range(func(x, n int) { total += x * n })
+	if total != 4 {
+		t.Fatal("unexpected reflective Range total", total)
+	}
+	calls := 0
+	b.Range(func(_ interface{}, _ int) bool {
+		calls++
+		return false
+	})
+	if calls != 1 {
+		t.Fatal("expected Range to stop", calls)
+	}
+}
+
+func TestFrom(t *testing.T) {
+	want := New(1, 2, 2)
+	if !From(vector.New(1, 2, 2)).Equal(want) {
+		t.Fatal("expected From to accept a seqable")
+	}
+	if !From(want.AsTransient()).Equal(want) || From(want) != want {
+		t.Fatal("expected From to accept bags")
+	}
+	if From(1).Total() != 0 {
+		t.Fatal("expected an empty bag")
+	}
+}
+
+func TestPanics(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		do   func()
+	}{
+		{"AddN", errNegativeCount, func() { Empty().AddN(1, -1) }},
+		{"TBag.AddN", errNegativeCount, func() { Empty().AsTransient().AddN(1, -1) }},
+		{"Range", errRangeSig, func() { Empty().Range(1) }},
+		{"Transform", errTransformSig, func() { Empty().Transform(1) }},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			defer func() {
+				if r := recover(); r != test.err {
+					t.Fatal("expected", test.err, "got", r)
+				}
+			}()
+			test.do()
+		})
+	}
+}
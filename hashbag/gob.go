@@ -0,0 +1,52 @@
+package hashbag
+
+import (
+	"encoding/gob"
+
+	"jsouthworth.net/go/immutable/internal/gobenc"
+)
+
+func init() {
+	gob.Register(&Bag{})
+}
+
+// GobEncode encodes the bag for encoding/gob as the number of
+// distinct elements followed by each element and its count. Elements
+// held in interfaces must be of types registered with gob.Register.
+func (b *Bag) GobEncode() ([]byte, error) {
+	return gobenc.EncodePairs(func(yield func(elem, count interface{}) bool) {
+		b.counts.Range(func(elem, count interface{}) bool {
+			return yield(elem, count)
+		})
+	})
+}
+
+// GobDecode replaces the contents of the bag with data produced by
+// GobEncode. GobDecode overwrites the bag in place, so it must only
+// be used on a bag that is not shared, such as the one encoding/gob
+// allocates when decoding into a nil *Bag.
+func (b *Bag) GobDecode(data []byte) error {
+	out := Empty().AsTransient()
+	err := gobenc.DecodePairs(data, func(elem, count interface{}) {
+		if n, ok := count.(int); ok && n > 0 {
+			out = out.AddN(elem, n)
+		}
+	})
+	if err != nil {
+		return err
+	}
+	*b = *out.AsPersistent()
+	return nil
+}
+
+// MarshalBinary implements encoding.BinaryMarshaler with the
+// encoding used by GobEncode.
+func (b *Bag) MarshalBinary() ([]byte, error) {
+	return b.GobEncode()
+}
+
+// UnmarshalBinary implements encoding.BinaryUnmarshaler. It is the
+// same as GobDecode.
+func (b *Bag) UnmarshalBinary(data []byte) error {
+	return b.GobDecode(data)
+}
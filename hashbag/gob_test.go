@@ -0,0 +1,38 @@
+package hashbag
+
+import (
+	"testing"
+
+	"jsouthworth.net/go/immutable/internal/gobenc"
+)
+
+func TestGobKeepsCounts(t *testing.T) {
+	bag := New("a", "a", "b").AddN("c", 3)
+	b, err := bag.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	out := new(Bag)
+	if err := out.UnmarshalBinary(b); err != nil || !out.Equal(bag) {
+		t.Fatal("unexpected decoding", out, err)
+	}
+	if out.Count("a") != 2 || out.Count("c") != 3 || out.Total() != 6 {
+		t.Fatal("expected the decoded bag to keep the counts", out)
+	}
+}
+
+func TestGobSkipsBadCounts(t *testing.T) {
+	b, err := gobenc.EncodePairs(func(yield func(elem, count interface{}) bool) {
+		_ = yield("a", 2) && yield("b", 0) && yield("c", -1) && yield("d", "x")
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	out := new(Bag)
+	if err := out.UnmarshalBinary(b); err != nil {
+		t.Fatal(err)
+	}
+	if out.Length() != 1 || out.Count("a") != 2 {
+		t.Fatal("expected elements without a positive count to be skipped", out)
+	}
+}
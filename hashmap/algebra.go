@@ -0,0 +1,637 @@
+package hashmap
+
+import (
+	"math/bits"
+
+	"jsouthworth.net/go/dyn"
+	"jsouthworth.net/go/hash"
+)
+
+// Union returns a map holding the entries of both m and other. When
+// a key is in both maps the entry from m is kept.
+//
+// The set operations below walk the two tries together when the maps
+// share a hash seed, which is the case for maps derived from one
+// another. Subtrees held by only one map, or by both, are reused
+// rather than rebuilt. Maps with different seeds, or where one map is
+// much smaller than the other, are combined by looking up the entries
+// of the smaller map in the larger one.
+func (m *Map) Union(other *Map) *Map {
+	switch {
+	case other.count == 0:
+		return m
+	case m.count == 0 && m.hashSeed == other.hashSeed:
+		return other
+	case m.probes(other) && m.count < other.count:
+		return other.Transform(func(t *TMap) {
+			m.Range(func(e Entry) {
+				t.Assoc(e.Key(), e.Value())
+			})
+		})
+	case m.probes(other):
+		return m.Transform(func(t *TMap) {
+			other.Range(func(e Entry) {
+				if !t.Contains(e.Key()) {
+					t.Assoc(e.Key(), e.Value())
+				}
+			})
+		})
+	}
+	return m.combine(other, opUnion)
+}
+
+// Intersection returns a map holding the entries of m whose keys are
+// also in other.
+func (m *Map) Intersection(other *Map) *Map {
+	switch {
+	case m.count == 0:
+		return m
+	case m.probes(other):
+		small, large := m, other
+		if small.count > large.count {
+			small, large = large, small
+		}
+		out := m.emptyLike().AsTransient()
+		small.Range(func(e Entry) {
+			if value, ok := m.Find(e.Key()); ok && large.Contains(e.Key()) {
+				out.Assoc(e.Key(), value)
+			}
+		})
+		return out.AsPersistent()
+	}
+	return m.combine(other, opIntersection)
+}
+
+// Difference returns a map holding the entries of m whose keys are
+// not in other.
+func (m *Map) Difference(other *Map) *Map {
+	switch {
+	case m.count == 0 || other.count == 0:
+		return m
+	case m.probes(other):
+		return m.Transform(func(t *TMap) {
+			if other.count < m.count {
+				other.Range(func(e Entry) {
+					t.Delete(e.Key())
+				})
+				return
+			}
+			m.Range(func(e Entry) {
+				if other.Contains(e.Key()) {
+					t.Delete(e.Key())
+				}
+			})
+		})
+	}
+	return m.combine(other, opDifference)
+}
+
+// SymmetricDifference returns a map holding the entries of m and
+// other whose keys are in only one of the two maps.
+func (m *Map) SymmetricDifference(other *Map) *Map {
+	switch {
+	case other.count == 0:
+		return m
+	case m.count == 0 && m.hashSeed == other.hashSeed:
+		return other
+	case m.probes(other) && m.count < other.count:
+		return other.Transform(func(t *TMap) {
+			m.Range(func(e Entry) {
+				if other.Contains(e.Key()) {
+					t.Delete(e.Key())
+				} else {
+					t.Assoc(e.Key(), e.Value())
+				}
+			})
+		})
+	case m.probes(other):
+		return m.Transform(func(t *TMap) {
+			other.Range(func(e Entry) {
+				if m.Contains(e.Key()) {
+					t.Delete(e.Key())
+				} else {
+					t.Assoc(e.Key(), e.Value())
+				}
+			})
+		})
+	}
+	return m.combine(other, opSymmetricDifference)
+}
+
+// KeysSubsetOf returns true if every key of m is also a key of other.
+// Values are not compared.
+func (m *Map) KeysSubsetOf(other *Map) bool {
+	switch {
+	case m.count > other.count:
+		return false
+	case m.count == 0:
+		return true
+	case m.hashSeed != other.hashSeed:
+		subset := true
+		m.Range(func(e Entry) bool {
+			subset = other.Contains(e.Key())
+			return subset
+		})
+		return subset
+	}
+	return subsetNode(m.hashSeed, 0, m.root, other.root)
+}
+
+// KeysDisjoint returns true if m and other have no keys in common.
+func (m *Map) KeysDisjoint(other *Map) bool {
+	switch {
+	case m.count == 0 || other.count == 0:
+		return true
+	case m.hashSeed != other.hashSeed:
+		small, large := m, other
+		if small.count > large.count {
+			small, large = large, small
+		}
+		disjoint := true
+		small.Range(func(e Entry) bool {
+			disjoint = !large.Contains(e.Key())
+			return disjoint
+		})
+		return disjoint
+	}
+	return disjointNode(m.hashSeed, 0, m.root, other.root)
+}
+
+// probes reports whether a set operation on m and other should look
+// up the entries of the smaller map in the larger one rather than walk
+// the two tries together. Walking the tries counts the entries of
+// every subtree held by only one map, so when one map holds n entries
+// and the other m entries with m·log n < n the lookups are cheaper.
+func (m *Map) probes(other *Map) bool {
+	if m.hashSeed != other.hashSeed {
+		return true
+	}
+	small, large := m.count, other.count
+	if small > large {
+		small, large = large, small
+	}
+	return small*bits.Len(uint(large)) < large
+}
+
+func (m *Map) emptyLike() *Map {
+	return &Map{
+		hashSeed: m.hashSeed,
+		root:     emptySeededBitmapNode(m.hashSeed),
+	}
+}
+
+type setOp int
+
+const (
+	opUnion setOp = iota
+	opIntersection
+	opDifference
+	opSymmetricDifference
+)
+
+// keepA reports whether entries found only in the first map are kept.
+func (op setOp) keepA() bool {
+	return op != opIntersection
+}
+
+// keepB reports whether entries found only in the second map are kept.
+func (op setOp) keepB() bool {
+	return op == opUnion || op == opSymmetricDifference
+}
+
+// keepBoth reports whether entries found in both maps are kept.
+func (op setOp) keepBoth() bool {
+	return op == opUnion || op == opIntersection
+}
+
+// merger combines two tries built with the same seed. onlyA and
+// onlyB count the keys held by just one of the tries, from which the
+// size of the result follows. Subtrees shared by both tries hold no
+// such keys so they are never walked.
+type merger struct {
+	op    setOp
+	seed  uintptr
+	onlyA int
+	onlyB int
+}
+
+// countA reports whether the size of the result depends on onlyA.
+func (mg *merger) countA() bool {
+	return mg.op != opUnion
+}
+
+// countB reports whether the size of the result depends on onlyB.
+func (mg *merger) countB() bool {
+	return mg.op.keepB()
+}
+
+func (m *Map) combine(other *Map, op setOp) *Map {
+	mg := merger{op: op, seed: m.hashSeed}
+	root := mg.merge(0, m.root, other.root)
+	switch root {
+	case m.root:
+		return m
+	case other.root:
+		return other
+	}
+	var count int
+	switch op {
+	case opUnion:
+		count = m.count + mg.onlyB
+	case opIntersection:
+		count = m.count - mg.onlyA
+	case opDifference:
+		count = mg.onlyA
+	case opSymmetricDifference:
+		count = mg.onlyA + mg.onlyB
+	}
+	if root == nil {
+		return m.emptyLike()
+	}
+	return &Map{
+		hashSeed: m.hashSeed,
+		count:    count,
+		root:     root,
+	}
+}
+
+// merge combines the nodes a and b found at the same position of the
+// two tries. It returns a when the result is the same as a and b when
+// it is the same as b so that unchanged subtrees are shared. An empty
+// result is nil.
+func (mg *merger) merge(shift uint, a, b node) node {
+	if a == b {
+		if mg.op.keepBoth() {
+			return a
+		}
+		return nil
+	}
+	_, aCollides := a.(*hashCollisionNode)
+	_, bCollides := b.(*hashCollisionNode)
+	if aCollides || bCollides {
+		return mg.mergeByKey(shift, a, b)
+	}
+	var out [width]entry
+	present, sameA, sameB := 0, true, true
+	for i := uint(0); i < width; i++ {
+		ea, hasA := slot(a, i)
+		eb, hasB := slot(b, i)
+		e, has, fromA, fromB := mg.mergeSlot(shift, ea, hasA, eb, hasB)
+		out[i] = e
+		if has {
+			present++
+		}
+		sameA = sameA && fromA
+		sameB = sameB && fromB
+	}
+	switch {
+	case sameA:
+		return a
+	case sameB:
+		return b
+	}
+	return mg.build(shift, &out, present)
+}
+
+// mergeSlot combines one slot of two nodes. It returns the resulting
+// entry, whether there is one and whether it is the same as the slot
+// of a or of b.
+func (mg *merger) mergeSlot(
+	shift uint,
+	ea entry, hasA bool,
+	eb entry, hasB bool,
+) (e entry, has, fromA, fromB bool) {
+	switch {
+	case !hasA && !hasB:
+		return entry{}, false, true, true
+	case !hasB:
+		if mg.countA() {
+			mg.onlyA += entrySize(ea)
+		}
+		if mg.op.keepA() {
+			return ea, true, true, false
+		}
+		return entry{}, false, false, true
+	case !hasA:
+		if mg.countB() {
+			mg.onlyB += entrySize(eb)
+		}
+		if mg.op.keepB() {
+			return eb, true, false, true
+		}
+		return entry{}, false, true, false
+	case !ea.isLeaf() && !eb.isLeaf():
+		child := mg.merge(shift+shiftBits, ea.v.(node), eb.v.(node))
+		if child == nil {
+			return entry{}, false, false, false
+		}
+		return entry{v: child}, true, child == ea.v, child == eb.v
+	case ea.isLeaf() && eb.isLeaf():
+		return mg.mergeLeaves(shift, ea, eb)
+	case ea.isLeaf():
+		return mg.mergeLeafIntoChild(shift, ea, eb.v.(node), true)
+	default:
+		return mg.mergeLeafIntoChild(shift, eb, ea.v.(node), false)
+	}
+}
+
+func (mg *merger) mergeLeaves(shift uint, ea, eb entry) (entry, bool, bool, bool) {
+	if dyn.Equal(ea.k, eb.k) {
+		if mg.op.keepBoth() {
+			return ea, true, true, equalValues(ea.v, eb.v)
+		}
+		return entry{}, false, false, false
+	}
+	mg.onlyA++
+	mg.onlyB++
+	switch {
+	case mg.op.keepA() && mg.op.keepB():
+		child, _ := emptySeededBitmapNode(mg.seed).
+			assoc(zero, shift+shiftBits, hash.Any(ea.k, mg.seed), ea.k, ea.v)
+		child, _ = child.
+			assoc(zero, shift+shiftBits, hash.Any(eb.k, mg.seed), eb.k, eb.v)
+		return entry{v: child}, true, false, false
+	case mg.op.keepA():
+		return ea, true, true, false
+	default:
+		return entry{}, false, false, false
+	}
+}
+
+// mergeLeafIntoChild combines a leaf with a child node in the same
+// slot. leafFromA is true when the leaf belongs to the first map.
+func (mg *merger) mergeLeafIntoChild(
+	shift uint,
+	leaf entry,
+	child node,
+	leafFromA bool,
+) (entry, bool, bool, bool) {
+	h := hash.Any(leaf.k, mg.seed)
+	value, found := child.find(shift+shiftBits, h, leaf.k)
+	// rest counts the keys of child other than the leaf's and lone
+	// is one when the leaf's key is not in child.
+	rest, lone := size(child), 1
+	if found {
+		rest, lone = rest-1, 0
+	}
+	if leafFromA {
+		mg.onlyA, mg.onlyB = mg.onlyA+lone, mg.onlyB+rest
+	} else {
+		mg.onlyA, mg.onlyB = mg.onlyA+rest, mg.onlyB+lone
+	}
+	var out node
+	switch {
+	case found && mg.op == opIntersection:
+		if leafFromA {
+			return leaf, true, true, false
+		}
+		return entry{k: leaf.k, v: value}, true, false, false
+	case found && mg.op == opUnion:
+		if !leafFromA {
+			return entry{v: child}, true, true, false
+		}
+		out, _ = child.assoc(zero, shift+shiftBits, h, leaf.k, leaf.v)
+	case found && mg.op == opDifference && leafFromA:
+		return entry{}, false, false, false
+	case found:
+		out, _ = child.without(zero, shift+shiftBits, h, leaf.k)
+	case mg.op == opIntersection:
+		return entry{}, false, false, false
+	case mg.op == opDifference:
+		if leafFromA {
+			return leaf, true, true, false
+		}
+		return entry{v: child}, true, true, false
+	default:
+		out, _ = child.assoc(zero, shift+shiftBits, h, leaf.k, leaf.v)
+	}
+	if out == nil {
+		return entry{}, false, false, false
+	}
+	same := out == child
+	return entry{v: out}, true, same && !leafFromA, same && leafFromA
+}
+
+// mergeByKey combines two nodes when at least one of them is a hash
+// collision node. Collision nodes hold few entries so the entries of
+// one node are applied to the other one at a time.
+func (mg *merger) mergeByKey(shift uint, a, b node) node {
+	var out node
+	found := 0
+	if mg.op == opIntersection {
+		out = emptySeededBitmapNode(mg.seed)
+		a.rnge(func(e Entry) bool {
+			h := hash.Any(e.Key(), mg.seed)
+			if _, ok := b.find(shift, h, e.Key()); ok {
+				found++
+				out, _ = out.assoc(zero, shift, h, e.Key(), e.Value())
+			}
+			return true
+		})
+		mg.onlyA += size(a) - found
+		mg.onlyB += size(b) - found
+	} else {
+		out = a
+		b.rnge(func(e Entry) bool {
+			h := hash.Any(e.Key(), mg.seed)
+			_, ok := a.find(shift, h, e.Key())
+			switch {
+			case ok:
+				found++
+				if !mg.op.keepBoth() {
+					out, _ = out.without(zero, shift, h, e.Key())
+				}
+			case mg.op.keepB():
+				out, _ = out.assoc(zero, shift, h, e.Key(), e.Value())
+			}
+			if out == nil {
+				out = emptySeededBitmapNode(mg.seed)
+			}
+			return true
+		})
+		mg.onlyA += size(a) - found
+		mg.onlyB += size(b) - found
+	}
+	if size(out) == 0 {
+		return nil
+	}
+	return out
+}
+
+// build makes a node at shift from the present slots of out.
+func (mg *merger) build(shift uint, out *[width]entry, present int) node {
+	switch {
+	case present == 0:
+		return nil
+	case present > bitmapCap:
+		var nodes array
+		for i, e := range out {
+			switch {
+			case e.isLeaf():
+				nodes[i], _ = emptySeededBitmapNode(mg.seed).
+					assoc(zero, shift+shiftBits,
+						hash.Any(e.k, mg.seed), e.k, e.v)
+			case e.v != nil:
+				nodes[i] = e.v.(node)
+			}
+		}
+		return &arrayNode{
+			seed:  mg.seed,
+			count: present,
+			array: nodes,
+			edit:  zero,
+		}
+	}
+	n := &bitmapIndexedNode{
+		seed:  mg.seed,
+		array: make(entries, 0, present),
+		edit:  zero,
+	}
+	for i, e := range out {
+		if e.isLeaf() || e.v != nil {
+			n.bitmap |= 1 << uint(i)
+			n.array = append(n.array, e)
+		}
+	}
+	return n
+}
+
+// slot returns the entry at index i of a bitmap or array node. Array
+// node children are returned as non-leaf entries.
+func slot(n node, i uint) (entry, bool) {
+	switch n := n.(type) {
+	case *bitmapIndexedNode:
+		bit := uint32(1) << i
+		if n.bitmap&bit == 0 {
+			return entry{}, false
+		}
+		e := n.array[n.index(bit)]
+		return e, e.isLeaf() || e.v != nil
+	case *arrayNode:
+		if n.array[i] == nil {
+			return entry{}, false
+		}
+		return entry{v: n.array[i]}, true
+	default:
+		return entry{}, false
+	}
+}
+
+// size counts the entries below n.
+func size(n node) int {
+	switch n := n.(type) {
+	case *bitmapIndexedNode:
+		count := 0
+		for _, e := range n.array {
+			count += entrySize(e)
+		}
+		return count
+	case *arrayNode:
+		count := 0
+		for _, child := range n.array {
+			if child != nil {
+				count += size(child)
+			}
+		}
+		return count
+	case *hashCollisionNode:
+		return len(n.array)
+	default:
+		return 0
+	}
+}
+
+// entrySize counts the entries held by a bitmap node entry.
+func entrySize(e entry) int {
+	switch {
+	case e.isLeaf():
+		return 1
+	case e.v != nil:
+		return size(e.v.(node))
+	default:
+		return 0
+	}
+}
+
+// subsetNode reports whether every key below a is also below b.
+func subsetNode(seed uintptr, shift uint, a, b node) bool {
+	if a == b {
+		return true
+	}
+	_, aCollides := a.(*hashCollisionNode)
+	_, bCollides := b.(*hashCollisionNode)
+	if aCollides || bCollides {
+		return a.rnge(func(e Entry) bool {
+			_, ok := b.find(shift, hash.Any(e.Key(), seed), e.Key())
+			return ok
+		})
+	}
+	for i := uint(0); i < width; i++ {
+		ea, hasA := slot(a, i)
+		if !hasA {
+			continue
+		}
+		eb, hasB := slot(b, i)
+		if !hasB {
+			return false
+		}
+		var ok bool
+		switch {
+		case !ea.isLeaf() && !eb.isLeaf():
+			ok = subsetNode(seed, shift+shiftBits, ea.v.(node), eb.v.(node))
+		case ea.isLeaf() && eb.isLeaf():
+			ok = dyn.Equal(ea.k, eb.k)
+		case ea.isLeaf():
+			_, ok = eb.v.(node).find(shift+shiftBits,
+				hash.Any(ea.k, seed), ea.k)
+		default:
+			ok = ea.v.(node).rnge(func(e Entry) bool {
+				return dyn.Equal(e.Key(), eb.k)
+			})
+		}
+		if !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// disjointNode reports whether no key below a is also below b.
+func disjointNode(seed uintptr, shift uint, a, b node) bool {
+	if a == b {
+		return size(a) == 0
+	}
+	_, aCollides := a.(*hashCollisionNode)
+	_, bCollides := b.(*hashCollisionNode)
+	if aCollides || bCollides {
+		return a.rnge(func(e Entry) bool {
+			_, ok := b.find(shift, hash.Any(e.Key(), seed), e.Key())
+			return !ok
+		})
+	}
+	for i := uint(0); i < width; i++ {
+		ea, hasA := slot(a, i)
+		eb, hasB := slot(b, i)
+		if !hasA || !hasB {
+			continue
+		}
+		var found bool
+		switch {
+		case !ea.isLeaf() && !eb.isLeaf():
+			found = !disjointNode(seed, shift+shiftBits,
+				ea.v.(node), eb.v.(node))
+		case ea.isLeaf() && eb.isLeaf():
+			found = dyn.Equal(ea.k, eb.k)
+		case ea.isLeaf():
+			_, found = eb.v.(node).find(shift+shiftBits,
+				hash.Any(ea.k, seed), ea.k)
+		default:
+			_, found = ea.v.(node).find(shift+shiftBits,
+				hash.Any(eb.k, seed), eb.k)
+		}
+		if found {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,232 @@
+package hashmap
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/leanovate/gopter"
+	"github.com/leanovate/gopter/gen"
+	"github.com/leanovate/gopter/prop"
+)
+
+// algebraKey returns i as a key, turning every seventh into a
+// hashCollider so the tries hold collision nodes.
+func algebraKey(i int) interface{} {
+	if i%7 == 0 {
+		return hashCollider(fmt.Sprint(i))
+	}
+	return i
+}
+
+type algebraCase struct {
+	base, aAdd, aDel, bAdd, bDel []int
+}
+
+// build returns two maps derived from a common map, and so sharing a
+// hash seed, along with models of their contents. When independent
+// is true the second map is built from scratch with its own seed.
+func (c algebraCase) build(independent bool) (a, b *Map, am, bm map[interface{}]interface{}) {
+	base := Empty().AsTransient()
+	am, bm = map[interface{}]interface{}{}, map[interface{}]interface{}{}
+	for _, i := range c.base {
+		base.Assoc(algebraKey(i), "base")
+		am[algebraKey(i)], bm[algebraKey(i)] = "base", "base"
+	}
+	shared := base.AsPersistent()
+	apply := func(m *Map, model map[interface{}]interface{}, add, del []int, v string) *Map {
+		return m.Transform(func(t *TMap) {
+			for _, i := range add {
+				t.Assoc(algebraKey(i), v)
+				model[algebraKey(i)] = v
+			}
+			for _, i := range del {
+				t.Delete(algebraKey(i))
+				delete(model, algebraKey(i))
+			}
+		})
+	}
+	a = apply(shared, am, c.aAdd, c.aDel, "a")
+	b = apply(shared, bm, c.bAdd, c.bDel, "b")
+	if independent {
+		b = From(bm)
+	}
+	return a, b, am, bm
+}
+
+var genAlgebraCase = gopter.CombineGens(
+	gen.SliceOf(gen.IntRange(0, 400)),
+	gen.SliceOf(gen.IntRange(0, 400)),
+	gen.SliceOf(gen.IntRange(0, 400)),
+	gen.SliceOf(gen.IntRange(0, 400)),
+	gen.SliceOf(gen.IntRange(0, 400)),
+).Map(func(vs []interface{}) algebraCase {
+	return algebraCase{
+		base: vs[0].([]int),
+		aAdd: vs[1].([]int),
+		aDel: vs[2].([]int),
+		bAdd: vs[3].([]int),
+		bDel: vs[4].([]int),
+	}
+})
+
+// matchesModel checks the contents of m against want, including that
+// the trie is still sound enough to delete every key from.
+func matchesModel(m *Map, want map[interface{}]interface{}) bool {
+	if m.Length() != len(want) {
+		return false
+	}
+	for k, v := range want {
+		if got, ok := m.Find(k); !ok || got != v {
+			return false
+		}
+	}
+	count := 0
+	for iter := m.Iterator(); iter.HasNext(); iter.Next() {
+		count++
+	}
+	if count != len(want) {
+		return false
+	}
+	for k := range want {
+		m = m.Delete(k)
+	}
+	return m.Length() == 0
+}
+
+func TestAlgebra(t *testing.T) {
+	parameters := gopter.DefaultTestParameters()
+	properties := gopter.NewProperties(parameters)
+	for _, independent := range []bool{false, true} {
+		name := fmt.Sprintf("(independent=%v)", independent)
+		properties.Property("Union "+name, prop.ForAll(
+			func(c algebraCase) bool {
+				a, b, am, bm := c.build(independent)
+				want := map[interface{}]interface{}{}
+				for k, v := range bm {
+					want[k] = v
+				}
+				for k, v := range am {
+					want[k] = v
+				}
+				return matchesModel(a.Union(b), want)
+			},
+			genAlgebraCase,
+		))
+		properties.Property("Intersection "+name, prop.ForAll(
+			func(c algebraCase) bool {
+				a, b, am, bm := c.build(independent)
+				want := map[interface{}]interface{}{}
+				for k, v := range am {
+					if _, ok := bm[k]; ok {
+						want[k] = v
+					}
+				}
+				return matchesModel(a.Intersection(b), want)
+			},
+			genAlgebraCase,
+		))
+		properties.Property("Difference "+name, prop.ForAll(
+			func(c algebraCase) bool {
+				a, b, am, bm := c.build(independent)
+				want := map[interface{}]interface{}{}
+				for k, v := range am {
+					if _, ok := bm[k]; !ok {
+						want[k] = v
+					}
+				}
+				return matchesModel(a.Difference(b), want)
+			},
+			genAlgebraCase,
+		))
+		properties.Property("SymmetricDifference "+name, prop.ForAll(
+			func(c algebraCase) bool {
+				a, b, am, bm := c.build(independent)
+				want := map[interface{}]interface{}{}
+				for k, v := range am {
+					if _, ok := bm[k]; !ok {
+						want[k] = v
+					}
+				}
+				for k, v := range bm {
+					if _, ok := am[k]; !ok {
+						want[k] = v
+					}
+				}
+				return matchesModel(a.SymmetricDifference(b), want)
+			},
+			genAlgebraCase,
+		))
+		properties.Property("KeysSubsetOf and KeysDisjoint "+name, prop.ForAll(
+			func(c algebraCase) bool {
+				a, b, am, bm := c.build(independent)
+				subset, disjoint := true, true
+				for k := range am {
+					_, ok := bm[k]
+					subset = subset && ok
+					disjoint = disjoint && !ok
+				}
+				return a.KeysSubsetOf(b) == subset &&
+					a.KeysDisjoint(b) == disjoint &&
+					a.Intersection(b).KeysSubsetOf(b) &&
+					a.Difference(b).KeysDisjoint(b)
+			},
+			genAlgebraCase,
+		))
+	}
+	properties.TestingRun(t)
+}
+
+func TestAlgebraSharing(t *testing.T) {
+	base := Empty().AsTransient()
+	for i := 0; i < 1000; i++ {
+		base.Assoc(i, i)
+	}
+	m := base.AsPersistent()
+	added := m.Assoc(1000, 1000)
+	if m.Union(m) != m || m.Intersection(m) != m {
+		t.Fatal("expected operations on the same map to return it")
+	}
+	if m.Union(added) != added || added.Union(m) != added {
+		t.Fatal("expected union with a subset to return the superset")
+	}
+	if added.Intersection(m) != m.Intersection(added) &&
+		!added.Intersection(m).Equal(m) {
+		t.Fatal("unexpected intersection")
+	}
+	if d := added.Difference(m); d.Length() != 1 || d.At(1000) != 1000 {
+		t.Fatal("unexpected difference", d)
+	}
+	if !m.KeysSubsetOf(added) || added.KeysSubsetOf(m) {
+		t.Fatal("unexpected subset result")
+	}
+}
+
+func TestAlgebraSmallAgainstLarge(t *testing.T) {
+	start := Empty()
+	large := start.Transform(func(t *TMap) {
+		for i := 0; i < 10000; i++ {
+			t.Assoc(i, "large")
+		}
+	})
+	small := start.Assoc(1, "small").Assoc(2, "small").Assoc(-1, "small")
+	if u := small.Union(large); u.Length() != 10001 ||
+		u.At(1) != "small" || u.At(3) != "large" {
+		t.Fatal("expected the union to keep the entries of the receiver", u.Length())
+	}
+	if u := large.Union(small); u.Length() != 10001 || u.At(1) != "large" {
+		t.Fatal("expected the union to keep the entries of the receiver", u.Length())
+	}
+	if i := large.Intersection(small); i.Length() != 2 || i.At(2) != "large" {
+		t.Fatal("unexpected intersection", i)
+	}
+	if d := large.Difference(small); d.Length() != 9998 || d.Contains(1) {
+		t.Fatal("unexpected difference", d.Length())
+	}
+	if d := small.Difference(large); d.Length() != 1 || d.At(-1) != "small" {
+		t.Fatal("unexpected difference", d)
+	}
+	if s := small.SymmetricDifference(large); s.Length() != 9999 ||
+		s.At(-1) != "small" || s.Contains(2) {
+		t.Fatal("unexpected symmetric difference", s.Length())
+	}
+}
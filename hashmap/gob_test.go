@@ -0,0 +1,59 @@
+package hashmap
+
+import (
+	"bytes"
+	"encoding/gob"
+	"testing"
+
+	"github.com/leanovate/gopter"
+	"github.com/leanovate/gopter/gen"
+	"github.com/leanovate/gopter/prop"
+)
+
+func init() {
+	gob.Register(hashCollider(""))
+}
+
+func TestGob(t *testing.T) {
+	parameters := gopter.DefaultTestParameters()
+	properties := gopter.NewProperties(parameters)
+	properties.Property("decode(encode(m)) == m", prop.ForAll(
+		func(keys []int) bool {
+			m := Empty().Transform(func(t *TMap) {
+				for _, k := range keys {
+					t.Assoc(algebraKey(k), k)
+				}
+			})
+			var buf bytes.Buffer
+			if err := gob.NewEncoder(&buf).Encode(m); err != nil {
+				return false
+			}
+			var out *Map
+			if err := gob.NewDecoder(&buf).Decode(&out); err != nil {
+				return false
+			}
+			return out.Equal(m) && matchesModel(out, m.AsNative())
+		},
+		gen.SliceOf(gen.IntRange(0, 400)),
+	))
+	properties.TestingRun(t)
+}
+
+func TestGobNestedMap(t *testing.T) {
+	m := New("a", New("b", 1), "c", Empty())
+	b, err := m.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	out := new(Map)
+	if err := out.UnmarshalBinary(b); err != nil || !out.Equal(m) {
+		t.Fatal("unexpected decoding", out, err)
+	}
+	inner, ok := out.At("a").(*Map)
+	if !ok || inner.At("b") != 1 {
+		t.Fatal("expected a nested map", out.At("a"))
+	}
+	if out.At("c").(*Map).Assoc("d", 2).Length() != 1 {
+		t.Fatal("expected the nested empty map to be usable")
+	}
+}
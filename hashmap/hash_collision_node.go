@@ -30,6 +30,7 @@ func (n *hashCollisionNode) assoc(
 	}
 	out := &bitmapIndexedNode{
 		edit:   edit,
+		seed:   n.seed,
 		bitmap: bitpos(n.hash, shift),
 		array:  []entry{entry{k: nil, v: n}},
 	}
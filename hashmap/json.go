@@ -0,0 +1,48 @@
+package hashmap
+
+import (
+	"jsouthworth.net/go/immutable/internal/jsonenc"
+)
+
+// MarshalJSON encodes the map as a JSON object if every key is a
+// string. Any other map is encoded as a JSON array of [key, value]
+// pairs, for example [[1,"a"],[2,"b"]]. The entries are sorted by
+// their encoded keys so that equal maps encode identically.
+func (m *Map) MarshalJSON() ([]byte, error) {
+	return jsonenc.EncodeMap(func(yield func(key, value interface{}) bool) {
+		m.Range(func(key, value interface{}) bool {
+			return yield(key, value)
+		})
+	}, true)
+}
+
+// UnmarshalJSON replaces the contents of the map with the entries of
+// a JSON object or of a JSON array of [key, value] pairs. Object keys
+// are decoded as strings; all other keys and values are decoded as
+// encoding/json decodes into an empty interface. Decoding null
+// leaves the map unchanged.
+//
+// UnmarshalJSON overwrites the map in place, so it must only be used
+// on a map that is not shared, such as the one encoding/json
+// allocates when decoding into a nil *Map.
+func (m *Map) UnmarshalJSON(data []byte) error {
+	return m.DecodeJSON(data, nil)
+}
+
+// DecodeJSON is UnmarshalJSON with each value, and each key of the
+// pair form, decoded by decode instead. A nil decode behaves like
+// UnmarshalJSON.
+func (m *Map) DecodeJSON(data []byte, decode func(data []byte) (interface{}, error)) error {
+	if jsonenc.IsNull(data) {
+		return nil
+	}
+	out := Empty().AsTransient()
+	err := jsonenc.DecodeMap(data, decode, func(key, value interface{}) {
+		out = out.Assoc(key, value)
+	})
+	if err != nil {
+		return err
+	}
+	*m = *out.AsPersistent()
+	return nil
+}
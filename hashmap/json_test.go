@@ -0,0 +1,65 @@
+package hashmap
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/leanovate/gopter"
+	"github.com/leanovate/gopter/gen"
+	"github.com/leanovate/gopter/prop"
+)
+
+func TestJSON(t *testing.T) {
+	parameters := gopter.DefaultTestParameters()
+	properties := gopter.NewProperties(parameters)
+	properties.Property("decode(encode(m)) == m", prop.ForAll(
+		func(m map[string]string) bool {
+			hm := From(m)
+			b, err := json.Marshal(hm)
+			if err != nil {
+				return false
+			}
+			var out *Map
+			if err := json.Unmarshal(b, &out); err != nil {
+				return false
+			}
+			return out.Equal(hm)
+		},
+		gen.MapOf(gen.AlphaString(), gen.AlphaString()),
+	))
+	properties.TestingRun(t)
+}
+
+func TestJSONEncoding(t *testing.T) {
+	tests := []struct {
+		m    *Map
+		want string
+	}{
+		{Empty(), `{}`},
+		{New("b", 2, "a", 1), `{"a":1,"b":2}`},
+		{New(2, "b", 1, "a"), `[[1,"a"],[2,"b"]]`},
+		{New("a", 1, 2, "b"), `[["a",1],[2,"b"]]`},
+	}
+	for _, test := range tests {
+		b, err := json.Marshal(test.m)
+		if err != nil || string(b) != test.want {
+			t.Fatal("unexpected encoding", test.m, string(b), err)
+		}
+	}
+}
+
+func TestJSONDecodePairs(t *testing.T) {
+	var out *Map
+	if err := json.Unmarshal([]byte(`[[1,"a"],["b",[2]]]`), &out); err != nil {
+		t.Fatal(err)
+	}
+	if out.Length() != 2 || out.At(1.0) != "a" {
+		t.Fatal("unexpected map", out)
+	}
+	if err := json.Unmarshal([]byte(`[[1]]`), &out); err == nil {
+		t.Fatal("expected an error for a short pair")
+	}
+	if err := json.Unmarshal([]byte(`"a"`), &out); err == nil {
+		t.Fatal("expected an error for a string")
+	}
+}
@@ -23,6 +23,7 @@ var errTafterP = errors.New("transient used after persistent call")
 var errOddElements = errors.New("must supply an even number elements")
 var errRangeSig = errors.New("Range requires a function: func(k kT, v vT) bool or func(k kT, v vT)")
 var errReduceSig = errors.New("Reduce requires a function: func(init iT, k kT, v vT) oT or func(init iT, e Entry) oT")
+var errTransformSig = errors.New("Transform requires functions: func(t *TMap) *TMap or func(t *TMap)")
 
 var zero = atomicZero()
 
@@ -436,10 +437,22 @@ func (m *Map) Apply(args ...interface{}) interface{} {
 // on the persistent map. It does this by making a transient
 // map and calling each action on it, then converting it back
 // to a persistent map.
-func (m *Map) Transform(actions ...func(*TMap) *TMap) *Map {
+// Each action may be a func(*TMap) *TMap, whose result is passed to
+// the following action unless it is nil, or a func(*TMap).
+// Transform will panic if given any other type.
+func (m *Map) Transform(actions ...interface{}) *Map {
 	out := m.AsTransient()
 	for _, action := range actions {
-		out = action(out)
+		switch fn := action.(type) {
+		case func(*TMap) *TMap:
+			if next := fn(out); next != nil {
+				out = next
+			}
+		case func(*TMap):
+			fn(out)
+		default:
+			panic(errTransformSig)
+		}
 	}
 	return out.AsPersistent()
 }
@@ -1759,3 +1759,27 @@ func TestReduce(t *testing.T) {
 			}
 		})
 }
+
+func TestTransformForms(t *testing.T) {
+	m := New("a", 1, "b", 2)
+	got := m.Transform(
+		func(t *TMap) { t.Delete("a").Delete("missing") },
+		func(t *TMap) *TMap { return t.Assoc("b", 20) },
+		func(t *TMap) *TMap {
+			t.Assoc("c", 3)
+			return nil
+		},
+	)
+	if !got.Equal(New("b", 20, "c", 3)) {
+		t.Fatalf("unexpected map %s", got)
+	}
+	if !m.Equal(New("a", 1, "b", 2)) {
+		t.Fatalf("transform changed the original %s", m)
+	}
+	defer func() {
+		if r := recover(); r != errTransformSig {
+			t.Fatalf("expected errTransformSig, got %v", r)
+		}
+	}()
+	m.Transform(func(t *TMap) interface{} { return t.At("b") })
+}
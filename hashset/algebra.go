@@ -0,0 +1,66 @@
+package hashset
+
+import (
+	"jsouthworth.net/go/immutable/hashmap"
+)
+
+// The set operations below are performed on the tries backing the
+// sets. Sets derived from one another, by adding to or deleting from
+// a common set, share a hash seed; their tries are walked together
+// and any subtrees that can be are reused in the result. Sets built
+// independently have different seeds and are combined by iterating
+// over the elements of one of them.
+
+// Union returns a set holding the elements that are in either s or
+// other.
+func (s *Set) Union(other *Set) *Set {
+	return s.fromMap(other, s.backingMap.Union(other.backingMap))
+}
+
+// Intersection returns a set holding the elements that are in both
+// s and other. The elements are taken from s.
+func (s *Set) Intersection(other *Set) *Set {
+	return s.fromMap(other, s.backingMap.Intersection(other.backingMap))
+}
+
+// Difference returns a set holding the elements of s that are not
+// in other.
+func (s *Set) Difference(other *Set) *Set {
+	return s.fromMap(other, s.backingMap.Difference(other.backingMap))
+}
+
+// SymmetricDifference returns a set holding the elements that are in
+// exactly one of s and other.
+func (s *Set) SymmetricDifference(other *Set) *Set {
+	return s.fromMap(other,
+		s.backingMap.SymmetricDifference(other.backingMap))
+}
+
+// IsSubset returns true if every element of s is in other.
+func (s *Set) IsSubset(other *Set) bool {
+	return s.backingMap.KeysSubsetOf(other.backingMap)
+}
+
+// IsSuperset returns true if every element of other is in s.
+func (s *Set) IsSuperset(other *Set) bool {
+	return other.backingMap.KeysSubsetOf(s.backingMap)
+}
+
+// Disjoint returns true if s and other have no elements in common.
+func (s *Set) Disjoint(other *Set) bool {
+	return s.backingMap.KeysDisjoint(other.backingMap)
+}
+
+// fromMap wraps the result of a map operation on s and other,
+// returning s or other unchanged when the map is theirs.
+func (s *Set) fromMap(other *Set, m *hashmap.Map) *Set {
+	switch m {
+	case s.backingMap:
+		return s
+	case other.backingMap:
+		return other
+	}
+	return &Set{
+		backingMap: m,
+	}
+}
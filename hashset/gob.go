@@ -0,0 +1,48 @@
+package hashset
+
+import (
+	"encoding/gob"
+
+	"jsouthworth.net/go/immutable/internal/gobenc"
+)
+
+func init() {
+	gob.Register(&Set{})
+}
+
+// GobEncode encodes the set for encoding/gob as its length followed
+// by its elements. Elements held in interfaces must be of types
+// registered with gob.Register.
+func (s *Set) GobEncode() ([]byte, error) {
+	return gobenc.EncodeElems(func(yield func(interface{}) bool) {
+		s.Range(func(elem interface{}) bool {
+			return yield(elem)
+		})
+	})
+}
+
+// GobDecode replaces the contents of the set with data produced by
+// GobEncode. Like UnmarshalJSON it overwrites the set in place.
+func (s *Set) GobDecode(data []byte) error {
+	out := Empty().AsTransient()
+	err := gobenc.DecodeElems(data, func(elem interface{}) {
+		out = out.Add(elem)
+	})
+	if err != nil {
+		return err
+	}
+	*s = *out.AsPersistent()
+	return nil
+}
+
+// MarshalBinary implements encoding.BinaryMarshaler with the
+// encoding used by GobEncode.
+func (s *Set) MarshalBinary() ([]byte, error) {
+	return s.GobEncode()
+}
+
+// UnmarshalBinary implements encoding.BinaryUnmarshaler. It is the
+// same as GobDecode.
+func (s *Set) UnmarshalBinary(data []byte) error {
+	return s.GobDecode(data)
+}
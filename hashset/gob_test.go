@@ -0,0 +1,34 @@
+package hashset
+
+import (
+	"bytes"
+	"encoding/gob"
+	"testing"
+
+	"github.com/leanovate/gopter"
+	"github.com/leanovate/gopter/gen"
+	"github.com/leanovate/gopter/prop"
+)
+
+func TestGob(t *testing.T) {
+	parameters := gopter.DefaultTestParameters()
+	properties := gopter.NewProperties(parameters)
+	properties.Property("decode(encode(s)) == s", prop.ForAll(
+		func(elems []string) bool {
+			s := From(elems)
+			var buf bytes.Buffer
+			if err := gob.NewEncoder(&buf).Encode(s); err != nil {
+				return false
+			}
+			var out *Set
+			if err := gob.NewDecoder(&buf).Decode(&out); err != nil {
+				return false
+			}
+			return out.Equal(s) &&
+				out.IsSubset(s) && s.IsSubset(out) &&
+				out.SymmetricDifference(s).Length() == 0
+		},
+		gen.SliceOf(gen.AlphaString()),
+	))
+	properties.TestingRun(t)
+}
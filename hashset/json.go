@@ -0,0 +1,43 @@
+package hashset
+
+import (
+	"jsouthworth.net/go/immutable/internal/jsonenc"
+)
+
+// MarshalJSON encodes the set as a JSON array of its elements. The
+// encoded elements are sorted so that equal sets encode identically.
+func (s *Set) MarshalJSON() ([]byte, error) {
+	return jsonenc.EncodeArray(func(yield func(interface{}) bool) {
+		s.Range(func(elem interface{}) bool {
+			return yield(elem)
+		})
+	}, true)
+}
+
+// UnmarshalJSON replaces the contents of the set with the elements
+// of a JSON array. Elements are decoded as encoding/json decodes
+// into an empty interface. Decoding null leaves the set unchanged.
+//
+// UnmarshalJSON overwrites the set in place, so it must only be used
+// on a set that is not shared, such as the one encoding/json
+// allocates when decoding into a nil *Set.
+func (s *Set) UnmarshalJSON(data []byte) error {
+	return s.DecodeJSON(data, nil)
+}
+
+// DecodeJSON is UnmarshalJSON with each element decoded by decode
+// instead. A nil decode behaves like UnmarshalJSON.
+func (s *Set) DecodeJSON(data []byte, decode func(data []byte) (interface{}, error)) error {
+	if jsonenc.IsNull(data) {
+		return nil
+	}
+	out := Empty().AsTransient()
+	err := jsonenc.DecodeArray(data, decode, func(elem interface{}) {
+		out = out.Add(elem)
+	})
+	if err != nil {
+		return err
+	}
+	*s = *out.AsPersistent()
+	return nil
+}
@@ -0,0 +1,39 @@
+package hashset
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/leanovate/gopter"
+	"github.com/leanovate/gopter/gen"
+	"github.com/leanovate/gopter/prop"
+)
+
+func TestJSON(t *testing.T) {
+	parameters := gopter.DefaultTestParameters()
+	properties := gopter.NewProperties(parameters)
+	properties.Property("decode(encode(s)) == s", prop.ForAll(
+		func(elems []string) bool {
+			s := From(elems)
+			b, err := json.Marshal(s)
+			if err != nil {
+				return false
+			}
+			var out *Set
+			if err := json.Unmarshal(b, &out); err != nil {
+				return false
+			}
+			return out.Equal(s)
+		},
+		gen.SliceOf(gen.AlphaString()),
+	))
+	properties.Property("equal sets encode identically", prop.ForAll(
+		func(elems []string) bool {
+			a, _ := json.Marshal(From(elems))
+			b, _ := json.Marshal(From(elems))
+			return string(a) == string(b)
+		},
+		gen.SliceOf(gen.AlphaString()),
+	))
+	properties.TestingRun(t)
+}
@@ -19,6 +19,7 @@ import (
 
 var errRangeSig = errors.New("Range requires a function: func(v vT) bool or func(v vT)")
 var errReduceSig = errors.New("Reduce requires a function: func(init iT, v vT) oT")
+var errTransformSig = errors.New("Transform requires functions: func(t *TSet) *TSet or func(t *TSet)")
 
 // Set is a persistent unordered set implementation.
 type Set struct {
@@ -278,12 +279,24 @@ func genReduceFunc(fn interface{}) func(r, k, v interface{}) interface{} {
 // on the persistent set. It does this by making a transient
 // set and calling each action on it, then converting it back
 // to a persistent set.
-func (s *Set) Transform(xfrms ...func(*TSet) *TSet) *Set {
-	t := s.AsTransient()
-	for _, xfrm := range xfrms {
-		t = xfrm(t)
+// Each action may be a func(*TSet) *TSet, whose result is passed to
+// the following action unless it is nil, or a func(*TSet).
+// Transform will panic if given any other type.
+func (s *Set) Transform(actions ...interface{}) *Set {
+	out := s.AsTransient()
+	for _, action := range actions {
+		switch fn := action.(type) {
+		case func(*TSet) *TSet:
+			if next := fn(out); next != nil {
+				out = next
+			}
+		case func(*TSet):
+			fn(out)
+		default:
+			panic(errTransformSig)
+		}
 	}
-	return t.AsPersistent()
+	return out.AsPersistent()
 }
 
 // Length returns the elements in the set.
@@ -765,3 +765,169 @@ func TestReduce(t *testing.T) {
 		}
 	})
 }
+
+func TestTransformForms(t *testing.T) {
+	s := New("a", "b").Transform(
+		func(t *TSet) *TSet { return t.Add("a").Add("c") },
+		func(t *TSet) { t.Delete("b") },
+		func(t *TSet) *TSet {
+			t.Delete("missing").Add("d")
+			return nil
+		},
+	)
+	if !s.Equal(New("a", "c", "d")) {
+		t.Fatalf("unexpected set %s", s)
+	}
+	defer func() {
+		if r := recover(); r != errTransformSig {
+			t.Fatalf("expected errTransformSig, got %v", r)
+		}
+	}()
+	s.Transform(func(t *TSet) bool { return t.Contains("a") })
+}
+
+func TestSetAlgebra(t *testing.T) {
+	native := func(s *Set) map[int]bool {
+		out := make(map[int]bool)
+		s.Range(func(elem interface{}) {
+			out[elem.(int)] = true
+		})
+		return out
+	}
+	// derived builds a and b from a common set so their tries share a
+	// hash seed; otherwise each set has its own seed.
+	build := func(base, as, bs []int, derived bool) (*Set, *Set) {
+		common := New()
+		if derived {
+			common = From(base)
+		} else {
+			as = append(append([]int{}, base...), as...)
+			bs = append(append([]int{}, base...), bs...)
+		}
+		addAll := func(elems []int) *Set {
+			return common.Transform(func(t *TSet) {
+				for _, elem := range elems {
+					if elem%3 == 0 {
+						t.Delete(elem)
+					} else {
+						t.Add(elem)
+					}
+				}
+			})
+		}
+		return addAll(as), addAll(bs)
+	}
+	expect := func(got *Set, want func(inA, inB bool) bool, a, b map[int]bool) bool {
+		g := native(got)
+		for k := range a {
+			if g[k] != want(true, b[k]) {
+				return false
+			}
+		}
+		for k := range b {
+			if g[k] != want(a[k], true) {
+				return false
+			}
+		}
+		for k := range g {
+			if !a[k] && !b[k] {
+				return false
+			}
+		}
+		return got.Length() == len(g)
+	}
+	genInts := gen.SliceOf(gen.IntRange(0, 500))
+	ops := []struct {
+		name string
+		op   func(a, b *Set) *Set
+		want func(inA, inB bool) bool
+	}{
+		{"Union", (*Set).Union, func(a, b bool) bool { return a || b }},
+		{"Intersection", (*Set).Intersection, func(a, b bool) bool { return a && b }},
+		{"Difference", (*Set).Difference, func(a, b bool) bool { return a && !b }},
+		{"SymmetricDifference", (*Set).SymmetricDifference, func(a, b bool) bool { return a != b }},
+	}
+	parameters := gopter.DefaultTestParameters()
+	properties := gopter.NewProperties(parameters)
+	for _, op := range ops {
+		op := op
+		properties.Property(op.name+" agrees with native sets", prop.ForAll(
+			func(base, as, bs []int, derived bool) bool {
+				a, b := build(base, as, bs, derived)
+				return expect(op.op(a, b), op.want, native(a), native(b))
+			},
+			genInts, genInts, genInts, gen.Bool(),
+		))
+	}
+	properties.Property("IsSubset and IsSuperset agree with native sets", prop.ForAll(
+		func(base, as, bs []int, derived bool) bool {
+			a, b := build(base, as, bs, derived)
+			na, nb := native(a), native(b)
+			subset := true
+			for k := range na {
+				subset = subset && nb[k]
+			}
+			return a.IsSubset(b) == subset && b.IsSuperset(a) == subset &&
+				a.IsSubset(a.Union(b)) && a.Union(b).IsSuperset(b) &&
+				a.Intersection(b).IsSubset(b)
+		},
+		genInts, genInts, genInts, gen.Bool(),
+	))
+	properties.Property("Disjoint agrees with Intersection", prop.ForAll(
+		func(base, as, bs []int, derived bool) bool {
+			a, b := build(base, as, bs, derived)
+			return a.Disjoint(b) == (a.Intersection(b).Length() == 0) &&
+				a.Difference(b).Disjoint(b)
+		},
+		genInts, genInts, genInts, gen.Bool(),
+	))
+	properties.TestingRun(t)
+}
+
+func TestSetAlgebraSharing(t *testing.T) {
+	s := New(1, 2, 3)
+	bigger := s.Add(4)
+	if s.Union(bigger) != bigger || bigger.Intersection(s).Length() != 3 {
+		t.Fatal("expected derived sets to share structure")
+	}
+	if s.Difference(New()) != s || s.Union(s) != s {
+		t.Fatal("expected unchanged results to return the set")
+	}
+}
+
+func BenchmarkSetAlgebra(b *testing.B) {
+	base := Empty().AsTransient()
+	for i := 0; i < 100000; i++ {
+		base.Add(i)
+	}
+	s := base.AsPersistent()
+	derived := s.Add(-1).Delete(0)
+	independent := Empty().Transform(func(t *TSet) {
+		derived.Range(func(elem interface{}) {
+			t.Add(elem)
+		})
+	})
+	b.Run("derived", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			s.Union(derived)
+		}
+	})
+	b.Run("independent", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			s.Union(independent)
+		}
+	})
+	// small shares the seed of s but holds only a few elements, so
+	// the operations below should take time in proportion to small.
+	small := s.Transform(func(t *TSet) {
+		for i := 12; i < 100000; i++ {
+			t.Delete(i)
+		}
+	})
+	b.Run("small", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			s.Intersection(small)
+			s.Difference(small)
+		}
+	})
+}
@@ -16,6 +16,10 @@ func (e Error) Error() string {
 }
 
 const ErrTafterP = Error("transient used after persistent call")
+const ErrOutOfBounds = Error("index out of bounds")
+const ErrOverlap = Error("joined trees must not overlap")
+const ErrNodeSize = Error("node size must be between 16 and 1024")
+const ErrNodeSizeMismatch = Error("joined trees must use the same node size")
 
 type BTree struct {
 	root    node
@@ -23,29 +27,57 @@ type BTree struct {
 	version int
 	edit    *atomic.Bool
 
-	cmp compareFunc
-	eq  eqFunc
+	cmp   compareFunc
+	eq    eqFunc
+	order *ordering
 }
 
 var emptyEdit = atomic.NewBool(false)
 
+// ordering identifies the comparison function of a tree. Comparing
+// the functions themselves is not enough since every closure made by
+// the same function literal shares its code pointer.
+type ordering struct {
+	cmp compareFunc
+}
+
+var defaultOrdering = &ordering{cmp: dyn.Compare}
+
 var empty = &BTree{
-	root: newLeaf(0, emptyEdit),
-	edit: emptyEdit,
-	cmp:  dyn.Compare,
-	eq:   dyn.Equal,
+	root:  newLeaf(0, DefaultNodeSize, emptyEdit),
+	edit:  emptyEdit,
+	cmp:   dyn.Compare,
+	eq:    dyn.Equal,
+	order: defaultOrdering,
 }
 
 type btreeOptions struct {
-	cmp compareFunc
-	eq  eqFunc
+	order    *ordering
+	eq       eqFunc
+	nodeSize int
 }
 
 type Option func(*btreeOptions)
 
+// Compare sets the function used to order the keys of the tree.
+// Every tree made with the same Compare option, and every tree
+// derived from one, shares an ordering as reported by SameOrder.
+// Calling Compare again, even with the same function, makes a
+// distinct ordering.
 func Compare(cmp func(k1, k2 interface{}) int) Option {
+	order := &ordering{cmp: cmp}
 	return func(opts *btreeOptions) {
-		opts.cmp = cmp
+		opts.order = order
+	}
+}
+
+// Ordering returns an option that gives a tree the ordering of t.
+// Trees made with it share an ordering with t as reported by
+// SameOrder.
+func (t *BTree) Ordering() Option {
+	order := t.order
+	return func(opts *btreeOptions) {
+		opts.order = order
 	}
 }
 
@@ -55,24 +87,46 @@ func Equal(eq func(k1, k2 interface{}) bool) Option {
 	}
 }
 
+// DefaultNodeSize is the node size used when NodeSize is not
+// supplied.
+const DefaultNodeSize = 64
+
+// NodeSize sets the maximum number of entries held by each node of
+// the tree. Wider nodes make the tree shallower and improve locality
+// which favours keys that are cheap to compare. Narrower nodes copy
+// less on each update and need fewer comparisons to search which
+// favours keys that are expensive to compare. The size must be
+// between 16 and 1024; Empty panics with ErrNodeSize otherwise. The
+// default is 64.
+func NodeSize(size int) Option {
+	return func(opts *btreeOptions) {
+		opts.nodeSize = size
+	}
+}
+
 func Empty(options ...Option) *BTree {
 	if len(options) == 0 {
 		return empty
 	}
 
 	opts := btreeOptions{
-		cmp: dyn.Compare,
-		eq:  dyn.Equal,
+		order:    defaultOrdering,
+		eq:       dyn.Equal,
+		nodeSize: DefaultNodeSize,
 	}
 	for _, option := range options {
 		option(&opts)
 	}
+	if opts.nodeSize < minNodeSize || opts.nodeSize > maxNodeSize {
+		panic(ErrNodeSize)
+	}
 
 	return &BTree{
-		root: newLeaf(0, emptyEdit),
-		edit: emptyEdit,
-		cmp:  opts.cmp,
-		eq:   opts.eq,
+		root:  newLeaf(0, opts.nodeSize, emptyEdit),
+		edit:  emptyEdit,
+		cmp:   opts.order.cmp,
+		eq:    opts.eq,
+		order: opts.order,
 	}
 }
 
@@ -106,13 +160,14 @@ func (t *BTree) Add(key interface{}) *BTree {
 			edit:    t.edit,
 			cmp:     t.cmp,
 			eq:      t.eq,
+			order:   t.order,
 		}
 	default:
-		nr := newNode(2, t.edit)
+		nr := newNode(2, t.nodeSize(), t.edit)
 		nr.keys[0] = ret.nodes[0].maxKey()
 		nr.keys[1] = ret.nodes[1].maxKey()
 		copy(nr.children, ret.nodes[:])
-		newRoot = nr
+		newRoot = nr.recount()
 	}
 	return &BTree{
 		root:    newRoot,
@@ -121,9 +176,25 @@ func (t *BTree) Add(key interface{}) *BTree {
 		edit:    t.edit,
 		cmp:     t.cmp,
 		eq:      t.eq,
+		order:   t.order,
 	}
 }
 
+// Rank returns the number of keys in the tree that are before key.
+// When key is in the tree this is its index.
+func (t *BTree) Rank(key interface{}) int {
+	return t.root.rank(key, t.cmp)
+}
+
+// Nth returns the key at index i of the tree in sorted order. It
+// panics if i is out of bounds.
+func (t *BTree) Nth(i int) interface{} {
+	if i < 0 || i >= t.count {
+		panic(ErrOutOfBounds)
+	}
+	return t.root.nth(i)
+}
+
 func (t *BTree) Delete(key interface{}) *BTree {
 	ret := t.root.remove(key, nil, nil, t.cmp, t.edit)
 	if ret.status == returnUnchanged {
@@ -140,6 +211,7 @@ func (t *BTree) Delete(key interface{}) *BTree {
 		edit:    t.edit,
 		cmp:     t.cmp,
 		eq:      t.eq,
+		order:   t.order,
 	}
 }
 
@@ -154,81 +226,100 @@ func (t *BTree) String() string {
 }
 
 func (t *BTree) Iterator() Iterator {
-	i := makeIterator(t.root)
-	i.HasNext() // Make sure the initial iterator value is valid
-	return i
+	return makeIterator(t.root, t.cmp)
 }
 
-type Iterator struct {
-	depth int
-	stack [maxIterDepth]struct {
-		n   node
-		cur int
-	}
+// IteratorRange returns an iterator over the keys that lie between
+// lo and hi. The iterator is positioned on the first key with a
+// single descent of the tree and stops once a key exceeds hi.
+func (t *BTree) IteratorRange(lo, hi Bound) Iterator {
+	return makeRangeIterator(t.root, t.cmp, lo, hi, false)
 }
 
-func makeIterator(n node) Iterator {
-	var i Iterator
-	i.stack[0].n = n
-	return i
+// ReverseIterator returns an iterator that walks the keys of the
+// tree from largest to smallest.
+func (t *BTree) ReverseIterator() Iterator {
+	return makeRangeIterator(t.root, t.cmp, Unbounded(), Unbounded(), true)
 }
 
-func (i *Iterator) Next() interface{} {
-	state := i.stack[i.depth]
-	n := state.n.(*leafNode)
-	out := n.keys[state.cur]
-	i.stack[i.depth].cur++
-	return out
+// ReverseIteratorRange returns an iterator that walks the keys
+// between lo and hi from largest to smallest.
+func (t *BTree) ReverseIteratorRange(lo, hi Bound) Iterator {
+	return makeRangeIterator(t.root, t.cmp, lo, hi, true)
 }
 
-func (i *Iterator) HasNext() bool {
-	state := i.stack[i.depth]
-	switch n := state.n.(type) {
-	case *leafNode:
-		if state.cur < n.len {
-			return true
-		}
-		if i.depth == 0 {
-			return false
-		}
-		i.popNode()
-		return i.HasNext()
-	case *internalNode:
-		if state.cur < n.len {
-			child := n.children[state.cur]
-			i.stack[i.depth].cur++
-			i.pushNode(child)
-			switch child.(type) {
-			case *leafNode:
-				return true
-			case *internalNode:
-				return i.HasNext()
-			}
-		}
-		if i.depth == 0 {
-			return false
-		}
-		i.popNode()
-		return i.HasNext()
-	default:
-		return false
+// CountRange returns the number of keys between lo and hi.
+func (t *BTree) CountRange(lo, hi Bound) int {
+	return countRange(t.root, t.count, t.cmp, lo, hi)
+}
+
+func countRange(root node, count int, cmp compareFunc, lo, hi Bound) int {
+	start := 0
+	if lo.bounded {
+		start = boundRank(root, cmp, lo.key, !lo.inclusive)
+	}
+	end := count
+	if hi.bounded {
+		end = boundRank(root, cmp, hi.key, hi.inclusive)
+	}
+	return max(end-start, 0)
+}
+
+// boundRank returns the number of keys before key, counting key
+// itself when withKey is true.
+func boundRank(root node, cmp compareFunc, key interface{}, withKey bool) int {
+	rank := root.rank(key, cmp)
+	if _, found := root.find(key, cmp); withKey && found {
+		rank++
 	}
+	return rank
+}
+
+// Compare compares two keys using the tree's comparison function.
+func (t *BTree) Compare(k1, k2 interface{}) int {
+	return t.cmp(k1, k2)
 }
 
-func (i *Iterator) pushNode(n node) {
-	i.depth = i.depth + 1
-	state := i.stack[i.depth]
-	state.n = n
-	state.cur = 0
-	i.stack[i.depth] = state
+// Comparator returns the tree's comparison function.
+func (t *BTree) Comparator() func(k1, k2 interface{}) int {
+	return t.cmp
 }
 
-func (i *Iterator) popNode() {
-	state := i.stack[i.depth]
-	state.n = nil
-	state.cur = 0
-	i.stack[i.depth] = state
-	i.depth = i.depth - 1
+// SameOrder reports whether t and o share an ordering, that is
+// whether both were derived from trees made with the same Compare
+// option or both use the default comparison. Trees that do not share
+// an ordering may still sort their keys the same way but this can not
+// be known.
+func (t *BTree) SameOrder(o *BTree) bool {
+	return t.order == o.order
+}
+
+// InRange returns whether key lies between lo and hi using the
+// tree's comparison function.
+func (t *BTree) InRange(key interface{}, lo, hi Bound) bool {
+	return lo.below(key, t.cmp) && hi.above(key, t.cmp)
+}
+
+// SameNodeSize reports whether t and o were made with the same
+// NodeSize option.
+func (t *BTree) SameNodeSize(o *BTree) bool {
+	return t.nodeSize() == o.nodeSize()
+}
+
+func (t *BTree) nodeSize() int {
+	return t.root.leafPart().width
+}
+
+// Clear returns an empty tree that shares the comparison and
+// equality functions of t.
+func (t *BTree) Clear() *BTree {
+	return &BTree{
+		root:  newLeaf(0, t.nodeSize(), emptyEdit),
+		edit:  emptyEdit,
+		cmp:   t.cmp,
+		eq:    t.eq,
+		order: t.order,
+	}
 }
 
 type TBTree struct {
@@ -237,8 +328,9 @@ type TBTree struct {
 	version int
 	edit    *atomic.Bool
 
-	cmp compareFunc
-	eq  eqFunc
+	cmp   compareFunc
+	eq    eqFunc
+	order *ordering
 
 	orig *BTree
 }
@@ -251,6 +343,7 @@ func (t *BTree) AsTransient() *TBTree {
 		edit:    atomic.NewBool(true),
 		cmp:     t.cmp,
 		eq:      t.eq,
+		order:   t.order,
 
 		orig: t,
 	}
@@ -287,11 +380,11 @@ func (t *TBTree) Add(key interface{}) *TBTree {
 	case returnOne:
 		t.root = ret.nodes[0]
 	default:
-		nr := newNode(2, t.edit)
+		nr := newNode(2, t.nodeSize(), t.edit)
 		nr.keys[0] = ret.nodes[0].maxKey()
 		nr.keys[1] = ret.nodes[1].maxKey()
 		copy(nr.children, ret.nodes[:])
-		t.root = nr
+		t.root = nr.recount()
 	}
 	t.count++
 	t.version++
@@ -319,9 +412,22 @@ func (t *TBTree) Delete(key interface{}) *TBTree {
 
 func (t *TBTree) Iterator() Iterator {
 	t.ensureEditable()
-	i := makeIterator(t.root)
-	i.HasNext() // Make sure the initial iterator value is valid
-	return i
+	return makeIterator(t.root, t.cmp)
+}
+
+func (t *TBTree) ReverseIterator() Iterator {
+	t.ensureEditable()
+	return makeRangeIterator(t.root, t.cmp, Unbounded(), Unbounded(), true)
+}
+
+func (t *TBTree) IteratorRange(lo, hi Bound) Iterator {
+	t.ensureEditable()
+	return makeRangeIterator(t.root, t.cmp, lo, hi, false)
+}
+
+func (t *TBTree) CountRange(lo, hi Bound) int {
+	t.ensureEditable()
+	return countRange(t.root, t.count, t.cmp, lo, hi)
 }
 
 func (t *TBTree) Length() int {
@@ -348,9 +454,14 @@ func (t *TBTree) AsPersistent() *BTree {
 		edit:    t.edit,
 		cmp:     t.cmp,
 		eq:      t.eq,
+		order:   t.order,
 	}
 }
 
+func (t *TBTree) nodeSize() int {
+	return t.root.leafPart().width
+}
+
 func (t *TBTree) ensureEditable() {
 	if !t.edit.Deref() {
 		panic(ErrTafterP)
@@ -361,17 +472,17 @@ type compareFunc func(k1, k2 interface{}) int
 type eqFunc func(k1, k2 interface{}) bool
 
 const (
-	maxLen    = 64
-	minLen    = maxLen >> 1
-	expandLen = 8
-	// maxIterDepth is log_32(^uintptr(0)) rounded up -- 13.
-	// The height is calculated as h <= log_32((n+1)/2). The
-	// maximum height must therefore be smaller than
-	// log_32(^uintptr(0)) rounded up to the next value. To
-	// calculate this we use log_2(^uintptr(0))/log_2(32). Which
-	// is of course 64/5 = 12.8.  We round 64 up to get an even
-	// 13.
-	maxIterDepth = (64 + 1) / 5
+	minNodeSize = 16
+	maxNodeSize = 1024
+	expandLen   = 8
+	// maxIterDepth is log_8(^uintptr(0)) rounded up -- 22.
+	// The height is calculated as h <= log_m((n+1)/2) where m is
+	// half the node size. The smallest node size allowed is 16 so
+	// the maximum height must be smaller than log_8(^uintptr(0))
+	// rounded up to the next value. To calculate this we use
+	// log_2(^uintptr(0))/log_2(8). Which is of course 64/3 =
+	// 21.3.  We round 64 up to get an even 22.
+	maxIterDepth = (64 + 2) / 3
 )
 
 type node interface {
@@ -381,6 +492,9 @@ type node interface {
 	remove(key interface{}, left, right node, cmp compareFunc, edit *atomic.Bool) nodeReturn
 	leafPart() *leafNode
 	maxKey() interface{}
+	size() int
+	rank(key interface{}, cmp compareFunc) int
+	nth(i int) interface{}
 	string(b *strings.Builder, lvl int)
 }
 
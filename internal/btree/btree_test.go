@@ -2,6 +2,7 @@ package btree_test
 
 import (
 	"fmt"
+	"math/rand"
 	"strconv"
 	"strings"
 	"testing"
@@ -762,3 +763,562 @@ func unmakeTree(m *btree.BTree) {
 }
 
 var genTree = gopter.DeriveGen(makeTree, unmakeTree)
+
+func TestIteratorRange(t *testing.T) {
+	tree := btree.Empty().AsTransient()
+	for i := 0; i < 10000; i += 2 {
+		tree = tree.Add(i)
+	}
+	p := tree.AsPersistent()
+	collect := func(lo, hi btree.Bound) []int {
+		var out []int
+		iter := p.IteratorRange(lo, hi)
+		for iter.HasNext() {
+			out = append(out, iter.Next().(int))
+		}
+		return out
+	}
+	parameters := gopter.DefaultTestParameters()
+	properties := gopter.NewProperties(parameters)
+	properties.Property("IteratorRange(Inclusive(a), Exclusive(b)) yields [a, b)", prop.ForAll(
+		func(a, b int) bool {
+			got := collect(btree.Inclusive(a), btree.Exclusive(b))
+			var expected []int
+			for i := a; i < b; i++ {
+				if i%2 == 0 && i >= 0 && i < 10000 {
+					expected = append(expected, i)
+				}
+			}
+			return fmt.Sprint(got) == fmt.Sprint(expected)
+		},
+		gen.IntRange(-100, 10100),
+		gen.IntRange(-100, 10100),
+	))
+	properties.Property("IteratorRange(Exclusive(a), Inclusive(b)) yields (a, b]", prop.ForAll(
+		func(a, b int) bool {
+			got := collect(btree.Exclusive(a), btree.Inclusive(b))
+			var expected []int
+			for i := a + 1; i <= b; i++ {
+				if i%2 == 0 && i >= 0 && i < 10000 {
+					expected = append(expected, i)
+				}
+			}
+			return fmt.Sprint(got) == fmt.Sprint(expected)
+		},
+		gen.IntRange(-100, 10100),
+		gen.IntRange(-100, 10100),
+	))
+	properties.Property("IteratorRange(Unbounded(), Unbounded()) yields every key", prop.ForAll(
+		func(rt *rtree) bool {
+			iter := rt.t.IteratorRange(btree.Unbounded(), btree.Unbounded())
+			var count int
+			for iter.HasNext() {
+				iter.Next()
+				count++
+			}
+			return count == rt.t.Length()
+		},
+		genRandomTree,
+	))
+	properties.Property("InRange agrees with bounds", prop.ForAll(
+		func(k, a, b int) bool {
+			return p.InRange(k, btree.Inclusive(a), btree.Exclusive(b)) ==
+				(k >= a && k < b)
+		},
+		gen.Int(),
+		gen.Int(),
+		gen.Int(),
+	))
+	properties.Property("CountRange agrees with IteratorRange", prop.ForAll(
+		func(a, b int, loIncl, hiIncl bool) bool {
+			lo, hi := btree.Exclusive(a), btree.Exclusive(b)
+			if loIncl {
+				lo = btree.Inclusive(a)
+			}
+			if hiIncl {
+				hi = btree.Inclusive(b)
+			}
+			return p.CountRange(lo, hi) == len(collect(lo, hi))
+		},
+		gen.IntRange(-100, 10100),
+		gen.IntRange(-100, 10100),
+		gen.Bool(),
+		gen.Bool(),
+	))
+	properties.TestingRun(t)
+}
+
+func TestIteratorRangeEmpty(t *testing.T) {
+	iter := btree.Empty().IteratorRange(btree.Inclusive(1), btree.Inclusive(10))
+	if iter.HasNext() {
+		t.Fatal("range iterator over empty tree had next")
+	}
+}
+
+func TestIteratorBidirectional(t *testing.T) {
+	tree := btree.Empty().AsTransient()
+	for i := 0; i < 10000; i += 2 {
+		tree = tree.Add(i)
+	}
+	p := tree.AsPersistent()
+	parameters := gopter.DefaultTestParameters()
+	properties := gopter.NewProperties(parameters)
+	properties.Property("ReverseIterator yields keys in descending order", prop.ForAll(
+		func(rt *rtree) bool {
+			iter := rt.t.ReverseIterator()
+			var count int
+			var last interface{}
+			for iter.HasNext() {
+				k := iter.Next()
+				if last != nil && dyn.Compare(last, k) <= 0 {
+					return false
+				}
+				last = k
+				count++
+			}
+			return count == rt.t.Length()
+		},
+		genRandomTree,
+	))
+	properties.Property("Seek(k) then Next yields the least key >= k", prop.ForAll(
+		func(k int) bool {
+			iter := p.Iterator()
+			iter.Seek(k)
+			expected := k + k%2
+			if k < 0 {
+				expected = 0
+			}
+			if expected >= 10000 {
+				return !iter.HasNext()
+			}
+			return iter.HasNext() && iter.Next() == expected
+		},
+		gen.IntRange(-100, 10100),
+	))
+	properties.Property("Seek(k) then Prev yields the greatest key < k", prop.ForAll(
+		func(k int) bool {
+			iter := p.Iterator()
+			iter.Seek(k)
+			expected := k - 2 + k%2
+			if k > 10000 {
+				expected = 9998
+			}
+			if expected < 0 {
+				return !iter.HasPrev()
+			}
+			return iter.HasPrev() && iter.Prev() == expected
+		},
+		gen.IntRange(-100, 10100),
+	))
+	properties.Property("reverse Seek(k) then Next yields the greatest key <= k", prop.ForAll(
+		func(k int) bool {
+			iter := p.ReverseIterator()
+			iter.Seek(k)
+			expected := k - k%2
+			if k >= 10000 {
+				expected = 9998
+			}
+			if k < 0 {
+				return !iter.HasNext()
+			}
+			return iter.HasNext() && iter.Next() == expected
+		},
+		gen.IntRange(-100, 10100),
+	))
+	properties.Property("Next then Prev returns the same key", prop.ForAll(
+		func(k int) bool {
+			iter := p.Iterator()
+			iter.Seek(k)
+			if !iter.HasNext() {
+				return true
+			}
+			a := iter.Next()
+			return iter.HasPrev() && iter.Prev() == a
+		},
+		gen.IntRange(0, 10000),
+	))
+	properties.Property("walking back from the end visits every key in range", prop.ForAll(
+		func(a, b int) bool {
+			iter := p.IteratorRange(btree.Inclusive(a), btree.Exclusive(b))
+			for iter.HasNext() {
+				iter.Next()
+			}
+			var got []int
+			for iter.HasPrev() {
+				got = append(got, iter.Prev().(int))
+			}
+			rev := p.ReverseIteratorRange(btree.Inclusive(a), btree.Exclusive(b))
+			var expected []int
+			for rev.HasNext() {
+				expected = append(expected, rev.Next().(int))
+			}
+			return fmt.Sprint(got) == fmt.Sprint(expected)
+		},
+		gen.IntRange(-100, 10100),
+		gen.IntRange(-100, 10100),
+	))
+	properties.Property("Clone advances independently", prop.ForAll(
+		func(k int) bool {
+			iter := p.Iterator()
+			iter.Seek(k)
+			clone := iter.Clone()
+			for iter.HasNext() {
+				iter.Next()
+			}
+			return clone.HasNext() && clone.Next() == k+k%2
+		},
+		gen.IntRange(0, 9998),
+	))
+	properties.TestingRun(t)
+}
+
+func TestOrderStatistics(t *testing.T) {
+	check := func(tree *btree.BTree) bool {
+		iter := tree.Iterator()
+		var i int
+		for iter.HasNext() {
+			k := iter.Next()
+			if tree.Nth(i) != k || tree.Rank(k) != i {
+				return false
+			}
+			i++
+		}
+		return i == tree.Length()
+	}
+	parameters := gopter.DefaultTestParameters()
+	parameters.MinSuccessfulTests = 20
+	properties := gopter.NewProperties(parameters)
+	properties.Property("Nth and Rank agree with iteration order after persistent updates", prop.ForAll(
+		func(seed int64) bool {
+			r := rand.New(rand.NewSource(seed))
+			tree := btree.Empty()
+			for i := 0; i < 5000; i++ {
+				if r.Intn(3) == 0 {
+					tree = tree.Delete(r.Intn(2000))
+				} else {
+					tree = tree.Add(r.Intn(2000))
+				}
+			}
+			return check(tree)
+		},
+		gen.Int64(),
+	))
+	properties.Property("Nth and Rank agree with iteration order after transient updates", prop.ForAll(
+		func(seed int64) bool {
+			r := rand.New(rand.NewSource(seed))
+			tree := btree.Empty().AsTransient()
+			for i := 0; i < 5000; i++ {
+				if r.Intn(3) == 0 {
+					tree = tree.Delete(r.Intn(2000))
+				} else {
+					tree = tree.Add(r.Intn(2000))
+				}
+			}
+			return check(tree.AsPersistent())
+		},
+		gen.Int64(),
+	))
+	properties.Property("Rank counts keys before a missing key", prop.ForAll(
+		func(k int) bool {
+			tree := btree.Empty().AsTransient()
+			for i := 0; i < 1000; i += 2 {
+				tree = tree.Add(i)
+			}
+			expected := (k + 1) / 2
+			if k < 0 {
+				expected = 0
+			}
+			if expected > 500 {
+				expected = 500
+			}
+			return tree.AsPersistent().Rank(k) == expected
+		},
+		gen.IntRange(-10, 1010),
+	))
+	properties.TestingRun(t)
+}
+
+func TestNthOutOfBounds(t *testing.T) {
+	defer func() {
+		if r := recover(); r != btree.ErrOutOfBounds {
+			t.Fatalf("expected ErrOutOfBounds, got %v", r)
+		}
+	}()
+	btree.Empty().Add(1).Nth(1)
+}
+
+func TestFromSorted(t *testing.T) {
+	build := func(n int) *btree.BTree {
+		keys := make([]interface{}, n)
+		for i := range keys {
+			keys[i] = i
+		}
+		return btree.Empty().FromSorted(keys)
+	}
+	parameters := gopter.DefaultTestParameters()
+	properties := gopter.NewProperties(parameters)
+	properties.Property("FromSorted holds every key in order", prop.ForAll(
+		func(n int) bool {
+			tree := build(n)
+			iter := tree.Iterator()
+			var i int
+			for iter.HasNext() {
+				if iter.Next() != i || tree.Nth(i) != i {
+					return false
+				}
+				i++
+			}
+			return i == n && tree.Length() == n
+		},
+		gen.IntRange(0, 20000),
+	))
+	properties.Property("FromSorted trees stay consistent under updates", prop.ForAll(
+		func(n int, seed int64) bool {
+			r := rand.New(rand.NewSource(seed))
+			tree := build(n)
+			expected := make(map[int]bool, n)
+			for i := 0; i < n; i++ {
+				expected[i] = true
+			}
+			for i := 0; i < 2000; i++ {
+				k := r.Intn(n + 100)
+				if r.Intn(2) == 0 {
+					tree = tree.Delete(k)
+					delete(expected, k)
+				} else {
+					tree = tree.Add(k)
+					expected[k] = true
+				}
+			}
+			if tree.Length() != len(expected) {
+				return false
+			}
+			iter := tree.Iterator()
+			var i int
+			for iter.HasNext() {
+				k := iter.Next()
+				if !expected[k.(int)] || tree.Rank(k) != i {
+					return false
+				}
+				i++
+			}
+			return i == len(expected)
+		},
+		gen.IntRange(0, 5000),
+		gen.Int64(),
+	))
+	properties.Property("IsSorted detects out of order keys", prop.ForAll(
+		func(keys []int) bool {
+			in := make([]interface{}, len(keys))
+			sorted := true
+			for i, k := range keys {
+				in[i] = k
+				if i > 0 && keys[i-1] >= k {
+					sorted = false
+				}
+			}
+			return btree.Empty().IsSorted(in) == sorted
+		},
+		gen.SliceOf(gen.IntRange(0, 20)),
+	))
+	properties.TestingRun(t)
+}
+
+func TestSplitJoin(t *testing.T) {
+	build := func(lo, hi int) *btree.BTree {
+		keys := make([]interface{}, 0, hi-lo)
+		for i := lo; i < hi; i++ {
+			keys = append(keys, i)
+		}
+		return btree.Empty().FromSorted(keys)
+	}
+	holds := func(tree *btree.BTree, lo, hi int) bool {
+		if tree.CheckInvariants() != nil || tree.Length() != hi-lo {
+			return false
+		}
+		iter := tree.Iterator()
+		for i := lo; i < hi; i++ {
+			if !iter.HasNext() || iter.Next() != i {
+				return false
+			}
+		}
+		return !iter.HasNext()
+	}
+	parameters := gopter.DefaultTestParameters()
+	properties := gopter.NewProperties(parameters)
+	properties.Property("Split(k) divides the keys at k", prop.ForAll(
+		func(n, k int) bool {
+			l, r := build(0, n).Split(k)
+			mid := k
+			if mid < 0 {
+				mid = 0
+			}
+			if mid > n {
+				mid = n
+			}
+			return holds(l, 0, mid) && holds(r, mid, n)
+		},
+		gen.IntRange(0, 100000),
+		gen.IntRange(-10, 100010),
+	))
+	properties.Property("Join concatenates trees of any size", prop.ForAll(
+		func(a, b int) bool {
+			return holds(btree.Join(build(0, a), build(a, a+b)), 0, a+b)
+		},
+		gen.IntRange(0, 100000),
+		gen.IntRange(0, 100000),
+	))
+	properties.Property("Join(Split(k)) restores the tree after updates", prop.ForAll(
+		func(seed int64, k int) bool {
+			r := rand.New(rand.NewSource(seed))
+			tree := btree.Empty().AsTransient()
+			for i := 0; i < 20000; i++ {
+				tree = tree.Add(r.Intn(30000))
+			}
+			p := tree.AsPersistent()
+			l, rt := p.Split(k)
+			j := btree.Join(l, rt)
+			if j.CheckInvariants() != nil || j.Length() != p.Length() {
+				return false
+			}
+			a, b := p.Iterator(), j.Iterator()
+			for a.HasNext() {
+				if !b.HasNext() || a.Next() != b.Next() {
+					return false
+				}
+			}
+			return !b.HasNext()
+		},
+		gen.Int64(),
+		gen.IntRange(0, 30000),
+	))
+	properties.TestingRun(t)
+}
+
+func TestJoinOverlap(t *testing.T) {
+	defer func() {
+		if r := recover(); r != btree.ErrOverlap {
+			t.Fatalf("expected ErrOverlap, got %v", r)
+		}
+	}()
+	btree.Join(btree.Empty().Add(1).Add(5), btree.Empty().Add(3))
+}
+
+func TestNodeSize(t *testing.T) {
+	parameters := gopter.DefaultTestParameters()
+	parameters.MinSuccessfulTests = 20
+	properties := gopter.NewProperties(parameters)
+	properties.Property("trees of any node size keep their invariants", prop.ForAll(
+		func(size int, seed int64) bool {
+			r := rand.New(rand.NewSource(seed))
+			tree := btree.Empty(btree.NodeSize(size)).AsTransient()
+			model := make(map[int]bool)
+			for i := 0; i < 20000; i++ {
+				k := r.Intn(5000)
+				if r.Intn(3) == 0 {
+					tree = tree.Delete(k)
+					delete(model, k)
+				} else {
+					tree = tree.Add(k)
+					model[k] = true
+				}
+			}
+			p := tree.AsPersistent()
+			for k := 0; k < 5000; k += 2 {
+				p = p.Delete(k)
+				delete(model, k)
+			}
+			if p.CheckInvariants() != nil || p.Length() != len(model) {
+				return false
+			}
+			l, rt := p.Split(2500)
+			j := btree.Join(l, rt)
+			keys := make([]interface{}, 0, p.Length())
+			iter := j.Iterator()
+			for iter.HasNext() {
+				k := iter.Next()
+				if !model[k.(int)] {
+					return false
+				}
+				keys = append(keys, k)
+			}
+			built := p.FromSorted(keys)
+			return j.CheckInvariants() == nil && len(keys) == len(model) &&
+				built.CheckInvariants() == nil &&
+				built.Length() == len(model)
+		},
+		gen.IntRange(16, 1024),
+		gen.Int64(),
+	))
+	properties.TestingRun(t)
+}
+
+func TestNodeSizeInvalid(t *testing.T) {
+	for _, size := range []int{0, 15, 1025} {
+		func() {
+			defer func() {
+				if r := recover(); r != btree.ErrNodeSize {
+					t.Fatalf("size %d: expected ErrNodeSize, got %v",
+						size, r)
+				}
+			}()
+			btree.Empty(btree.NodeSize(size))
+		}()
+	}
+}
+
+// BenchmarkNodeSize shows the trade-off between node sizes. Each
+// persistent update copies every node on the path to the key, so wide
+// nodes copy more per update while narrow nodes make the path longer.
+// Keys that are expensive to compare shift the balance towards the
+// size that needs the fewest comparisons per search.
+func BenchmarkNodeSize(b *testing.B) {
+	const n = 100000
+	prefix := strings.Repeat("k", 256)
+	slowKeys := make([]interface{}, n)
+	for i := range slowKeys {
+		slowKeys[i] = prefix + strconv.Itoa(i)
+	}
+	slowCmp := btree.Compare(func(a, b interface{}) int {
+		return strings.Compare(a.(string), b.(string))
+	})
+	for _, size := range []int{16, 64, 256, 1024} {
+		b.Run(fmt.Sprintf("Add/int/%d", size), func(b *testing.B) {
+			t := btree.Empty(btree.NodeSize(size))
+			for i := 0; i < b.N; i++ {
+				t = t.Add(i)
+			}
+		})
+		b.Run(fmt.Sprintf("Iter/int/%d", size), func(b *testing.B) {
+			t := btree.Empty(btree.NodeSize(size)).AsTransient()
+			for i := 0; i < n; i++ {
+				t = t.Add(i)
+			}
+			p := t.AsPersistent()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				iter := p.Iterator()
+				for iter.HasNext() {
+					iter.Next()
+				}
+			}
+		})
+		b.Run(fmt.Sprintf("Add/string/%d", size), func(b *testing.B) {
+			t := btree.Empty(btree.NodeSize(size), slowCmp)
+			for i := 0; i < b.N; i++ {
+				t = t.Add(slowKeys[i%n])
+			}
+		})
+		b.Run(fmt.Sprintf("Find/string/%d", size), func(b *testing.B) {
+			t := btree.Empty(btree.NodeSize(size), slowCmp).AsTransient()
+			for _, k := range slowKeys {
+				t = t.Add(k)
+			}
+			p := t.AsPersistent()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				p.Find(slowKeys[i%n])
+			}
+		})
+	}
+}
@@ -0,0 +1,75 @@
+package btree
+
+// FromSorted returns a new tree that holds keys and uses the same
+// comparison and equality functions as t. The keys must be in
+// strictly increasing order; IsSorted may be used to check this.
+//
+// The tree is built bottom-up in linear time. Every node is filled to
+// capacity except for the last two nodes of each level which share
+// the remainder so neither is left below the minimum size.
+func (t *BTree) FromSorted(keys []interface{}) *BTree {
+	if len(keys) == 0 {
+		return t.Clear()
+	}
+	width := t.nodeSize()
+	sizes := packSizes(len(keys), width)
+	level := make([]node, len(sizes))
+	var offset int
+	for i, size := range sizes {
+		leaf := newLeaf(size, width, emptyEdit)
+		copy(leaf.keys, keys[offset:offset+size])
+		offset += size
+		level[i] = leaf
+	}
+	for len(level) > 1 {
+		sizes = packSizes(len(level), width)
+		parents := make([]node, len(sizes))
+		offset = 0
+		for i, size := range sizes {
+			parent := newNode(size, width, emptyEdit)
+			for j, child := range level[offset : offset+size] {
+				parent.keys[j] = child.maxKey()
+				parent.children[j] = child
+			}
+			offset += size
+			parents[i] = parent.recount()
+		}
+		level = parents
+	}
+	return &BTree{
+		root:  level[0],
+		count: len(keys),
+		edit:  emptyEdit,
+		cmp:   t.cmp,
+		eq:    t.eq,
+		order: t.order,
+	}
+}
+
+// IsSorted reports whether keys are in strictly increasing order
+// according to the tree's comparison function.
+func (t *BTree) IsSorted(keys []interface{}) bool {
+	for i := 1; i < len(keys); i++ {
+		if t.cmp(keys[i-1], keys[i]) >= 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// packSizes returns the sizes of the nodes needed to hold n items
+// when nodes of the given width are packed as full as possible.
+func packSizes(n, width int) []int {
+	out := make([]int, 0, (n+width-1)/width)
+	for n > width {
+		out = append(out, width)
+		n -= width
+	}
+	out = append(out, n)
+	if last := len(out) - 1; last > 0 && n < width>>1 {
+		total := out[last-1] + n
+		out[last-1] = total - total>>1
+		out[last] = total >> 1
+	}
+	return out
+}
@@ -0,0 +1,52 @@
+package btree
+
+import "fmt"
+
+// CheckInvariants verifies the structure of the tree for tests.
+func (t *BTree) CheckInvariants() error {
+	if t.root.size() != t.count {
+		return fmt.Errorf("count %d does not match root size %d",
+			t.count, t.root.size())
+	}
+	_, err := checkNode(t.root, true, t.cmp)
+	return err
+}
+
+func checkNode(n node, root bool, cmp compareFunc) (int, error) {
+	leaf := n.leafPart()
+	if leaf.len > leaf.maxLen() || (!root && leaf.len < leaf.minLen()) {
+		return 0, fmt.Errorf("node holds %d entries", leaf.len)
+	}
+	for i := 1; i < leaf.len; i++ {
+		if cmp(leaf.keys[i-1], leaf.keys[i]) >= 0 {
+			return 0, fmt.Errorf("keys out of order at %d", i)
+		}
+	}
+	in, ok := n.(*internalNode)
+	if !ok {
+		return 0, nil
+	}
+	if root && in.len < 2 {
+		return 0, fmt.Errorf("internal root has %d children", in.len)
+	}
+	var count, depth int
+	for i, child := range in.children[:in.len] {
+		if cmp(in.keys[i], child.maxKey()) != 0 {
+			return 0, fmt.Errorf("key %d does not match child", i)
+		}
+		d, err := checkNode(child, false, cmp)
+		if err != nil {
+			return 0, err
+		}
+		if i > 0 && d != depth {
+			return 0, fmt.Errorf("leaves at differing depths")
+		}
+		depth = d
+		count += child.size()
+	}
+	if count != in.count {
+		return 0, fmt.Errorf("count %d does not match children %d",
+			in.count, count)
+	}
+	return depth + 1, nil
+}
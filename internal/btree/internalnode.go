@@ -11,19 +11,62 @@ type internalNode struct {
 	*leafNode
 
 	children []node
+	// count is the number of keys in the leaves beneath the node.
+	count int
 }
 
-func newNode(len int, edit *atomic.Bool) *internalNode {
+func newNode(len, width int, edit *atomic.Bool) *internalNode {
 	return &internalNode{
 		leafNode: &leafNode{
-			keys: make([]interface{}, len),
-			len:  len,
-			edit: edit,
+			keys:  make([]interface{}, len),
+			len:   len,
+			edit:  edit,
+			width: width,
 		},
 		children: make([]node, len),
 	}
 }
 
+func (n *internalNode) size() int {
+	return n.count
+}
+
+// recount recomputes the number of keys beneath the node from the
+// sizes of its children.
+func (n *internalNode) recount() *internalNode {
+	n.count = 0
+	for _, child := range n.children[:n.len] {
+		n.count += child.size()
+	}
+	return n
+}
+
+// rank returns the number of keys beneath the node that are before
+// key.
+func (n *internalNode) rank(key interface{}, cmp compareFunc) int {
+	idx := n.lowerBound(key, true, cmp)
+	if idx == n.len {
+		return n.count
+	}
+	var out int
+	for _, child := range n.children[:idx] {
+		out += child.size()
+	}
+	return out + n.children[idx].rank(key, cmp)
+}
+
+// nth returns the key at index i of the keys beneath the node.
+func (n *internalNode) nth(i int) interface{} {
+	for _, child := range n.children[:n.len] {
+		size := child.size()
+		if i < size {
+			return child.nth(i)
+		}
+		i -= size
+	}
+	panic(ErrOutOfBounds)
+}
+
 func (n *internalNode) find(key interface{}, cmp compareFunc) (interface{}, bool) {
 	idx := n.search(key, cmp)
 	if idx >= 0 {
@@ -55,6 +98,7 @@ func (n *internalNode) add(
 	case returnUnchanged:
 		return ret
 	case returnEarly:
+		n.count++
 		return ret
 	case returnOne, returnReplaced:
 		if n.isEditable() {
@@ -62,7 +106,7 @@ func (n *internalNode) add(
 		}
 		return n.copyAndModify(ins, eq, edit, ret.nodes[0], ret.status)
 	default:
-		if n.len < maxLen {
+		if n.len < n.maxLen() {
 			return n.copyAndAppend(
 				ins, ret.nodes[0], ret.nodes[1], edit)
 		}
@@ -75,6 +119,9 @@ func (n *internalNode) modifyInPlace(
 ) nodeReturn {
 	n.keys[ins] = new.maxKey()
 	n.children[ins] = new
+	if status == returnOne {
+		n.count++
+	}
 	if ins == n.len-1 && new.maxKey() == n.maxKey() {
 		return nodeReturn{
 			status: status,
@@ -106,6 +153,11 @@ func (n *internalNode) copyAndModify(
 		newKeys[ins] = newNode.maxKey()
 	}
 
+	count := n.count
+	if status == returnOne {
+		count++
+	}
+
 	var newChildren []node
 	if newNode == n.children[ins] {
 		newChildren = n.children
@@ -119,11 +171,13 @@ func (n *internalNode) copyAndModify(
 		nodes: [3]node{
 			&internalNode{
 				leafNode: &leafNode{
-					keys: newKeys,
-					len:  n.len,
-					edit: edit,
+					keys:  newKeys,
+					len:   n.len,
+					edit:  edit,
+					width: n.width,
 				},
 				children: newChildren,
+				count:    count,
 			},
 		},
 	}
@@ -134,7 +188,7 @@ func (n *internalNode) copyAndAppend(
 	n1, n2 node,
 	edit *atomic.Bool,
 ) nodeReturn {
-	newNode := newNode(n.len+1, edit)
+	newNode := newNode(n.len+1, n.width, edit)
 	kstitch := keyStitcher{newNode.keys, 0}
 	kstitch.copyAll(n.keys, 0, ins)
 	kstitch.copyOne(n1.maxKey())
@@ -146,6 +200,7 @@ func (n *internalNode) copyAndAppend(
 	nstitch.copyOne(n1)
 	nstitch.copyOne(n2)
 	nstitch.copyAll(n.children, ins+1, n.len)
+	newNode.count = n.count + 1
 
 	return nodeReturn{
 		status: returnOne,
@@ -164,8 +219,8 @@ func (n *internalNode) split(
 	}
 	half2 := n.len + 1 - half1
 
-	node1 := newNode(half1, edit)
-	node2 := newNode(half2, edit)
+	node1 := newNode(half1, n.width, edit)
+	node2 := newNode(half2, n.width, edit)
 
 	// add to first half
 	if ins < half1 {
@@ -186,8 +241,8 @@ func (n *internalNode) split(
 		return nodeReturn{
 			status: returnTwo,
 			nodes: [3]node{
-				node1,
-				node2,
+				node1.recount(),
+				node2.recount(),
 			},
 		}
 	}
@@ -210,8 +265,8 @@ func (n *internalNode) split(
 	return nodeReturn{
 		status: returnTwo,
 		nodes: [3]node{
-			node1,
-			node2,
+			node1.recount(),
+			node2.recount(),
 		},
 	}
 }
@@ -261,6 +316,7 @@ func (n *internalNode) removeInternal(
 	case returnUnchanged:
 		return ret
 	case returnEarly:
+		n.count--
 		return ret
 	}
 
@@ -306,7 +362,7 @@ func (n *internalNode) needsRebalance(
 	newLen int,
 	left, right *internalNode,
 ) bool {
-	return newLen < minLen && (left != nil || right != nil)
+	return newLen < n.minLen() && (left != nil || right != nil)
 }
 
 func (n *internalNode) removeInPlace(
@@ -341,6 +397,7 @@ func (n *internalNode) removeInPlace(
 	}
 
 	n.len = newLen
+	n.count--
 	return nodeReturn{status: returnEarly}
 }
 
@@ -351,7 +408,7 @@ func (n *internalNode) copyAndRemoveIdx(
 	edit *atomic.Bool,
 	nodes [3]node,
 ) nodeReturn {
-	newCenter := newNode(newLen, edit)
+	newCenter := newNode(newLen, n.width, edit)
 
 	ks := keyStitcher{newCenter.keys, 0}
 	ks.copyAll(n.keys, 0, idx-1)
@@ -374,6 +431,7 @@ func (n *internalNode) copyAndRemoveIdx(
 		cs.copyOne(nodes[2])
 	}
 	cs.copyAll(n.children, idx+2, n.len)
+	newCenter.count = n.count - 1
 
 	return nodeReturn{
 		status: returnThree,
@@ -392,7 +450,7 @@ func (n *internalNode) joinLeft(
 	edit *atomic.Bool,
 	nodes [3]node,
 ) nodeReturn {
-	join := newNode(left.len+newLen, edit)
+	join := newNode(left.len+newLen, n.width, edit)
 
 	ks := keyStitcher{join.keys, 0}
 	ks.copyAll(left.keys, 0, left.len)
@@ -417,6 +475,7 @@ func (n *internalNode) joinLeft(
 		cs.copyOne(nodes[2])
 	}
 	cs.copyAll(n.children, idx+2, n.len)
+	join.count = left.count + n.count - 1
 
 	return nodeReturn{
 		status: returnThree,
@@ -431,7 +490,7 @@ func (n *internalNode) joinRight(
 	edit *atomic.Bool,
 	nodes [3]node,
 ) nodeReturn {
-	join := newNode(newLen+right.len, edit)
+	join := newNode(newLen+right.len, n.width, edit)
 
 	ks := keyStitcher{join.keys, 0}
 	ks.copyAll(n.keys, 0, idx-1)
@@ -456,6 +515,7 @@ func (n *internalNode) joinRight(
 	}
 	cs.copyAll(n.children, idx+2, n.len)
 	cs.copyAll(right.children, 0, right.len)
+	join.count = n.count - 1 + right.count
 
 	return nodeReturn{
 		status: returnThree,
@@ -476,8 +536,8 @@ func (n *internalNode) borrowLeft(
 		newCenterLen = totalLen - newLeftLen
 	)
 
-	newLeft := newNode(newLeftLen, edit)
-	newCenter := newNode(newCenterLen, edit)
+	newLeft := newNode(newLeftLen, n.width, edit)
+	newCenter := newNode(newCenterLen, n.width, edit)
 
 	copy(newLeft.keys, left.keys[0:newLeftLen])
 
@@ -509,7 +569,11 @@ func (n *internalNode) borrowLeft(
 
 	return nodeReturn{
 		status: returnThree,
-		nodes:  [3]node{newLeft, newCenter, internalNodeToNode(right)},
+		nodes: [3]node{
+			newLeft.recount(),
+			newCenter.recount(),
+			internalNodeToNode(right),
+		},
 	}
 }
 
@@ -527,8 +591,8 @@ func (n *internalNode) borrowRight(
 		rightHead    = right.len - newRightLen
 	)
 
-	newCenter := newNode(newCenterLen, edit)
-	newRight := newNode(newRightLen, edit)
+	newCenter := newNode(newCenterLen, n.width, edit)
+	newRight := newNode(newRightLen, n.width, edit)
 
 	ks := keyStitcher{newCenter.keys, 0}
 	ks.copyAll(n.keys, 0, idx-1)
@@ -560,7 +624,11 @@ func (n *internalNode) borrowRight(
 
 	return nodeReturn{
 		status: returnThree,
-		nodes:  [3]node{internalNodeToNode(left), newCenter, newRight},
+		nodes: [3]node{
+			internalNodeToNode(left),
+			newCenter.recount(),
+			newRight.recount(),
+		},
 	}
 }
 
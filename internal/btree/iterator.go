@@ -0,0 +1,321 @@
+package btree
+
+// Bound is one end of a range of keys. The zero value is unbounded.
+type Bound struct {
+	key       interface{}
+	inclusive bool
+	bounded   bool
+}
+
+// Unbounded returns a bound that admits every key.
+func Unbounded() Bound {
+	return Bound{}
+}
+
+// Inclusive returns a bound that admits the key itself.
+func Inclusive(key interface{}) Bound {
+	return Bound{key: key, inclusive: true, bounded: true}
+}
+
+// Exclusive returns a bound that stops short of the key.
+func Exclusive(key interface{}) Bound {
+	return Bound{key: key, bounded: true}
+}
+
+// below reports whether key is admitted by b used as a lower bound.
+func (b Bound) below(key interface{}, cmp compareFunc) bool {
+	if !b.bounded {
+		return true
+	}
+	c := cmp(b.key, key)
+	return c < 0 || (c == 0 && b.inclusive)
+}
+
+// above reports whether key is admitted by b used as an upper bound.
+func (b Bound) above(key interface{}, cmp compareFunc) bool {
+	if !b.bounded {
+		return true
+	}
+	c := cmp(key, b.key)
+	return c < 0 || (c == 0 && b.inclusive)
+}
+
+// Iterator is a cursor over the keys of a tree. The cursor sits
+// between two keys and may move in either direction. The stack holds
+// the path from the root to the current leaf; for internal nodes cur
+// is the index of the child being visited and for the leaf it is the
+// index of the key after the cursor.
+//
+// Iterators over a persistent tree remain valid for as long as they
+// are held and may be cloned by copying the value.
+type Iterator struct {
+	depth int
+	stack [maxIterDepth]struct {
+		n   node
+		cur int
+	}
+	cmp     compareFunc
+	lo, hi  Bound
+	reverse bool
+}
+
+func makeIterator(root node, cmp compareFunc) Iterator {
+	var i Iterator
+	i.stack[0].n = root
+	i.cmp = cmp
+	i.descendFirst()
+	return i
+}
+
+func makeRangeIterator(
+	root node,
+	cmp compareFunc,
+	lo, hi Bound,
+	reverse bool,
+) Iterator {
+	var i Iterator
+	i.stack[0].n = root
+	i.cmp = cmp
+	i.lo = lo
+	i.hi = hi
+	i.reverse = reverse
+	if reverse {
+		i.seekEnd()
+	} else {
+		i.seekStart()
+	}
+	return i
+}
+
+// Next returns the key after the cursor and moves the cursor past
+// it. For reverse iterators the key before the cursor is returned.
+func (i *Iterator) Next() interface{} {
+	if i.reverse {
+		return i.prev()
+	}
+	return i.next()
+}
+
+// HasNext is true when there are more keys to be iterated over.
+func (i *Iterator) HasNext() bool {
+	if i.reverse {
+		return i.hasPrev()
+	}
+	return i.hasNext()
+}
+
+// Prev returns the key before the cursor and moves the cursor back
+// past it. For reverse iterators the key after the cursor is returned.
+func (i *Iterator) Prev() interface{} {
+	if i.reverse {
+		return i.next()
+	}
+	return i.prev()
+}
+
+// HasPrev is true when Prev may be called.
+func (i *Iterator) HasPrev() bool {
+	if i.reverse {
+		return i.hasNext()
+	}
+	return i.hasPrev()
+}
+
+// Seek moves the cursor so that the following call to Next returns
+// the first key that is not before key; for reverse iterators the
+// last key that is not after key. Seeking outside of the iterator's
+// bounds leaves the cursor at the nearest bound.
+func (i *Iterator) Seek(key interface{}) {
+	switch {
+	case !i.lo.below(key, i.cmp):
+		i.seekStart()
+	case !i.hi.above(key, i.cmp):
+		i.seekEnd()
+	case i.reverse:
+		i.seek(key, false)
+	default:
+		i.seek(key, true)
+	}
+}
+
+// Reverse reports whether the iterator visits keys in descending
+// order.
+func (i *Iterator) Reverse() bool {
+	return i.reverse
+}
+
+// Clone returns an independent copy of the iterator at the same
+// position.
+func (i *Iterator) Clone() Iterator {
+	return *i
+}
+
+func (i *Iterator) next() interface{} {
+	i.advance()
+	state := &i.stack[i.depth]
+	n := state.n.(*leafNode)
+	out := n.keys[state.cur]
+	state.cur++
+	return out
+}
+
+func (i *Iterator) hasNext() bool {
+	if !i.advance() {
+		return false
+	}
+	state := i.stack[i.depth]
+	return i.hi.above(state.n.leafPart().keys[state.cur], i.cmp)
+}
+
+func (i *Iterator) prev() interface{} {
+	i.retreat()
+	state := &i.stack[i.depth]
+	n := state.n.(*leafNode)
+	state.cur--
+	return n.keys[state.cur]
+}
+
+func (i *Iterator) hasPrev() bool {
+	if !i.retreat() {
+		return false
+	}
+	state := i.stack[i.depth]
+	return i.lo.below(state.n.leafPart().keys[state.cur-1], i.cmp)
+}
+
+// advance moves a cursor sitting at the end of a leaf to the start
+// of the following leaf. It reports whether a key is under the
+// cursor.
+func (i *Iterator) advance() bool {
+	state := i.stack[i.depth]
+	if state.cur < state.n.leafPart().len {
+		return true
+	}
+	d := i.depth - 1
+	for d >= 0 && i.stack[d].cur+1 >= i.stack[d].n.leafPart().len {
+		d--
+	}
+	if d < 0 {
+		return false
+	}
+	i.truncate(d)
+	i.stack[d].cur++
+	i.descendFirst()
+	return true
+}
+
+// retreat moves a cursor sitting at the start of a leaf to the end
+// of the preceding leaf. It reports whether a key is before the
+// cursor.
+func (i *Iterator) retreat() bool {
+	if i.stack[i.depth].cur > 0 {
+		return true
+	}
+	d := i.depth - 1
+	for d >= 0 && i.stack[d].cur == 0 {
+		d--
+	}
+	if d < 0 {
+		return false
+	}
+	i.truncate(d)
+	i.stack[d].cur--
+	n := i.stack[d].n.(*internalNode)
+	i.pushNode(n.children[i.stack[d].cur])
+	i.descendLast()
+	return true
+}
+
+// seekStart positions the cursor before the first key admitted by
+// the lower bound.
+func (i *Iterator) seekStart() {
+	if i.lo.bounded {
+		i.seek(i.lo.key, i.lo.inclusive)
+		return
+	}
+	i.truncate(0)
+	i.stack[0].cur = 0
+	i.descendFirst()
+}
+
+// seekEnd positions the cursor after the last key admitted by the
+// upper bound.
+func (i *Iterator) seekEnd() {
+	if i.hi.bounded {
+		i.seek(i.hi.key, !i.hi.inclusive)
+		return
+	}
+	i.truncate(0)
+	i.descendLast()
+}
+
+// seek positions the cursor on the first key that is not before
+// key, or after key when inclusive is false.
+func (i *Iterator) seek(key interface{}, inclusive bool) {
+	i.truncate(0)
+	for {
+		state := &i.stack[i.depth]
+		idx := state.n.leafPart().lowerBound(key, inclusive, i.cmp)
+		n, ok := state.n.(*internalNode)
+		if !ok {
+			state.cur = idx
+			return
+		}
+		if idx == n.len {
+			// Every key in the tree is before key.
+			state.cur = n.len - 1
+			i.pushNode(n.children[state.cur])
+			i.descendLast()
+			return
+		}
+		state.cur = idx
+		i.pushNode(n.children[idx])
+	}
+}
+
+// descendFirst follows the current child of each internal node down
+// to the leftmost leaf beneath it.
+func (i *Iterator) descendFirst() {
+	for {
+		state := i.stack[i.depth]
+		n, ok := state.n.(*internalNode)
+		if !ok {
+			return
+		}
+		i.pushNode(n.children[state.cur])
+	}
+}
+
+// descendLast places the cursor after the last key beneath the node
+// on top of the stack.
+func (i *Iterator) descendLast() {
+	for {
+		state := &i.stack[i.depth]
+		n, ok := state.n.(*internalNode)
+		if !ok {
+			state.cur = state.n.leafPart().len
+			return
+		}
+		state.cur = n.len - 1
+		i.pushNode(n.children[state.cur])
+	}
+}
+
+func (i *Iterator) pushNode(n node) {
+	i.depth = i.depth + 1
+	state := i.stack[i.depth]
+	state.n = n
+	state.cur = 0
+	i.stack[i.depth] = state
+}
+
+// truncate pops nodes off of the stack until depth is reached.
+func (i *Iterator) truncate(depth int) {
+	for i.depth > depth {
+		state := i.stack[i.depth]
+		state.n = nil
+		state.cur = 0
+		i.stack[i.depth] = state
+		i.depth = i.depth - 1
+	}
+}
@@ -12,15 +12,18 @@ type leafNode struct {
 	keys []interface{}
 	len  int
 	edit *atomic.Bool
+	// width is the maximum number of entries the node may hold.
+	width int
 }
 
-func newLeaf(len int, edit *atomic.Bool) *leafNode {
+func newLeaf(len, width int, edit *atomic.Bool) *leafNode {
 	out := leafNode{
-		len:  len,
-		edit: edit,
+		len:   len,
+		edit:  edit,
+		width: width,
 	}
 	if edit.Deref() {
-		out.keys = make([]interface{}, min(maxLen, len+expandLen))
+		out.keys = make([]interface{}, min(width, len+expandLen))
 	} else {
 		out.keys = make([]interface{}, len)
 	}
@@ -31,6 +34,14 @@ func (n *leafNode) isEditable() bool {
 	return n.edit.Deref()
 }
 
+func (n *leafNode) maxLen() int {
+	return n.width
+}
+
+func (n *leafNode) minLen() int {
+	return n.width >> 1
+}
+
 func (n *leafNode) leafPart() *leafNode {
 	return n
 }
@@ -39,6 +50,18 @@ func (n *leafNode) maxKey() interface{} {
 	return n.keys[n.len-1]
 }
 
+func (n *leafNode) size() int {
+	return n.len
+}
+
+func (n *leafNode) rank(key interface{}, cmp compareFunc) int {
+	return n.lowerBound(key, true, cmp)
+}
+
+func (n *leafNode) nth(i int) interface{} {
+	return n.keys[i]
+}
+
 func (n *leafNode) search(key interface{}, cmp compareFunc) int {
 	i := sort.Search(n.len, func(i int) bool {
 		return cmp(n.keys[i], key) >= 0
@@ -50,6 +73,15 @@ func (n *leafNode) search(key interface{}, cmp compareFunc) int {
 	}
 }
 
+// lowerBound returns the index of the first key that is not before
+// key. When inclusive is false keys equal to key are skipped as well.
+func (n *leafNode) lowerBound(key interface{}, inclusive bool, cmp compareFunc) int {
+	return sort.Search(n.len, func(i int) bool {
+		c := cmp(n.keys[i], key)
+		return c > 0 || (inclusive && c == 0)
+	})
+}
+
 func (n *leafNode) searchEq(key interface{}, cmp compareFunc, eq eqFunc) (int, bool) {
 	i := sort.Search(n.len, func(i int) bool {
 		return cmp(n.keys[i], key) >= 0
@@ -94,7 +126,7 @@ func (n *leafNode) add(
 		return n.copyAndReplaceNode(ins, key, edit)
 	}
 
-	if n.len < maxLen {
+	if n.len < n.maxLen() {
 		return n.copyAndInsertNode(ins, key, edit)
 	}
 
@@ -122,7 +154,7 @@ func (n *leafNode) modifyInPlace(
 func (n *leafNode) copyAndInsertNode(
 	ins int, key interface{}, edit *atomic.Bool,
 ) nodeReturn {
-	nl := newLeaf(n.len+1, edit)
+	nl := newLeaf(n.len+1, n.width, edit)
 	ks := keyStitcher{nl.keys, 0}
 	ks.copyAll(n.keys, 0, ins)
 	ks.copyOne(key)
@@ -133,7 +165,7 @@ func (n *leafNode) copyAndInsertNode(
 func (n *leafNode) copyAndReplaceNode(
 	ins int, key interface{}, edit *atomic.Bool,
 ) nodeReturn {
-	nl := newLeaf(n.len, edit)
+	nl := newLeaf(n.len, n.width, edit)
 	copy(nl.keys, n.keys)
 	nl.keys[ins] = key
 	return nodeReturn{status: returnReplaced, nodes: [3]node{nl}}
@@ -144,8 +176,8 @@ func (n *leafNode) split(
 ) nodeReturn {
 	firstHalf := (n.len + 1) >> 1
 	secondHalf := n.len + 1 - firstHalf
-	n1 := newLeaf(firstHalf, edit)
-	n2 := newLeaf(secondHalf, edit)
+	n1 := newLeaf(firstHalf, n.width, edit)
+	n2 := newLeaf(secondHalf, n.width, edit)
 
 	if ins < firstHalf {
 		ks := keyStitcher{n1.keys, 0}
@@ -209,7 +241,7 @@ func (n *leafNode) needsMerge(
 	newLen int,
 	left, right *leafNode,
 ) bool {
-	return newLen < minLen && (left != nil || right != nil)
+	return newLen < n.minLen() && (left != nil || right != nil)
 }
 
 func (n *leafNode) removeInPlace(
@@ -237,7 +269,7 @@ func (n *leafNode) copyAndRemoveIdx(
 	left, right *leafNode,
 	edit *atomic.Bool,
 ) nodeReturn {
-	center := newLeaf(newLen, edit)
+	center := newLeaf(newLen, n.width, edit)
 	copy(center.keys, n.keys[0:idx])
 	copy(center.keys[idx:], n.keys[idx+1:])
 	return nodeReturn{
@@ -255,7 +287,7 @@ func (n *leafNode) joinLeft(
 	left, right *leafNode,
 	edit *atomic.Bool,
 ) nodeReturn {
-	join := newLeaf(left.len+newLen, edit)
+	join := newLeaf(left.len+newLen, n.width, edit)
 	ks := keyStitcher{join.keys, 0}
 	ks.copyAll(left.keys, 0, left.len)
 	ks.copyAll(n.keys, 0, idx)
@@ -271,7 +303,7 @@ func (n *leafNode) joinRight(
 	left, right *leafNode,
 	edit *atomic.Bool,
 ) nodeReturn {
-	join := newLeaf(right.len+newLen, edit)
+	join := newLeaf(right.len+newLen, n.width, edit)
 	ks := keyStitcher{join.keys, 0}
 	ks.copyAll(n.keys, 0, idx)
 	ks.copyAll(n.keys, idx+1, n.len)
@@ -283,7 +315,7 @@ func (n *leafNode) joinRight(
 }
 
 func (n *leafNode) canJoin(newLen int) bool {
-	return n != nil && (n.len+newLen) < maxLen
+	return n != nil && (n.len+newLen) < n.maxLen()
 }
 
 func (n *leafNode) borrowLeft(
@@ -308,7 +340,7 @@ func (n *leafNode) borrowLeft(
 		copy(n.keys[0:], left.keys[newLeftLen:left.len])
 		n.len = newCenterLen
 	} else {
-		newCenter = newLeaf(newCenterLen, edit)
+		newCenter = newLeaf(newCenterLen, n.width, edit)
 		ks := keyStitcher{newCenter.keys, 0}
 		ks.copyAll(left.keys, newLeftLen, left.len)
 		ks.copyAll(n.keys, 0, idx)
@@ -320,7 +352,7 @@ func (n *leafNode) borrowLeft(
 		newLeft = left
 		left.len = newLeftLen
 	} else {
-		newLeft = newLeaf(newLeftLen, edit)
+		newLeft = newLeaf(newLeftLen, n.width, edit)
 		copy(newLeft.keys, left.keys[0:newLeftLen])
 	}
 
@@ -352,7 +384,7 @@ func (n *leafNode) borrowRight(
 		ks.copyAll(right.keys, 0, rightHead)
 		n.len = newCenterLen
 	} else {
-		newCenter = newLeaf(newCenterLen, edit)
+		newCenter = newLeaf(newCenterLen, n.width, edit)
 		ks := keyStitcher{newCenter.keys, 0}
 		ks.copyAll(n.keys, 0, idx)
 		ks.copyAll(n.keys, idx+1, n.len)
@@ -365,7 +397,7 @@ func (n *leafNode) borrowRight(
 		copy(right.keys, right.keys[rightHead:right.len])
 		right.len = newRightLen
 	} else {
-		newRight = newLeaf(newRightLen, edit)
+		newRight = newLeaf(newRightLen, n.width, edit)
 		copy(newRight.keys, right.keys[rightHead:right.len])
 	}
 	return nodeReturn{
@@ -0,0 +1,242 @@
+package btree
+
+// Split divides the tree into the keys that are before key and the
+// keys that are not. Nodes that lie entirely on one side of key are
+// shared with the original tree so only the nodes along the path to
+// key are copied.
+func (t *BTree) Split(key interface{}) (*BTree, *BTree) {
+	l, _, r, _ := splitNode(t.root, height(t.root), key, t.cmp)
+	return t.withRoot(l), t.withRoot(r)
+}
+
+// Join concatenates two trees. Every key in left must be before every
+// key in right and both trees must have the same node size. The
+// result uses the comparison and equality functions of left. Only the
+// nodes along the edges where the trees meet are copied.
+func Join(left, right *BTree) *BTree {
+	switch {
+	case !left.SameNodeSize(right):
+		panic(ErrNodeSizeMismatch)
+	case right.count == 0:
+		return left
+	case left.count == 0:
+		return left.withRoot(right.root)
+	case !left.Before(right):
+		panic(ErrOverlap)
+	}
+	root, _ := concat(
+		left.root, height(left.root),
+		right.root, height(right.root),
+	)
+	return left.withRoot(root)
+}
+
+// Before reports whether every key in t is before every key in o.
+func (t *BTree) Before(o *BTree) bool {
+	if t.count == 0 || o.count == 0 {
+		return true
+	}
+	return t.cmp(t.root.maxKey(), minKey(o.root)) < 0
+}
+
+// Adopt returns a tree holding the elements of o with the comparison
+// and equality functions of t. The result shares the nodes of o so
+// the trees must share an ordering and a node size.
+func (t *BTree) Adopt(o *BTree) *BTree {
+	return t.withRoot(o.root)
+}
+
+func (t *BTree) withRoot(root node) *BTree {
+	if root == nil {
+		return t.Clear()
+	}
+	return &BTree{
+		root:  root,
+		count: root.size(),
+		edit:  emptyEdit,
+		cmp:   t.cmp,
+		eq:    t.eq,
+		order: t.order,
+	}
+}
+
+func height(n node) int {
+	var h int
+	for {
+		in, ok := n.(*internalNode)
+		if !ok {
+			return h
+		}
+		n = in.children[0]
+		h++
+	}
+}
+
+func minKey(n node) interface{} {
+	for {
+		in, ok := n.(*internalNode)
+		if !ok {
+			return n.leafPart().keys[0]
+		}
+		n = in.children[0]
+	}
+}
+
+// splitNode divides n, which has height h, into the keys before key
+// and the keys that are not. Either side is nil when it holds no
+// keys.
+func splitNode(
+	n node, h int, key interface{}, cmp compareFunc,
+) (l node, hl int, r node, hr int) {
+	switch n := n.(type) {
+	case *internalNode:
+		idx := n.lowerBound(key, true, cmp)
+		if idx == n.len {
+			return n, h, nil, 0
+		}
+		cl, chl, cr, chr := splitNode(n.children[idx], h-1, key, cmp)
+		pl, phl := partial(n.children[:idx], h)
+		pr, phr := partial(n.children[idx+1:n.len], h)
+		l, hl = concat(pl, phl, cl, chl)
+		r, hr = concat(cr, chr, pr, phr)
+		return l, hl, r, hr
+	default:
+		leaf := n.leafPart()
+		idx := leaf.lowerBound(key, true, cmp)
+		switch idx {
+		case 0:
+			return nil, 0, leafNodeToNode(leaf), 0
+		case leaf.len:
+			return leafNodeToNode(leaf), 0, nil, 0
+		}
+		return makeLeaf(leaf.keys[:idx], leaf.width), 0,
+			makeLeaf(leaf.keys[idx:leaf.len], leaf.width), 0
+	}
+}
+
+// partial returns a node of height h holding children. A single
+// child is returned in place of a parent holding only it.
+func partial(children []node, h int) (node, int) {
+	switch len(children) {
+	case 0:
+		return nil, 0
+	case 1:
+		return children[0], h - 1
+	}
+	return makeInternal(children), h
+}
+
+// concat joins l and r, with heights hl and hr, into a single node
+// and returns it along with its height. Either may be nil. The roots
+// of l and r may hold fewer than the minimum number of entries; every other node
+// must be at least half full and the result preserves this.
+func concat(l node, hl int, r node, hr int) (node, int) {
+	switch {
+	case l == nil:
+		return r, hr
+	case r == nil:
+		return l, hl
+	}
+	var nodes []node
+	var h int
+	if hl >= hr {
+		nodes, h = appendRight(l, hl, r, hr), hl
+	} else {
+		nodes, h = prependLeft(l, hl, r, hr), hr
+	}
+	if len(nodes) == 1 {
+		return nodes[0], h
+	}
+	return makeInternal(nodes), h + 1
+}
+
+// appendRight attaches r to the right edge of l at the level where
+// their heights match. It returns one node, or two when the root of
+// l overflowed.
+func appendRight(l node, hl int, r node, hr int) []node {
+	if hl == hr {
+		return combine(l, r)
+	}
+	ln := l.(*internalNode)
+	last := ln.len - 1
+	sub := appendRight(ln.children[last], hl-1, r, hr)
+	children := make([]node, 0, ln.len+1)
+	children = append(children, ln.children[:last]...)
+	children = append(children, sub...)
+	return makeInternals(children)
+}
+
+// prependLeft attaches l to the left edge of r at the level where
+// their heights match. It returns one node, or two when the root of
+// r overflowed.
+func prependLeft(l node, hl int, r node, hr int) []node {
+	if hl == hr {
+		return combine(l, r)
+	}
+	rn := r.(*internalNode)
+	sub := prependLeft(l, hl, rn.children[0], hr-1)
+	children := make([]node, 0, rn.len+1)
+	children = append(children, sub...)
+	children = append(children, rn.children[1:rn.len]...)
+	return makeInternals(children)
+}
+
+// combine places two nodes of the same height side by side. Nodes
+// that are both at least half full are left untouched, otherwise
+// their entries are merged into one node or shared evenly between
+// two.
+func combine(l, r node) []node {
+	ll, rl := l.leafPart(), r.leafPart()
+	if ll.len >= ll.minLen() && rl.len >= rl.minLen() {
+		return []node{l, r}
+	}
+	ln, ok := l.(*internalNode)
+	if !ok {
+		keys := make([]interface{}, 0, ll.len+rl.len)
+		keys = append(keys, ll.keys[:ll.len]...)
+		keys = append(keys, rl.keys[:rl.len]...)
+		if len(keys) <= ll.maxLen() {
+			return []node{makeLeaf(keys, ll.width)}
+		}
+		half := len(keys) >> 1
+		return []node{
+			makeLeaf(keys[:half], ll.width),
+			makeLeaf(keys[half:], ll.width),
+		}
+	}
+	rn := r.(*internalNode)
+	children := make([]node, 0, ln.len+rn.len)
+	children = append(children, ln.children[:ln.len]...)
+	children = append(children, rn.children[:rn.len]...)
+	return makeInternals(children)
+}
+
+// makeInternals groups children under one parent, or under two when
+// there are too many for one.
+func makeInternals(children []node) []node {
+	if len(children) <= children[0].leafPart().maxLen() {
+		return []node{makeInternal(children)}
+	}
+	half := len(children) >> 1
+	return []node{
+		makeInternal(children[:half]),
+		makeInternal(children[half:]),
+	}
+}
+
+// makeInternal groups children under one parent with the same width
+// as the children.
+func makeInternal(children []node) node {
+	n := newNode(len(children), children[0].leafPart().width, emptyEdit)
+	for i, child := range children {
+		n.keys[i] = child.maxKey()
+		n.children[i] = child
+	}
+	return n.recount()
+}
+
+func makeLeaf(keys []interface{}, width int) node {
+	n := newLeaf(len(keys), width, emptyEdit)
+	copy(n.keys, keys)
+	return n
+}
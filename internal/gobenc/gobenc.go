@@ -0,0 +1,97 @@
+// Package gobenc holds the binary encoding shared by the collections.
+//
+// A collection is encoded as the number of elements, or of entries
+// for maps, as a uvarint. If there are any elements a gob stream
+// holding them as a []interface{} follows; the key and value of each
+// entry are adjacent. As the elements are interface values their
+// concrete types must be registered with gob.Register, which the
+// collection packages do for their own types.
+package gobenc
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/gob"
+	"errors"
+)
+
+var errCorrupt = errors.New("gob: collection encoding is corrupt")
+
+// EncodeElems encodes the elements produced by each.
+func EncodeElems(each func(yield func(elem interface{}) bool)) ([]byte, error) {
+	var elems []interface{}
+	each(func(elem interface{}) bool {
+		elems = append(elems, elem)
+		return true
+	})
+	return encode(elems, 1)
+}
+
+// EncodePairs encodes the entries produced by each.
+func EncodePairs(each func(yield func(key, value interface{}) bool)) ([]byte, error) {
+	var elems []interface{}
+	each(func(key, value interface{}) bool {
+		elems = append(elems, key, value)
+		return true
+	})
+	return encode(elems, 2)
+}
+
+func encode(elems []interface{}, width int) ([]byte, error) {
+	var buf bytes.Buffer
+	var count [binary.MaxVarintLen64]byte
+	buf.Write(count[:binary.PutUvarint(count[:], uint64(len(elems)/width))])
+	if len(elems) == 0 {
+		return buf.Bytes(), nil
+	}
+	if err := gob.NewEncoder(&buf).Encode(elems); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// DecodeElems decodes data and passes each element to add in order.
+func DecodeElems(data []byte, add func(elem interface{})) error {
+	elems, err := decode(data, 1)
+	if err != nil {
+		return err
+	}
+	for _, elem := range elems {
+		add(elem)
+	}
+	return nil
+}
+
+// DecodePairs decodes data and passes each entry to assoc in order.
+func DecodePairs(data []byte, assoc func(key, value interface{})) error {
+	elems, err := decode(data, 2)
+	if err != nil {
+		return err
+	}
+	for i := 0; i < len(elems); i += 2 {
+		assoc(elems[i], elems[i+1])
+	}
+	return nil
+}
+
+func decode(data []byte, width int) ([]interface{}, error) {
+	count, n := binary.Uvarint(data)
+	if n <= 0 {
+		return nil, errCorrupt
+	}
+	if count == 0 {
+		if n != len(data) {
+			return nil, errCorrupt
+		}
+		return nil, nil
+	}
+	var elems []interface{}
+	r := bytes.NewReader(data[n:])
+	if err := gob.NewDecoder(r).Decode(&elems); err != nil {
+		return nil, err
+	}
+	if r.Len() != 0 || uint64(len(elems)) != count*uint64(width) {
+		return nil, errCorrupt
+	}
+	return elems, nil
+}
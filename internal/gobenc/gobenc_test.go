@@ -0,0 +1,72 @@
+package gobenc
+
+import (
+	"bytes"
+	"encoding/gob"
+	"io"
+	"testing"
+)
+
+// frame returns the encoding of elems with count written in place of
+// their number.
+func frame(t *testing.T, count byte, elems ...interface{}) []byte {
+	buf := bytes.NewBuffer([]byte{count})
+	if err := gob.NewEncoder(buf).Encode(elems); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestDecode(t *testing.T) {
+	valid := frame(t, 2, 1, "a", 2, "b")
+	tests := []struct {
+		name  string
+		data  []byte
+		width int
+		elems int
+		err   error
+	}{
+		{name: "elems", data: frame(t, 3, 1, 2, 3), width: 1, elems: 3},
+		{name: "pairs", data: valid, width: 2, elems: 4},
+		{name: "empty", data: []byte{0}, width: 2},
+		{name: "no data", width: 1, err: errCorrupt},
+		{name: "bad count", data: []byte{0x80}, width: 1, err: errCorrupt},
+		{name: "empty with extra data", data: []byte{0, 1}, width: 1, err: errCorrupt},
+		{name: "count too large", data: frame(t, 3, 1, 2), width: 1, err: errCorrupt},
+		{name: "count too small", data: frame(t, 1, 1, 2), width: 1, err: errCorrupt},
+		{name: "partial pair", data: frame(t, 2, 1, "a", 2), width: 2, err: errCorrupt},
+		{name: "trailing bytes", data: append(valid[:len(valid):len(valid)], 0), width: 2, err: errCorrupt},
+		{name: "truncated", data: valid[:len(valid)-1], width: 2, err: io.ErrUnexpectedEOF},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			elems, err := decode(test.data, test.width)
+			if err != test.err {
+				t.Fatal("unexpected error", err)
+			}
+			if len(elems) != test.elems {
+				t.Fatal("unexpected elements", elems)
+			}
+		})
+	}
+}
+
+func TestRoundTrip(t *testing.T) {
+	b, err := EncodePairs(func(yield func(key, value interface{}) bool) {
+		_ = yield(1, "a") && yield(2, "b")
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []interface{}
+	err = DecodePairs(b, func(key, value interface{}) {
+		got = append(got, key, value)
+	})
+	if err != nil || len(got) != 4 || got[0] != 1 || got[3] != "b" {
+		t.Fatal("unexpected decoding", got, err)
+	}
+	b, err = EncodeElems(func(func(interface{}) bool) {})
+	if err != nil || !bytes.Equal(b, []byte{0}) {
+		t.Fatal("expected no elements to encode as their count", b, err)
+	}
+}
@@ -0,0 +1,177 @@
+// Package jsonenc holds the JSON encoding shared by the collections.
+//
+// Sequential collections and sets are encoded as JSON arrays. Maps
+// whose keys are all strings are encoded as JSON objects; any other
+// map is encoded as an array of [key, value] pairs. Both map forms
+// are accepted when decoding.
+package jsonenc
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"sort"
+)
+
+var errPair = errors.New("json: map pairs must be arrays of two elements")
+
+// DecodeFunc decodes a single JSON value into a go value.
+type DecodeFunc func(data []byte) (interface{}, error)
+
+// Native decodes data the way encoding/json decodes into an empty
+// interface.
+func Native(data []byte) (interface{}, error) {
+	var out interface{}
+	err := json.Unmarshal(data, &out)
+	return out, err
+}
+
+// IsNull reports whether data is the JSON null literal. By
+// convention decoding null leaves a value unchanged.
+func IsNull(data []byte) bool {
+	return string(bytes.TrimSpace(data)) == "null"
+}
+
+// EncodeArray encodes the elements produced by each as a JSON
+// array. When sorted is true the encoded elements are sorted so that
+// collections without a defined order encode identically.
+func EncodeArray(each func(yield func(elem interface{}) bool), sorted bool) ([]byte, error) {
+	var elems [][]byte
+	var err error
+	each(func(elem interface{}) bool {
+		var b []byte
+		b, err = json.Marshal(elem)
+		elems = append(elems, b)
+		return err == nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if sorted {
+		sort.Slice(elems, func(i, j int) bool {
+			return bytes.Compare(elems[i], elems[j]) < 0
+		})
+	}
+	return join('[', elems, ']'), nil
+}
+
+// EncodeMap encodes the entries produced by each as a JSON object if
+// every key is a string and as an array of [key, value] pairs
+// otherwise. When sorted is true the entries are sorted by their
+// encoded keys.
+func EncodeMap(each func(yield func(key, value interface{}) bool), sorted bool) ([]byte, error) {
+	type pair struct {
+		key, value []byte
+	}
+	var pairs []pair
+	var err error
+	allStrings := true
+	each(func(key, value interface{}) bool {
+		var p pair
+		_, isString := key.(string)
+		allStrings = allStrings && isString
+		if p.key, err = json.Marshal(key); err != nil {
+			return false
+		}
+		if p.value, err = json.Marshal(value); err != nil {
+			return false
+		}
+		pairs = append(pairs, p)
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+	if sorted {
+		sort.Slice(pairs, func(i, j int) bool {
+			return bytes.Compare(pairs[i].key, pairs[j].key) < 0
+		})
+	}
+	elems := make([][]byte, len(pairs))
+	for i, p := range pairs {
+		if allStrings {
+			elems[i] = append(append(p.key, ':'), p.value...)
+		} else {
+			elems[i] = join('[', [][]byte{p.key, p.value}, ']')
+		}
+	}
+	if allStrings {
+		return join('{', elems, '}'), nil
+	}
+	return join('[', elems, ']'), nil
+}
+
+func join(open byte, elems [][]byte, close byte) []byte {
+	var b bytes.Buffer
+	b.WriteByte(open)
+	for i, elem := range elems {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		b.Write(elem)
+	}
+	b.WriteByte(close)
+	return b.Bytes()
+}
+
+// DecodeArray decodes each element of the JSON array in data with
+// decode and passes the results to add in order.
+func DecodeArray(data []byte, decode DecodeFunc, add func(elem interface{})) error {
+	if decode == nil {
+		decode = Native
+	}
+	var raw []json.RawMessage
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	for _, r := range raw {
+		elem, err := decode(r)
+		if err != nil {
+			return err
+		}
+		add(elem)
+	}
+	return nil
+}
+
+// DecodeMap decodes a JSON object or an array of [key, value] pairs
+// and passes each entry to assoc. Object keys are passed as strings;
+// the keys of pairs and all values are decoded with decode.
+func DecodeMap(data []byte, decode DecodeFunc, assoc func(key, value interface{})) error {
+	if decode == nil {
+		decode = Native
+	}
+	if trimmed := bytes.TrimSpace(data); len(trimmed) > 0 && trimmed[0] == '[' {
+		var pairs [][]json.RawMessage
+		if err := json.Unmarshal(trimmed, &pairs); err != nil {
+			return err
+		}
+		for _, p := range pairs {
+			if len(p) != 2 {
+				return errPair
+			}
+			key, err := decode(p[0])
+			if err != nil {
+				return err
+			}
+			value, err := decode(p[1])
+			if err != nil {
+				return err
+			}
+			assoc(key, value)
+		}
+		return nil
+	}
+	var obj map[string]json.RawMessage
+	if err := json.Unmarshal(data, &obj); err != nil {
+		return err
+	}
+	for key, r := range obj {
+		value, err := decode(r)
+		if err != nil {
+			return err
+		}
+		assoc(key, value)
+	}
+	return nil
+}
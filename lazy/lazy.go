@@ -0,0 +1,393 @@
+// Package lazy builds lazy sequences on top of seq.Sequence.
+//
+// Each function takes a collection, anything accepted by seq.Seq, and
+// returns a seq.Sequence whose elements are only computed as the
+// sequence is walked. An empty sequence is nil, so the first element
+// of a sequence is computed when the sequence is made; the rest of it
+// is computed the first time Next is called and remembered, so
+// walking a sequence again does not call the supplied functions
+// again. Sequences are safe to share between goroutines.
+//
+// Into collects a sequence into any collection with a Conj method,
+// using a transient when the collection provides MakeTransient.
+package lazy // import "jsouthworth.net/go/immutable/lazy"
+
+import (
+	"errors"
+	"reflect"
+	"sync"
+
+	"jsouthworth.net/go/dyn"
+	"jsouthworth.net/go/immutable/vector"
+	"jsouthworth.net/go/seq"
+)
+
+var errMapSig = errors.New("Map requires a function: func(v vT) oT")
+var errFilterSig = errors.New("Filter requires a function: func(v vT) bool")
+var errIterateSig = errors.New("Iterate requires a function: func(v vT) vT")
+var errRangeStep = errors.New("Range requires a non-zero step")
+var errPartitionSize = errors.New("Partition requires a positive size")
+var errIntoType = errors.New("Into requires a collection with a Conj method")
+
+// cell is a memoised sequence. The first element is known when the
+// cell is made and rest computes the remainder of the sequence when
+// Next is first called.
+type cell struct {
+	first interface{}
+	once  sync.Once
+	rest  func() seq.Sequence
+	next  seq.Sequence
+}
+
+func (c *cell) First() interface{} {
+	return c.first
+}
+
+func (c *cell) Next() seq.Sequence {
+	c.once.Do(func() {
+		c.next = c.rest()
+		c.rest = nil
+	})
+	return c.next
+}
+
+func (c *cell) String() string {
+	return seq.ConvertToString(c)
+}
+
+// Map returns a sequence of the results of calling fn on each element
+// of coll. Map can take the following types as the fn:
+//
+// func(value interface{}) interface{}
+// func(v vT) oT
+//
+// Map will panic if given any other function type.
+func Map(fn interface{}, coll interface{}) seq.Sequence {
+	var f func(v interface{}) interface{}
+	switch fn := fn.(type) {
+	case func(value interface{}) interface{}:
+		f = fn
+	default:
+		f = genUnaryFunc(fn, errMapSig)
+	}
+	return mapSeq(f, seq.Seq(coll))
+}
+
+func mapSeq(f func(v interface{}) interface{}, s seq.Sequence) seq.Sequence {
+	if s == nil {
+		return nil
+	}
+	return &cell{
+		first: f(s.First()),
+		rest: func() seq.Sequence {
+			return mapSeq(f, s.Next())
+		},
+	}
+}
+
+func genUnaryFunc(fn interface{}, sigErr error) func(v interface{}) interface{} {
+	rv := reflect.ValueOf(fn)
+	if rv.Kind() != reflect.Func {
+		panic(sigErr)
+	}
+	rt := rv.Type()
+	if rt.NumIn() != 1 || rt.NumOut() != 1 {
+		panic(sigErr)
+	}
+	return func(v interface{}) interface{} {
+		return dyn.Apply(fn, v)
+	}
+}
+
+// Filter returns a sequence of the elements of coll for which fn
+// returns true. Filter can take the following types as the fn:
+//
+// func(value interface{}) bool
+// func(v vT) bool
+//
+// Filter will panic if given any other function type.
+func Filter(fn interface{}, coll interface{}) seq.Sequence {
+	var pred func(v interface{}) bool
+	switch fn := fn.(type) {
+	case func(value interface{}) bool:
+		pred = fn
+	default:
+		pred = genFilterFunc(fn)
+	}
+	return filterSeq(pred, seq.Seq(coll))
+}
+
+func filterSeq(pred func(v interface{}) bool, s seq.Sequence) seq.Sequence {
+	for ; s != nil; s = s.Next() {
+		if !pred(s.First()) {
+			continue
+		}
+		found := s
+		return &cell{
+			first: found.First(),
+			rest: func() seq.Sequence {
+				return filterSeq(pred, found.Next())
+			},
+		}
+	}
+	return nil
+}
+
+func genFilterFunc(fn interface{}) func(v interface{}) bool {
+	rv := reflect.ValueOf(fn)
+	if rv.Kind() != reflect.Func {
+		panic(errFilterSig)
+	}
+	rt := rv.Type()
+	if rt.NumIn() != 1 || rt.NumOut() != 1 ||
+		rt.Out(0).Kind() != reflect.Bool {
+		panic(errFilterSig)
+	}
+	return func(v interface{}) bool {
+		return dyn.Apply(fn, v).(bool)
+	}
+}
+
+// Take returns a sequence of at most the first n elements of coll.
+func Take(n int, coll interface{}) seq.Sequence {
+	return takeSeq(n, seq.Seq(coll))
+}
+
+func takeSeq(n int, s seq.Sequence) seq.Sequence {
+	if n <= 0 || s == nil {
+		return nil
+	}
+	return &cell{
+		first: s.First(),
+		rest: func() seq.Sequence {
+			if n == 1 {
+				return nil
+			}
+			return takeSeq(n-1, s.Next())
+		},
+	}
+}
+
+// Drop returns the sequence of the elements of coll after the first
+// n. The dropped elements are walked when Drop is called.
+func Drop(n int, coll interface{}) seq.Sequence {
+	s := seq.Seq(coll)
+	for ; n > 0 && s != nil; n-- {
+		s = s.Next()
+	}
+	return s
+}
+
+// Concat returns a sequence of the elements of each of colls in turn.
+func Concat(colls ...interface{}) seq.Sequence {
+	return concatSeq(nil, colls)
+}
+
+func concatSeq(s seq.Sequence, colls []interface{}) seq.Sequence {
+	for s == nil {
+		if len(colls) == 0 {
+			return nil
+		}
+		s, colls = seq.Seq(colls[0]), colls[1:]
+	}
+	return &cell{
+		first: s.First(),
+		rest: func() seq.Sequence {
+			return concatSeq(s.Next(), colls)
+		},
+	}
+}
+
+// Interleave returns a sequence of the first element of each of
+// colls, then the second of each and so on. The sequence ends when
+// any of the collections runs out.
+func Interleave(colls ...interface{}) seq.Sequence {
+	seqs := make([]seq.Sequence, len(colls))
+	for i, coll := range colls {
+		seqs[i] = seq.Seq(coll)
+	}
+	return interleaveSeq(seqs)
+}
+
+func interleaveSeq(seqs []seq.Sequence) seq.Sequence {
+	if len(seqs) == 0 {
+		return nil
+	}
+	for _, s := range seqs {
+		if s == nil {
+			return nil
+		}
+	}
+	return roundSeq(seqs, 0)
+}
+
+// roundSeq returns the sequence starting at the i-th collection of
+// the current round.
+func roundSeq(seqs []seq.Sequence, i int) seq.Sequence {
+	return &cell{
+		first: seqs[i].First(),
+		rest: func() seq.Sequence {
+			if i+1 < len(seqs) {
+				return roundSeq(seqs, i+1)
+			}
+			next := make([]seq.Sequence, len(seqs))
+			for j, s := range seqs {
+				next[j] = s.Next()
+			}
+			return interleaveSeq(next)
+		},
+	}
+}
+
+// Iterate returns the infinite sequence x, fn(x), fn(fn(x)) and so
+// on. Iterate can take the following types as the fn:
+//
+// func(value interface{}) interface{}
+// func(v vT) vT
+//
+// Iterate will panic if given any other function type.
+func Iterate(fn interface{}, x interface{}) seq.Sequence {
+	var f func(v interface{}) interface{}
+	switch fn := fn.(type) {
+	case func(value interface{}) interface{}:
+		f = fn
+	default:
+		f = genUnaryFunc(fn, errIterateSig)
+	}
+	return iterateSeq(f, x)
+}
+
+func iterateSeq(f func(v interface{}) interface{}, x interface{}) seq.Sequence {
+	return &cell{
+		first: x,
+		rest: func() seq.Sequence {
+			return iterateSeq(f, f(x))
+		},
+	}
+}
+
+// Repeat returns the infinite sequence of x. Use Take to bound it.
+func Repeat(x interface{}) seq.Sequence {
+	return &repeatSeq{value: x}
+}
+
+type repeatSeq struct {
+	value interface{}
+}
+
+func (s *repeatSeq) First() interface{} {
+	return s.value
+}
+
+func (s *repeatSeq) Next() seq.Sequence {
+	return s
+}
+
+// Range returns the sequence of integers from start up to, but not
+// including, end counting by step. A negative step counts down.
+// Range will panic if step is zero.
+func Range(start, end, step int) seq.Sequence {
+	if step == 0 {
+		panic(errRangeStep)
+	}
+	if (step > 0 && start >= end) || (step < 0 && start <= end) {
+		return nil
+	}
+	return &cell{
+		first: start,
+		rest: func() seq.Sequence {
+			return Range(start+step, end, step)
+		},
+	}
+}
+
+// Partition returns a sequence of vectors of n consecutive elements
+// of coll. The last vector holds the remaining elements and may be
+// shorter than n. Partition will panic if n is not positive.
+func Partition(n int, coll interface{}) seq.Sequence {
+	if n <= 0 {
+		panic(errPartitionSize)
+	}
+	return partitionSeq(n, seq.Seq(coll))
+}
+
+func partitionSeq(n int, s seq.Sequence) seq.Sequence {
+	if s == nil {
+		return nil
+	}
+	part := vector.Empty().AsTransient()
+	for ; s != nil && part.Length() < n; s = s.Next() {
+		part = part.Append(s.First())
+	}
+	return &cell{
+		first: part.AsPersistent(),
+		rest: func() seq.Sequence {
+			return partitionSeq(n, s)
+		},
+	}
+}
+
+// Dedupe returns a sequence of the elements of coll with consecutive
+// duplicates removed. Elements are compared with dyn.Equal.
+func Dedupe(coll interface{}) seq.Sequence {
+	return dedupeSeq(seq.Seq(coll))
+}
+
+func dedupeSeq(s seq.Sequence) seq.Sequence {
+	if s == nil {
+		return nil
+	}
+	value := s.First()
+	return &cell{
+		first: value,
+		rest: func() seq.Sequence {
+			next := s.Next()
+			for next != nil && dyn.Equal(next.First(), value) {
+				next = next.Next()
+			}
+			return dedupeSeq(next)
+		},
+	}
+}
+
+type conjer interface {
+	Conj(elem interface{}) interface{}
+}
+
+type transientable interface {
+	MakeTransient() interface{}
+}
+
+type persistable interface {
+	MakePersistent() interface{}
+}
+
+// Into adds each element of coll to the collection to using its Conj
+// method and returns the result. If to has a MakeTransient method the
+// elements are added to the transient, which must have Conj and
+// MakePersistent methods. Care should be taken to provide finite
+// sequences. Into will panic if to does not have a Conj method.
+func Into(to interface{}, coll interface{}) interface{} {
+	if t, ok := to.(transientable); ok {
+		if _, ok := to.(conjer); !ok {
+			panic(errIntoType)
+		}
+		out, ok := conjAll(t.MakeTransient(), seq.Seq(coll)).(persistable)
+		if !ok {
+			panic(errIntoType)
+		}
+		return out.MakePersistent()
+	}
+	return conjAll(to, seq.Seq(coll))
+}
+
+func conjAll(to interface{}, s seq.Sequence) interface{} {
+	for ; s != nil; s = s.Next() {
+		c, ok := to.(conjer)
+		if !ok {
+			panic(errIntoType)
+		}
+		to = c.Conj(s.First())
+	}
+	return to
+}
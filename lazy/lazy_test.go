@@ -0,0 +1,204 @@
+package lazy
+
+import (
+	"testing"
+
+	"github.com/leanovate/gopter"
+	"github.com/leanovate/gopter/gen"
+	"github.com/leanovate/gopter/prop"
+	"jsouthworth.net/go/dyn"
+	"jsouthworth.net/go/immutable/hashset"
+	"jsouthworth.net/go/immutable/list"
+	"jsouthworth.net/go/immutable/stack"
+	"jsouthworth.net/go/immutable/vector"
+	"jsouthworth.net/go/seq"
+)
+
+func ints(s seq.Sequence) []int {
+	var out []int
+	for ; s != nil; s = s.Next() {
+		out = append(out, s.First().(int))
+	}
+	return out
+}
+
+func intsEqual(a, b []int) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func TestLazy(t *testing.T) {
+	parameters := gopter.DefaultTestParameters()
+	properties := gopter.NewProperties(parameters)
+	xsGen := gen.SliceOf(gen.IntRange(0, 5))
+	properties.Property("Map and Filter match a slice", prop.ForAll(
+		func(xs []int) bool {
+			var doubled, odds []int
+			for _, x := range xs {
+				doubled = append(doubled, 2*x)
+				if x%2 == 1 {
+					odds = append(odds, x)
+				}
+			}
+			return intsEqual(ints(Map(func(x int) int { return 2 * x }, xs)), doubled) &&
+				intsEqual(ints(Filter(func(x int) bool { return x%2 == 1 }, xs)), odds)
+		},
+		xsGen,
+	))
+	properties.Property("Take and Drop split a sequence", prop.ForAll(
+		func(xs []int, n int) bool {
+			k := n
+			if k > len(xs) {
+				k = len(xs)
+			}
+			return intsEqual(ints(Take(n, xs)), xs[:k]) &&
+				intsEqual(ints(Drop(n, xs)), xs[k:])
+		},
+		xsGen, gen.IntRange(0, 20),
+	))
+	properties.Property("Concat joins sequences", prop.ForAll(
+		func(xs, ys []int) bool {
+			want := append(append([]int{}, xs...), ys...)
+			return intsEqual(ints(Concat(xs, nil, ys)), want)
+		},
+		xsGen, xsGen,
+	))
+	properties.Property("Dedupe removes consecutive duplicates", prop.ForAll(
+		func(xs []int) bool {
+			var want []int
+			for i, x := range xs {
+				if i == 0 || xs[i-1] != x {
+					want = append(want, x)
+				}
+			}
+			return intsEqual(ints(Dedupe(xs)), want)
+		},
+		xsGen,
+	))
+	properties.Property("Partition chunks a sequence", prop.ForAll(
+		func(xs []int, n int) bool {
+			var got []int
+			parts := 0
+			for s := Partition(n, xs); s != nil; s = s.Next() {
+				part := s.First().(*vector.Vector)
+				if part.Length() > n || (part.Length() < n && s.Next() != nil) {
+					return false
+				}
+				part.Range(func(_ int, x int) { got = append(got, x) })
+				parts++
+			}
+			return intsEqual(got, xs) && parts == (len(xs)+n-1)/n
+		},
+		xsGen, gen.IntRange(1, 5),
+	))
+	properties.TestingRun(t)
+}
+
+func TestMemoised(t *testing.T) {
+	calls := 0
+	s := Map(func(x interface{}) interface{} {
+		calls++
+		return x
+	}, []int{1, 2, 3})
+	ints(s)
+	ints(s)
+	if calls != 3 {
+		t.Fatal("expected each element to be mapped once, got", calls)
+	}
+}
+
+func TestLaziness(t *testing.T) {
+	calls := 0
+	s := Map(func(x interface{}) interface{} {
+		calls++
+		return x
+	}, Iterate(func(x int) int { return x + 1 }, 0))
+	if got := ints(Take(3, s)); !intsEqual(got, []int{0, 1, 2}) {
+		t.Fatal("unexpected elements", got)
+	}
+	if calls != 3 {
+		t.Fatal("expected only the taken elements to be mapped, got", calls)
+	}
+}
+
+func TestInfinite(t *testing.T) {
+	if got := ints(Take(3, Repeat(7))); !intsEqual(got, []int{7, 7, 7}) {
+		t.Fatal("unexpected Repeat", got)
+	}
+	got := ints(Take(7, Interleave(Repeat(0), Range(1, 100, 1))))
+	if !intsEqual(got, []int{0, 1, 0, 2, 0, 3, 0}) {
+		t.Fatal("unexpected Interleave", got)
+	}
+	if got := ints(Interleave(Repeat(0), []int{1, 2})); !intsEqual(got, []int{0, 1, 0, 2}) {
+		t.Fatal("expected Interleave to stop at the shortest", got)
+	}
+}
+
+func TestRange(t *testing.T) {
+	tests := []struct {
+		start, end, step int
+		want             []int
+	}{
+		{0, 5, 1, []int{0, 1, 2, 3, 4}},
+		{0, 5, 2, []int{0, 2, 4}},
+		{5, 0, -2, []int{5, 3, 1}},
+		{5, 5, 1, nil},
+		{5, 0, 1, nil},
+	}
+	for _, test := range tests {
+		if got := ints(Range(test.start, test.end, test.step)); !intsEqual(got, test.want) {
+			t.Fatal("unexpected range", test, got)
+		}
+	}
+}
+
+func TestInto(t *testing.T) {
+	v := Into(vector.Empty(), Range(0, 3, 1))
+	if !dyn.Equal(v, vector.New(0, 1, 2)) {
+		t.Fatal("unexpected vector", v)
+	}
+	s := Into(hashset.Empty(), Dedupe([]int{1, 1, 2}))
+	if !dyn.Equal(s, hashset.New(1, 2)) {
+		t.Fatal("unexpected set", s)
+	}
+	l := Into(list.Empty(), Range(0, 3, 1))
+	if !dyn.Equal(l, list.New(2, 1, 0)) {
+		t.Fatal("unexpected list", l)
+	}
+	st := Into(stack.Empty(), Range(0, 3, 1))
+	if !dyn.Equal(st, stack.New(0, 1, 2)) {
+		t.Fatal("unexpected stack", st)
+	}
+}
+
+func TestPanics(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		do   func()
+	}{
+		{"Map", errMapSig, func() { Map(1, nil) }},
+		{"Filter", errFilterSig, func() { Filter(func(int) int { return 0 }, nil) }},
+		{"Iterate", errIterateSig, func() { Iterate(func() {}, 0) }},
+		{"Range", errRangeStep, func() { Range(0, 1, 0) }},
+		{"Partition", errPartitionSize, func() { Partition(0, nil) }},
+		{"Into", errIntoType, func() { Into(1, []int{1}) }},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			defer func() {
+				if r := recover(); r != test.err {
+					t.Fatal("expected", test.err, "got", r)
+				}
+			}()
+			test.do()
+		})
+	}
+}
@@ -0,0 +1,28 @@
+package linkedmap
+
+import (
+	"bytes"
+	"encoding/gob"
+	"testing"
+)
+
+func TestGobKeepsOrder(t *testing.T) {
+	m := New(3, "c", 1, "a", 2, "b").Delete(1).Assoc(1, "A")
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(m); err != nil {
+		t.Fatal(err)
+	}
+	var out *Map
+	if err := gob.NewDecoder(&buf).Decode(&out); err != nil {
+		t.Fatal(err)
+	}
+	if out.String() != m.String() {
+		t.Fatal("expected the decoded map to keep the insertion order", out, m)
+	}
+	if out.First().Key() != 3 || out.Last().Key() != 1 {
+		t.Fatal("unexpected ends", out.First(), out.Last())
+	}
+	if out = out.Assoc(4, "d"); out.Last().Key() != 4 || out.Length() != 4 {
+		t.Fatal("expected new keys to follow the decoded ones", out)
+	}
+}
@@ -0,0 +1,555 @@
+// Package linkedmap implements a persistent map that remembers the
+// order in which its keys were first associated.
+//
+// Each key is given a sequence number when it is first added to the
+// map. A hashmap.Map from key to sequence number provides lookup and
+// a treemap.Map ordered by sequence number holds the entries in
+// insertion order. Deleting a key removes it from both, so no
+// tombstones are left behind and the size of the map is always
+// proportional to the number of keys it holds.
+package linkedmap // import "jsouthworth.net/go/immutable/linkedmap"
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"jsouthworth.net/go/dyn"
+	"jsouthworth.net/go/immutable/hashmap"
+	"jsouthworth.net/go/immutable/treemap"
+	"jsouthworth.net/go/seq"
+)
+
+var errOddElements = errors.New("must supply an even number elements")
+var errRangeSig = errors.New("Range requires a function: func(k kT, v vT) bool or func(k kT, v vT)")
+var errReduceSig = errors.New("Reduce requires a function: func(init iT, k kT, v vT) oT or func(init iT, e Entry) oT")
+var errTransformSig = errors.New("Transform requires functions: func(t *TMap) *TMap or func(t *TMap)")
+
+// Entry is a map entry. Each entry consists of a key and value.
+type Entry interface {
+	Key() interface{}
+	Value() interface{}
+}
+
+// EntryNew constructs a map entry that may be used with Conj.
+func EntryNew(key, value interface{}) Entry {
+	return entry{key, value}
+}
+
+type entry struct {
+	key   interface{}
+	value interface{}
+}
+
+func (e entry) Key() interface{} {
+	return e.key
+}
+
+func (e entry) Value() interface{} {
+	return e.value
+}
+
+func (e entry) String() string {
+	return fmt.Sprintf("[%v %v]", e.key, e.value)
+}
+
+func compareSeq(a, b interface{}) int {
+	x, y := a.(int), b.(int)
+	switch {
+	case x < y:
+		return -1
+	case x > y:
+		return 1
+	default:
+		return 0
+	}
+}
+
+var emptyOrder = treemap.Empty(treemap.Compare(compareSeq))
+
+// Map is a persistent immutable map that ranges over its entries in
+// the order their keys were first associated. Associating a key that
+// is already in the map changes its value but not its position.
+type Map struct {
+	// index maps each key to its sequence number.
+	index *hashmap.Map
+	// order maps each sequence number to the entry holding it.
+	order *treemap.Map
+	next  int
+}
+
+// Empty returns a new empty persistent map.
+func Empty() *Map {
+	return &Map{
+		index: hashmap.Empty(),
+		order: emptyOrder,
+	}
+}
+
+// New converts a list of elements to a persistent map by associating
+// them pairwise in the order given. New will panic if the number of
+// elements is not even.
+func New(elems ...interface{}) *Map {
+	if len(elems)%2 != 0 {
+		panic(errOddElements)
+	}
+	out := Empty().AsTransient()
+	for i := 0; i < len(elems); i += 2 {
+		out = out.Assoc(elems[i], elems[i+1])
+	}
+	return out.AsPersistent()
+}
+
+// From will convert many different go types to an insertion ordered
+// map. Only types with a defined order are accepted.
+//
+// *Map:
+//
+//	Returned directly as it is already immutable.
+//
+// *TMap:
+//
+//	AsPersistent is called on it and the result is returned.
+//
+// []Entry:
+//
+//	The entries are associated in order with an empty transient map. The transient map is converted to a persistent map and then returned.
+//
+// []interface{}:
+//
+//	The elements are passed to New.
+//
+// seq.Sequence:
+//
+//	Each element of the sequence must be an Entry and is associated in order.
+//
+// seq.Seqable:
+//
+//	Each element of the sequence returned by Seq must be an Entry and is associated in order.
+//
+// []T:
+//
+//	Reflection is used to convert the slice to []interface{} and then passed to New.
+//
+// Other:
+//
+//	Returns Empty()
+func From(value interface{}) *Map {
+	switch v := value.(type) {
+	case *Map:
+		return v
+	case *TMap:
+		return v.AsPersistent()
+	case []Entry:
+		out := Empty().AsTransient()
+		for _, entry := range v {
+			out = out.Assoc(entry.Key(), entry.Value())
+		}
+		return out.AsPersistent()
+	case []interface{}:
+		return New(v...)
+	case seq.Seqable:
+		return mapFromSequence(v.Seq())
+	case seq.Sequence:
+		return mapFromSequence(v)
+	default:
+		return mapFromReflection(value)
+	}
+}
+
+func mapFromSequence(coll seq.Sequence) *Map {
+	out := Empty().AsTransient()
+	for s := coll; s != nil; s = s.Next() {
+		out = out.Conj(s.First()).(*TMap)
+	}
+	return out.AsPersistent()
+}
+
+func mapFromReflection(value interface{}) *Map {
+	v := reflect.ValueOf(value)
+	if v.Kind() != reflect.Slice {
+		return Empty()
+	}
+	sl := make([]interface{}, v.Len())
+	for i := 0; i < v.Len(); i++ {
+		sl[i] = v.Index(i).Interface()
+	}
+	return New(sl...)
+}
+
+// At returns the value associated with the key.
+// If one is not found, nil is returned.
+func (m *Map) At(key interface{}) interface{} {
+	v, _ := m.Find(key)
+	return v
+}
+
+// EntryAt returns the entry (key, value pair) of the key.
+// If one is not found, nil is returned.
+func (m *Map) EntryAt(key interface{}) Entry {
+	n, ok := m.index.Find(key)
+	if !ok {
+		return nil
+	}
+	return m.order.At(n).(Entry)
+}
+
+// Find will return the value for a key if it exists in the map and
+// whether the key exists in the map. For non-nil values, exists will
+// always be true.
+func (m *Map) Find(key interface{}) (value interface{}, exists bool) {
+	e := m.EntryAt(key)
+	if e == nil {
+		return nil, false
+	}
+	return e.Value(), true
+}
+
+// Contains will test if the key exists in the map.
+func (m *Map) Contains(key interface{}) bool {
+	return m.index.Contains(key)
+}
+
+// Assoc associates a value with a key in the map. A new persistent
+// map is returned if the key and value are different from one already
+// in the map. A key that is already in the map keeps its position. A
+// new key is placed after every other key.
+func (m *Map) Assoc(key, value interface{}) *Map {
+	n, ok := m.index.Find(key)
+	if ok {
+		if dyn.Equal(m.order.At(n).(Entry).Value(), value) {
+			return m
+		}
+		return &Map{
+			index: m.index,
+			order: m.order.Assoc(n, entry{key, value}),
+			next:  m.next,
+		}
+	}
+	return &Map{
+		index: m.index.Assoc(key, m.next),
+		order: m.order.Assoc(m.next, entry{key, value}),
+		next:  m.next + 1,
+	}
+}
+
+// Conj takes a value that must be an Entry. Conj implements
+// a generic mechanism for building collections.
+func (m *Map) Conj(value interface{}) interface{} {
+	entry := value.(Entry)
+	return m.Assoc(entry.Key(), entry.Value())
+}
+
+// Delete removes a key and associated value from the map. If the key
+// is not in the map, m is returned.
+func (m *Map) Delete(key interface{}) *Map {
+	n, ok := m.index.Find(key)
+	if !ok {
+		return m
+	}
+	return &Map{
+		index: m.index.Delete(key),
+		order: m.order.Delete(n),
+		next:  m.next,
+	}
+}
+
+// Length returns the number of entries in the map.
+func (m *Map) Length() int {
+	return m.index.Length()
+}
+
+// First returns the entry whose key was added to the map earliest.
+// If the map is empty, nil is returned.
+func (m *Map) First() Entry {
+	return nextEntry(m.order.Iterator())
+}
+
+// nextEntry returns the entry iter visits next, or nil if iter is
+// exhausted. First and Last pass iterators in opposite directions.
+func nextEntry(iter treemap.Iterator) Entry {
+	if !iter.HasNext() {
+		return nil
+	}
+	_, v := iter.Next()
+	return v.(Entry)
+}
+
+// Last returns the entry whose key was added to the map most
+// recently. If the map is empty, nil is returned.
+func (m *Map) Last() Entry {
+	return nextEntry(m.order.ReverseIterator())
+}
+
+// Range will loop over the entries in the map in insertion order and
+// call 'do' on each entry. The 'do' function may be of many types:
+//
+// func(key, value interface{}) bool:
+//
+//	Takes empty interfaces and returns if the loop should continue.
+//	Useful to avoid reflection or for hetrogenous maps.
+//
+// func(key, value interface{}):
+//
+//	Takes empty interfaces.
+//	Useful to avoid reflection or for hetrogenous maps.
+//
+// func(entry Entry) bool:
+//
+//	Takes the Entry type and returns if the loop should continue
+//	Is called directly and avoids entry allocation.
+//
+// func(entry Entry):
+//
+//	Takes the Entry type.
+//	Is called directly and avoids entry allocation.
+//
+// func(k kT, v vT) bool
+//
+//	Takes a key of key type and a value of value type and returns if the loop should contiune.
+//	Is called with reflection and will panic if the kT and vT types are incorrect.
+//
+// func(k kT, v vT)
+//
+//	Takes a key of key type and a value of value type.
+//	Is called with reflection and will panic if the kT and vT types are incorrect.
+//
+// Range will panic if passed anything not matching these signatures.
+func (m *Map) Range(do interface{}) {
+	rangeOrder(m.order, do)
+}
+
+func rangeOrder(order interface{ Range(interface{}) }, do interface{}) {
+	// NOTE: Update other functions using the same pattern
+	//       when modifying the below.
+	//       This code is inlined to avoid heap allocation of
+	//       the closure.
+	var f func(Entry) bool
+	switch fn := do.(type) {
+	case func(key, value interface{}) bool:
+		f = func(entry Entry) bool {
+			return fn(entry.Key(), entry.Value())
+		}
+	case func(key, value interface{}):
+		f = func(entry Entry) bool {
+			fn(entry.Key(), entry.Value())
+			return true
+		}
+	case func(e Entry) bool:
+		f = fn
+	case func(e Entry):
+		f = func(entry Entry) bool {
+			fn(entry)
+			return true
+		}
+	default:
+		f = genRangeFunc(do)
+	}
+	order.Range(func(_, e interface{}) bool {
+		return f(e.(Entry))
+	})
+}
+
+func genRangeFunc(do interface{}) func(Entry) bool {
+	rv := reflect.ValueOf(do)
+	if rv.Kind() != reflect.Func {
+		panic(errRangeSig)
+	}
+	rt := rv.Type()
+	if rt.NumIn() != 2 || rt.NumOut() > 1 {
+		panic(errRangeSig)
+	}
+	if rt.NumOut() == 1 &&
+		rt.Out(0).Kind() != reflect.Bool {
+		panic(errRangeSig)
+	}
+	return func(entry Entry) bool {
+		out := dyn.Apply(do, entry.Key(), entry.Value())
+		if out != nil {
+			return out.(bool)
+		}
+		return true
+	}
+}
+
+// Reduce is a fast mechanism for reducing a Map in insertion order.
+// Reduce can take the following types as the fn:
+//
+// func(init interface{}, entry Entry) interface{}
+// func(init interface{}, key interface{}, value interface{}) interface{}
+// func(init iT, e Entry) oT
+// func(init iT, k kT, v vT) oT
+// Reduce will panic if given any other function type.
+func (m *Map) Reduce(fn interface{}, init interface{}) interface{} {
+	// NOTE: Update other functions using the same pattern
+	//       when modifying the below.
+	//       This code is inlined to avoid heap allocation of
+	//       the closure.
+	var rFn func(interface{}, Entry) interface{}
+	switch v := fn.(type) {
+	case func(interface{}, Entry) interface{}:
+		rFn = v
+	case func(interface{}, interface{}) interface{}:
+		rFn = func(init interface{}, entry Entry) interface{} {
+			return v(init, entry)
+		}
+	case func(interface{}, interface{}, interface{}) interface{}:
+		rFn = func(init interface{}, entry Entry) interface{} {
+			return v(init, entry.Key(), entry.Value())
+		}
+	default:
+		rFn = genReduceFunc(fn)
+	}
+	res := init
+	m.Range(func(e Entry) {
+		res = rFn(res, e)
+	})
+	return res
+}
+
+func genReduceFunc(fn interface{}) func(interface{}, Entry) interface{} {
+	rv := reflect.ValueOf(fn)
+	if rv.Kind() != reflect.Func {
+		panic(errReduceSig)
+	}
+	rt := rv.Type()
+	if rt.NumOut() != 1 {
+		panic(errReduceSig)
+	}
+	switch rt.NumIn() {
+	case 2:
+		return func(i interface{}, e Entry) interface{} {
+			return dyn.Apply(fn, i, e)
+		}
+	case 3:
+		return func(i interface{}, e Entry) interface{} {
+			return dyn.Apply(fn, i, e.Key(), e.Value())
+		}
+	default:
+		panic(errReduceSig)
+	}
+}
+
+// Seq returns a seralized sequence of Entry corresponding to the
+// maps entries in insertion order.
+func (m *Map) Seq() seq.Sequence {
+	return orderSeqNew(m.order.Seq())
+}
+
+// String returns a string representation of the map.
+func (m *Map) String() string {
+	var b strings.Builder
+	fmt.Fprint(&b, "{ ")
+	m.Range(func(entry Entry) {
+		fmt.Fprintf(&b, "%s ", entry)
+	})
+	fmt.Fprint(&b, "}")
+	return b.String()
+}
+
+// AsNative returns the map converted to a go native map type. The
+// insertion order is lost.
+func (m *Map) AsNative() map[interface{}]interface{} {
+	out := make(map[interface{}]interface{}, m.Length())
+	m.Range(func(key, value interface{}) {
+		out[key] = value
+	})
+	return out
+}
+
+// Equal tests if two maps hold equal entries in the same order.
+func (m *Map) Equal(o interface{}) bool {
+	other, ok := o.(*Map)
+	if !ok {
+		return ok
+	}
+	return m.Length() == other.Length() &&
+		equalOrder(m.order.Iterator(), other.order.Iterator())
+}
+
+// equalOrder walks two order maps of the same length together and
+// reports whether they hold equal entries at each position.
+func equalOrder(a, b treemap.Iterator) bool {
+	for a.HasNext() {
+		_, x := a.Next()
+		_, y := b.Next()
+		e1, e2 := x.(Entry), y.(Entry)
+		if !dyn.Equal(e1.Key(), e2.Key()) ||
+			!dyn.Equal(e1.Value(), e2.Value()) {
+			return false
+		}
+	}
+	return true
+}
+
+// Apply takes an arbitrary number of arguments and returns the
+// value At the first argument.  Apply allows map to be called
+// as a function by the 'dyn' library.
+func (m *Map) Apply(args ...interface{}) interface{} {
+	key := args[0]
+	return m.At(key)
+}
+
+// AsTransient will return a mutable copy on write version of the map.
+func (m *Map) AsTransient() *TMap {
+	return &TMap{
+		index: m.index.AsTransient(),
+		order: m.order.AsTransient(),
+		next:  m.next,
+	}
+}
+
+// MakeTransient is a generic version of AsTransient.
+func (m *Map) MakeTransient() interface{} {
+	return m.AsTransient()
+}
+
+// Transform takes a set of actions and performs them
+// on the persistent map. It does this by making a transient
+// map and calling each action on it, then converting it back
+// to a persistent map.
+// Each action may be a func(*TMap) *TMap, whose result is passed to
+// the following action unless it is nil, or a func(*TMap).
+// Transform will panic if given any other type.
+func (m *Map) Transform(actions ...interface{}) *Map {
+	out := m.AsTransient()
+	for _, action := range actions {
+		switch fn := action.(type) {
+		case func(*TMap) *TMap:
+			if next := fn(out); next != nil {
+				out = next
+			}
+		case func(*TMap):
+			fn(out)
+		default:
+			panic(errTransformSig)
+		}
+	}
+	return out.AsPersistent()
+}
+
+// orderSeq adapts a sequence of the order map's entries to a sequence
+// of the entries they hold.
+type orderSeq struct {
+	entries seq.Sequence
+}
+
+func orderSeqNew(entries seq.Sequence) seq.Sequence {
+	if entries == nil {
+		return nil
+	}
+	return orderSeq{entries: entries}
+}
+
+func (s orderSeq) First() interface{} {
+	return s.entries.First().(treemap.Entry).Value()
+}
+
+func (s orderSeq) Next() seq.Sequence {
+	return orderSeqNew(s.entries.Next())
+}
+
+func (s orderSeq) String() string {
+	return seq.ConvertToString(s)
+}
@@ -0,0 +1,197 @@
+package linkedmap
+
+import (
+	"testing"
+
+	"github.com/leanovate/gopter"
+	"github.com/leanovate/gopter/gen"
+	"github.com/leanovate/gopter/prop"
+	"jsouthworth.net/go/immutable/vector"
+)
+
+// op is an Assoc when del is false and a Delete otherwise.
+type op struct {
+	key, value int
+	del        bool
+}
+
+var genOps = gen.SliceOf(gopter.CombineGens(
+	gen.IntRange(0, 30),
+	gen.Int(),
+	gen.Bool(),
+).Map(func(vs []interface{}) op {
+	return op{vs[0].(int), vs[1].(int), vs[2].(bool)}
+}))
+
+// model applies ops to a slice of entries kept in insertion order.
+func model(ops []op) []Entry {
+	var out []Entry
+	for _, o := range ops {
+		idx := -1
+		for i, e := range out {
+			if e.Key() == o.key {
+				idx = i
+			}
+		}
+		switch {
+		case o.del && idx >= 0:
+			out = append(out[:idx], out[idx+1:]...)
+		case !o.del && idx >= 0:
+			out[idx] = EntryNew(o.key, o.value)
+		case !o.del:
+			out = append(out, EntryNew(o.key, o.value))
+		}
+	}
+	return out
+}
+
+func matchesModel(m *Map, want []Entry) bool {
+	var got []Entry
+	m.Range(func(e Entry) { got = append(got, e) })
+	if len(got) != len(want) || m.Length() != len(want) {
+		return false
+	}
+	for i := range want {
+		if got[i].Key() != want[i].Key() ||
+			got[i].Value() != want[i].Value() ||
+			m.At(want[i].Key()) != want[i].Value() {
+			return false
+		}
+	}
+	if len(want) == 0 {
+		return m.First() == nil && m.Last() == nil
+	}
+	return m.First().Key() == want[0].Key() &&
+		m.Last().Key() == want[len(want)-1].Key()
+}
+
+func TestMap(t *testing.T) {
+	parameters := gopter.DefaultTestParameters()
+	properties := gopter.NewProperties(parameters)
+	properties.Property("persistent operations keep insertion order", prop.ForAll(
+		func(ops []op) bool {
+			m := Empty()
+			for _, o := range ops {
+				if o.del {
+					m = m.Delete(o.key)
+				} else {
+					m = m.Assoc(o.key, o.value)
+				}
+			}
+			return matchesModel(m, model(ops))
+		},
+		genOps,
+	))
+	properties.Property("transient operations match persistent ones", prop.ForAll(
+		func(ops []op) bool {
+			m := Empty()
+			t := m.AsTransient()
+			for _, o := range ops {
+				if o.del {
+					m = m.Delete(o.key)
+					t.Delete(o.key)
+				} else {
+					m = m.Assoc(o.key, o.value)
+					t.Assoc(o.key, o.value)
+				}
+			}
+			if !t.Equal(m.AsTransient()) || t.Length() != m.Length() {
+				return false
+			}
+			return t.AsPersistent().Equal(m)
+		},
+		genOps,
+	))
+	properties.Property("deleting leaves no tombstones", prop.ForAll(
+		func(ops []op) bool {
+			m := Empty()
+			for _, o := range ops {
+				m = m.Assoc(o.key, o.value).Delete(o.key)
+			}
+			return m.order.Length() == 0 && m.index.Length() == 0
+		},
+		genOps,
+	))
+	properties.TestingRun(t)
+}
+
+func TestAssocKeepsPosition(t *testing.T) {
+	m := New("a", 1, "b", 2, "c", 3).Assoc("a", 10)
+	if got := m.String(); got != "{ [a 10] [b 2] [c 3] }" {
+		t.Fatal("unexpected order", got)
+	}
+	if m.Assoc("b", 2) != m {
+		t.Fatal("expected an unchanged map")
+	}
+	m = m.Delete("a").Assoc("a", 1)
+	if m.Last().Key() != "a" || m.First().Key() != "b" {
+		t.Fatal("expected a re-added key to move to the end", m)
+	}
+}
+
+func TestEqual(t *testing.T) {
+	if !New(1, 1, 2, 2).Equal(New(1, 1, 2, 2)) {
+		t.Fatal("expected equal maps")
+	}
+	if New(1, 1, 2, 2).Equal(New(2, 2, 1, 1)) {
+		t.Fatal("expected maps in a different order to differ")
+	}
+	if New(1, 1).Equal(1) {
+		t.Fatal("expected a map not to equal an int")
+	}
+}
+
+func TestFrom(t *testing.T) {
+	want := New(1, "a", 2, "b")
+	if !From([]Entry{EntryNew(1, "a"), EntryNew(2, "b")}).Equal(want) {
+		t.Fatal("unexpected map from entries")
+	}
+	if !From(want.Seq()).Equal(want) {
+		t.Fatal("unexpected map from a sequence")
+	}
+	if !From(vector.New(EntryNew(1, "a"), EntryNew(2, "b"))).Equal(want) {
+		t.Fatal("unexpected map from a seqable")
+	}
+	if !From([]int{1, 2}).Equal(New(1, 2)) {
+		t.Fatal("unexpected map from a slice")
+	}
+	if From(1).Length() != 0 {
+		t.Fatal("expected an empty map")
+	}
+}
+
+func TestReduce(t *testing.T) {
+	m := New(1, 2, 3, 4)
+	if got := m.Reduce(func(res, k, v int) int { return res + k*v }, 0); got != 14 {
+		t.Fatal("unexpected reflective Reduce", got)
+	}
+	got := m.Reduce(func(res interface{}, e Entry) interface{} {
+		return append(res.([]interface{}), e.Key())
+	}, []interface{}{})
+	if keys := got.([]interface{}); len(keys) != 2 || keys[0] != 1 || keys[1] != 3 {
+		t.Fatal("unexpected Reduce order", keys)
+	}
+}
+
+func TestPanics(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		do   func()
+	}{
+		{"New", errOddElements, func() { New(1) }},
+		{"Range", errRangeSig, func() { New(1, 1).Range(1) }},
+		{"Reduce", errReduceSig, func() { New(1, 1).Reduce(1, 0) }},
+		{"Transform", errTransformSig, func() { Empty().Transform(1) }},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			defer func() {
+				if r := recover(); r != test.err {
+					t.Fatal("expected", test.err, "got", r)
+				}
+			}()
+			test.do()
+		})
+	}
+}
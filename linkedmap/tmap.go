@@ -0,0 +1,153 @@
+package linkedmap
+
+import (
+	"fmt"
+	"strings"
+
+	"jsouthworth.net/go/dyn"
+	"jsouthworth.net/go/immutable/hashmap"
+	"jsouthworth.net/go/immutable/treemap"
+)
+
+// TMap is a transient version of a map. Changes made to a transient
+// map will not effect the original persistent structure. Changes to a
+// transient map occur as mutations. These mutations are then made
+// persistent when the transient is transformed into a persistent
+// structure. These are useful when appling multiple transforms to a
+// persistent map where the intermediate results will not be seen or
+// stored anywhere.
+type TMap struct {
+	index *hashmap.TMap
+	order *treemap.TMap
+	next  int
+}
+
+// At returns the value associated with the key.
+// If one is not found, nil is returned.
+func (m *TMap) At(key interface{}) interface{} {
+	v, _ := m.Find(key)
+	return v
+}
+
+// EntryAt returns the entry (key, value pair) of the key.
+// If one is not found, nil is returned.
+func (m *TMap) EntryAt(key interface{}) Entry {
+	n, ok := m.index.Find(key)
+	if !ok {
+		return nil
+	}
+	return m.order.At(n).(Entry)
+}
+
+// Find will return the value for a key if it exists in the map and
+// whether the key exists in the map. For non-nil values, exists will
+// always be true.
+func (m *TMap) Find(key interface{}) (value interface{}, exists bool) {
+	e := m.EntryAt(key)
+	if e == nil {
+		return nil, false
+	}
+	return e.Value(), true
+}
+
+// Contains will test if the key exists in the map.
+func (m *TMap) Contains(key interface{}) bool {
+	return m.index.Contains(key)
+}
+
+// Assoc associates a value with a key in the map. A key that is
+// already in the map keeps its position. A new key is placed after
+// every other key. The transient map is modified and then returned.
+func (m *TMap) Assoc(key, value interface{}) *TMap {
+	n, ok := m.index.Find(key)
+	if ok {
+		if !dyn.Equal(m.order.At(n).(Entry).Value(), value) {
+			m.order = m.order.Assoc(n, entry{key, value})
+		}
+		return m
+	}
+	m.index = m.index.Assoc(key, m.next)
+	m.order = m.order.Assoc(m.next, entry{key, value})
+	m.next++
+	return m
+}
+
+// Conj takes a value that must be an Entry. Conj implements
+// a generic mechanism for building collections.
+func (m *TMap) Conj(value interface{}) interface{} {
+	entry := value.(Entry)
+	return m.Assoc(entry.Key(), entry.Value())
+}
+
+// Delete removes a key and associated value from the map. The
+// transient map is modified and then returned.
+func (m *TMap) Delete(key interface{}) *TMap {
+	n, ok := m.index.Find(key)
+	if !ok {
+		return m
+	}
+	m.index = m.index.Delete(key)
+	m.order = m.order.Delete(n)
+	return m
+}
+
+// Length returns the number of entries in the map.
+func (m *TMap) Length() int {
+	return m.index.Length()
+}
+
+// First returns the entry whose key was added to the map earliest.
+// If the map is empty, nil is returned.
+func (m *TMap) First() Entry {
+	return nextEntry(m.order.Iterator())
+}
+
+// Last returns the entry whose key was added to the map most
+// recently. If the map is empty, nil is returned.
+func (m *TMap) Last() Entry {
+	return nextEntry(m.order.ReverseIterator())
+}
+
+// Range will loop over the entries in the map in insertion order and
+// call 'do' on each entry. The 'do' function may be of the same types
+// accepted by Map.Range.
+func (m *TMap) Range(do interface{}) {
+	rangeOrder(m.order, do)
+}
+
+// Equal tests if two maps hold equal entries in the same order.
+func (m *TMap) Equal(o interface{}) bool {
+	other, ok := o.(*TMap)
+	if !ok {
+		return ok
+	}
+	return m.Length() == other.Length() &&
+		equalOrder(m.order.Iterator(), other.order.Iterator())
+}
+
+// String returns a string representation of the map.
+func (m *TMap) String() string {
+	var b strings.Builder
+	fmt.Fprint(&b, "{ ")
+	m.Range(func(entry Entry) {
+		fmt.Fprintf(&b, "%s ", entry)
+	})
+	fmt.Fprint(&b, "}")
+	return b.String()
+}
+
+// AsPersistent will transform this transient map into a persistent
+// map. Once this occurs any additional actions on the transient map
+// will fail.
+func (m *TMap) AsPersistent() *Map {
+	return &Map{
+		index: m.index.AsPersistent(),
+		order: m.order.AsPersistent(),
+		next:  m.next,
+	}
+}
+
+// MakePersistent is a generic version of AsPersistent.
+func (m *TMap) MakePersistent() interface{} {
+	return m.AsPersistent()
+}
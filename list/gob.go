@@ -0,0 +1,54 @@
+package list
+
+import (
+	"encoding/gob"
+
+	"jsouthworth.net/go/immutable/internal/gobenc"
+)
+
+func init() {
+	gob.Register(&List{})
+}
+
+// GobEncode encodes the list for encoding/gob as its length followed
+// by its elements. Elements held in interfaces must be of types
+// registered with gob.Register. The empty list is nil, and gob does
+// not encode nil pointers, so it can only be encoded inside another
+// value where it is omitted.
+func (l *List) GobEncode() ([]byte, error) {
+	return gobenc.EncodeElems(func(yield func(interface{}) bool) {
+		l.Range(func(elem interface{}) bool {
+			return yield(elem)
+		})
+	})
+}
+
+// GobDecode replaces the contents of the list with data produced by
+// GobEncode. As with UnmarshalJSON the list is overwritten in place
+// and decoding an empty list is an error as the empty list is nil.
+func (l *List) GobDecode(data []byte) error {
+	var elems []interface{}
+	err := gobenc.DecodeElems(data, func(elem interface{}) {
+		elems = append(elems, elem)
+	})
+	if err != nil {
+		return err
+	}
+	if len(elems) == 0 {
+		return errDecodeEmptyList
+	}
+	*l = *consAll(elems, nil)
+	return nil
+}
+
+// MarshalBinary implements encoding.BinaryMarshaler with the
+// encoding used by GobEncode.
+func (l *List) MarshalBinary() ([]byte, error) {
+	return l.GobEncode()
+}
+
+// UnmarshalBinary implements encoding.BinaryUnmarshaler. It is the
+// same as GobDecode.
+func (l *List) UnmarshalBinary(data []byte) error {
+	return l.GobDecode(data)
+}
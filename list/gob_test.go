@@ -0,0 +1,22 @@
+package list
+
+import (
+	"bytes"
+	"encoding/gob"
+	"testing"
+)
+
+func TestGobEmptyList(t *testing.T) {
+	var buf bytes.Buffer
+	err := gob.NewEncoder(&buf).Encode(struct{ L *List }{New(1, "a")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var out struct{ L *List }
+	if err := gob.NewDecoder(&buf).Decode(&out); err != nil || !out.L.Equal(New(1, "a")) {
+		t.Fatal("unexpected decoding", out.L, err)
+	}
+	if err := new(List).UnmarshalBinary([]byte{0}); err != errDecodeEmptyList {
+		t.Fatal("expected decoding an empty list to fail", err)
+	}
+}
@@ -0,0 +1,56 @@
+package list
+
+import (
+	"errors"
+
+	"jsouthworth.net/go/immutable/internal/jsonenc"
+)
+
+var errDecodeEmptyList = errors.New("can not decode an empty array into a list, the empty list is nil")
+
+// MarshalJSON encodes the list as a JSON array of its elements. The
+// empty list is nil and so is encoded as null by encoding/json.
+func (l *List) MarshalJSON() ([]byte, error) {
+	if l == nil {
+		return []byte("null"), nil
+	}
+	return jsonenc.EncodeArray(func(yield func(interface{}) bool) {
+		l.Range(func(elem interface{}) bool {
+			return yield(elem)
+		})
+	}, false)
+}
+
+// UnmarshalJSON replaces the contents of the list with the elements
+// of a JSON array. Elements are decoded as encoding/json decodes into
+// an empty interface. Decoding null leaves the list unchanged, which
+// is how encoding/json decodes an empty list into a nil *List. As the
+// empty list is nil an empty array can not be decoded into a list
+// and UnmarshalJSON returns an error.
+//
+// UnmarshalJSON overwrites the list in place, so it must only be
+// used on a list that is not shared, such as the one encoding/json
+// allocates when decoding into a nil *List.
+func (l *List) UnmarshalJSON(data []byte) error {
+	return l.DecodeJSON(data, nil)
+}
+
+// DecodeJSON is UnmarshalJSON with each element decoded by decode
+// instead. A nil decode behaves like UnmarshalJSON.
+func (l *List) DecodeJSON(data []byte, decode func(data []byte) (interface{}, error)) error {
+	if jsonenc.IsNull(data) {
+		return nil
+	}
+	var elems []interface{}
+	err := jsonenc.DecodeArray(data, decode, func(elem interface{}) {
+		elems = append(elems, elem)
+	})
+	if err != nil {
+		return err
+	}
+	if len(elems) == 0 {
+		return errDecodeEmptyList
+	}
+	*l = *consAll(elems, nil)
+	return nil
+}
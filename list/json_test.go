@@ -0,0 +1,32 @@
+package list
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestJSON(t *testing.T) {
+	l := New("a", "b", "c")
+	b, err := json.Marshal(l)
+	if err != nil || string(b) != `["a","b","c"]` {
+		t.Fatal("unexpected encoding", string(b), err)
+	}
+	var out *List
+	if err := json.Unmarshal(b, &out); err != nil || !out.Equal(l) {
+		t.Fatal("unexpected decoding", out, err)
+	}
+}
+
+func TestJSONEmpty(t *testing.T) {
+	b, err := json.Marshal(Empty())
+	if err != nil || string(b) != `null` {
+		t.Fatal("expected the empty list to encode as null", string(b), err)
+	}
+	out := New(1)
+	if err := json.Unmarshal(b, &out); err != nil || out != nil {
+		t.Fatal("expected null to decode to the empty list", out, err)
+	}
+	if err := json.Unmarshal([]byte(`[]`), &out); err != errDecodeEmptyList {
+		t.Fatal("expected an error decoding an empty array", err)
+	}
+}
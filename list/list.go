@@ -10,6 +10,10 @@ import (
 )
 
 var errRangeSig = errors.New("Range requires a function: func(v vT) bool or func(v vT)")
+var errReduceSig = errors.New("Reduce requires a function: func(init iT, v vT) oT")
+var errMapSig = errors.New("Map requires a function: func(v vT) oT")
+var errFilterSig = errors.New("Filter requires a function: func(v vT) bool")
+var errOutOfBounds = errors.New("out of bounds")
 
 // List is a persistent linked list.
 type List struct {
@@ -230,6 +234,288 @@ func (l *List) elementsAreEqual(ol *List) bool {
 	return allEqual
 }
 
+// consAll returns the list of elems followed by tail.
+func consAll(elems []interface{}, tail *List) *List {
+	out := tail
+	for i := len(elems) - 1; i >= 0; i-- {
+		out = out.Cons(elems[i])
+	}
+	return out
+}
+
+// Reverse returns a list of the elements of l in reverse order.
+func (l *List) Reverse() *List {
+	out := Empty()
+	for list := l; list != nil; list = list.Next() {
+		out = out.Cons(list.First())
+	}
+	return out
+}
+
+// Concat returns a list of the elements of l followed by those of
+// other. The elements of l are copied and other is shared as the
+// tail of the new list.
+func (l *List) Concat(other *List) *List {
+	switch {
+	case l == nil:
+		return other
+	case other == nil:
+		return l
+	}
+	elems := make([]interface{}, 0, l.Length())
+	for list := l; list != nil; list = list.Next() {
+		elems = append(elems, list.First())
+	}
+	return consAll(elems, other)
+}
+
+// Take returns a list of the first n elements of l. If n is at least
+// the length of the list, l is returned.
+func (l *List) Take(n int) *List {
+	switch {
+	case n <= 0:
+		return Empty()
+	case n >= l.Length():
+		return l
+	}
+	elems := make([]interface{}, 0, n)
+	for list := l; len(elems) < n; list = list.Next() {
+		elems = append(elems, list.First())
+	}
+	return consAll(elems, Empty())
+}
+
+// Drop returns the list without its first n elements. The result
+// shares all of its structure with l.
+func (l *List) Drop(n int) *List {
+	list := l
+	for ; n > 0 && list != nil; n-- {
+		list = list.Next()
+	}
+	return list
+}
+
+// Nth returns the n-th element of the list counting from zero.
+// Nth will panic if n is out of bounds.
+func (l *List) Nth(n int) interface{} {
+	if n < 0 || n >= l.Length() {
+		panic(errOutOfBounds)
+	}
+	return l.Drop(n).First()
+}
+
+// Last returns the last element of the list, or nil if the list is
+// empty.
+func (l *List) Last() interface{} {
+	if l == nil {
+		return nil
+	}
+	list := l
+	for list.Next() != nil {
+		list = list.Next()
+	}
+	return list.First()
+}
+
+// Map returns a list of the results of calling fn on each element of
+// l. Map can take the following types as the fn:
+//
+// func(value interface{}) interface{}
+// func(v vT) oT
+//
+// Map will panic if given any other function type.
+func (l *List) Map(fn interface{}) *List {
+	// NOTE: Update other functions using the same pattern
+	//       when modifying the below.
+	//       This code is inlined to avoid heap allocation of
+	//       the closure.
+	var mFn func(v interface{}) interface{}
+	switch f := fn.(type) {
+	case func(value interface{}) interface{}:
+		mFn = f
+	default:
+		mFn = genMapFunc(fn)
+	}
+	elems := make([]interface{}, 0, l.Length())
+	for list := l; list != nil; list = list.Next() {
+		elems = append(elems, mFn(list.First()))
+	}
+	return consAll(elems, Empty())
+}
+
+func genMapFunc(fn interface{}) func(v interface{}) interface{} {
+	rv := reflect.ValueOf(fn)
+	if rv.Kind() != reflect.Func {
+		panic(errMapSig)
+	}
+	rt := rv.Type()
+	if rt.NumIn() != 1 || rt.NumOut() != 1 {
+		panic(errMapSig)
+	}
+	return func(v interface{}) interface{} {
+		return dyn.Apply(fn, v)
+	}
+}
+
+// Filter returns a list of the elements of l for which fn returns
+// true. The elements after the last one removed are shared with l.
+// Filter can take the following types as the fn:
+//
+// func(value interface{}) bool
+// func(v vT) bool
+//
+// Filter will panic if given any other function type.
+func (l *List) Filter(fn interface{}) *List {
+	// NOTE: Update other functions using the same pattern
+	//       when modifying the below.
+	//       This code is inlined to avoid heap allocation of
+	//       the closure.
+	var pred func(v interface{}) bool
+	switch f := fn.(type) {
+	case func(value interface{}) bool:
+		pred = f
+	default:
+		pred = genFilterFunc(fn)
+	}
+	var kept []interface{}
+	copied, tail := 0, l
+	for list := l; list != nil; list = list.Next() {
+		if pred(list.First()) {
+			kept = append(kept, list.First())
+			continue
+		}
+		copied, tail = len(kept), list.Next()
+	}
+	if tail == l {
+		return l
+	}
+	return consAll(kept[:copied], tail)
+}
+
+func genFilterFunc(fn interface{}) func(v interface{}) bool {
+	rv := reflect.ValueOf(fn)
+	if rv.Kind() != reflect.Func {
+		panic(errFilterSig)
+	}
+	rt := rv.Type()
+	if rt.NumIn() != 1 || rt.NumOut() != 1 ||
+		rt.Out(0).Kind() != reflect.Bool {
+		panic(errFilterSig)
+	}
+	return func(v interface{}) bool {
+		return dyn.Apply(fn, v).(bool)
+	}
+}
+
+// Reduce is a fast mechanism for reducing a List. Reduce can take
+// the following types as the fn:
+//
+// func(init interface{}, value interface{}) interface{}
+// func(init iT, v vT) oT
+//
+// Reduce will panic if given any other function type.
+func (l *List) Reduce(fn interface{}, init interface{}) interface{} {
+	// NOTE: Update other functions using the same pattern
+	//       when modifying the below.
+	//       This code is inlined to avoid heap allocation of
+	//       the closure.
+	var rFn func(r, v interface{}) interface{}
+	switch f := fn.(type) {
+	case func(res, val interface{}) interface{}:
+		rFn = f
+	default:
+		rFn = genReduceFunc(fn)
+	}
+	res := init
+	for list := l; list != nil; list = list.Next() {
+		res = rFn(res, list.First())
+	}
+	return res
+}
+
+func genReduceFunc(fn interface{}) func(r, v interface{}) interface{} {
+	rv := reflect.ValueOf(fn)
+	if rv.Kind() != reflect.Func {
+		panic(errReduceSig)
+	}
+	rt := rv.Type()
+	if rt.NumIn() != 2 {
+		panic(errReduceSig)
+	}
+	if rt.NumOut() != 1 {
+		panic(errReduceSig)
+	}
+	return func(r, v interface{}) interface{} {
+		return dyn.Apply(fn, r, v)
+	}
+}
+
+// Zip returns a list of pairs of the corresponding elements of l and
+// other. Each pair is a two element list. The result is as long as
+// the shorter of the two lists.
+func (l *List) Zip(other *List) *List {
+	n := l.Length()
+	if other.Length() < n {
+		n = other.Length()
+	}
+	pairs := make([]interface{}, 0, n)
+	for a, b := l, other; a != nil && b != nil; a, b = a.Next(), b.Next() {
+		pairs = append(pairs, New(a.First(), b.First()))
+	}
+	return consAll(pairs, Empty())
+}
+
+// Sort returns a list of the elements of l in ascending order as
+// determined by cmp. If cmp is nil dyn.Compare is used. The sort is a
+// merge sort and so is stable; equal elements keep their order.
+func (l *List) Sort(cmp func(a, b interface{}) int) *List {
+	if l.Length() < 2 {
+		return l
+	}
+	if cmp == nil {
+		cmp = dyn.Compare
+	}
+	elems := make([]interface{}, 0, l.Length())
+	for list := l; list != nil; list = list.Next() {
+		elems = append(elems, list.First())
+	}
+	return consAll(mergeSort(elems, cmp), Empty())
+}
+
+// mergeSort sorts elems bottom up, merging runs of doubling width
+// between elems and a buffer of the same size. The returned slice is
+// whichever of the two holds the final merge.
+func mergeSort(elems []interface{}, cmp func(a, b interface{}) int) []interface{} {
+	src, dst := elems, make([]interface{}, len(elems))
+	for width := 1; width < len(src); width *= 2 {
+		for lo := 0; lo < len(src); lo += 2 * width {
+			mid, hi := lo+width, lo+2*width
+			if mid > len(src) {
+				mid = len(src)
+			}
+			if hi > len(src) {
+				hi = len(src)
+			}
+			merge(dst[lo:hi], src[lo:mid], src[mid:hi], cmp)
+		}
+		src, dst = dst, src
+	}
+	return src
+}
+
+func merge(dst, a, b []interface{}, cmp func(a, b interface{}) int) {
+	i, j := 0, 0
+	for k := range dst {
+		if j == len(b) || (i < len(a) && cmp(a[i], b[j]) <= 0) {
+			dst[k] = a[i]
+			i++
+		} else {
+			dst[k] = b[j]
+			j++
+		}
+	}
+}
+
 type listSequence struct {
 	l *List
 }
@@ -3,6 +3,7 @@ package list
 import (
 	"fmt"
 	"reflect"
+	"sort"
 	"testing"
 
 	"github.com/leanovate/gopter"
@@ -333,3 +334,152 @@ func ExampleSeqString() {
 	fmt.Println(New(1, 2, 3, 4, 5, 6).Seq())
 	// Output: (1 2 3 4 5 6)
 }
+
+func intList(xs []int) *List {
+	out := Empty()
+	for i := len(xs) - 1; i >= 0; i-- {
+		out = out.Cons(xs[i])
+	}
+	return out
+}
+
+func TestListToolkit(t *testing.T) {
+	parameters := gopter.DefaultTestParameters()
+	properties := gopter.NewProperties(parameters)
+	ints := gen.SliceOf(gen.IntRange(-20, 20))
+	properties.Property("Reverse twice is the identity", prop.ForAll(
+		func(xs []int) bool {
+			l := intList(xs)
+			r := l.Reverse()
+			return r.Length() == len(xs) && r.Reverse().Equal(l) &&
+				(len(xs) == 0 || r.First() == xs[len(xs)-1])
+		},
+		ints,
+	))
+	properties.Property("Concat shares the second list", prop.ForAll(
+		func(xs, ys []int) bool {
+			a, b := intList(xs), intList(ys)
+			c := a.Concat(b)
+			return c.Equal(intList(append(append([]int{}, xs...), ys...))) &&
+				c.Drop(len(xs)) == b
+		},
+		ints, ints,
+	))
+	properties.Property("Take and Drop split the list", prop.ForAll(
+		func(xs []int, n int) bool {
+			l := intList(xs)
+			k := n
+			if k > len(xs) {
+				k = len(xs)
+			}
+			return l.Take(n).Equal(intList(xs[:k])) &&
+				l.Drop(n).Equal(intList(xs[k:])) &&
+				l.Take(n).Concat(l.Drop(n)).Equal(l)
+		},
+		ints, gen.IntRange(0, 30),
+	))
+	properties.Property("Nth and Last index the list", prop.ForAll(
+		func(xs []int) bool {
+			l := intList(xs)
+			for i, x := range xs {
+				if l.Nth(i) != x {
+					return false
+				}
+			}
+			return len(xs) == 0 && l.Last() == nil ||
+				len(xs) > 0 && l.Last() == xs[len(xs)-1]
+		},
+		ints,
+	))
+	properties.Property("Map, Filter and Reduce match a slice", prop.ForAll(
+		func(xs []int) bool {
+			l := intList(xs)
+			var doubled, evens []int
+			sum := 0
+			for _, x := range xs {
+				doubled = append(doubled, 2*x)
+				if x%2 == 0 {
+					evens = append(evens, x)
+				}
+				sum += x
+			}
+			return l.Map(func(x int) int { return 2 * x }).Equal(intList(doubled)) &&
+				l.Filter(func(x int) bool { return x%2 == 0 }).Equal(intList(evens)) &&
+				l.Reduce(func(r, x int) int { return r + x }, 0) == sum
+		},
+		ints,
+	))
+	properties.Property("Sort is a stable merge sort", prop.ForAll(
+		func(xs []int) bool {
+			type pair struct{ key, idx int }
+			l := Empty()
+			want := make([]pair, len(xs))
+			for i := len(xs) - 1; i >= 0; i-- {
+				l = l.Cons(pair{xs[i] / 4, i})
+				want[i] = pair{xs[i] / 4, i}
+			}
+			sort.SliceStable(want, func(i, j int) bool {
+				return want[i].key < want[j].key
+			})
+			got := l.Sort(func(a, b interface{}) int {
+				return a.(pair).key - b.(pair).key
+			})
+			for _, p := range want {
+				if got.First() != p {
+					return false
+				}
+				got = got.Next()
+			}
+			return got == nil
+		},
+		ints,
+	))
+	properties.TestingRun(t)
+}
+
+func TestListFilterSharesTail(t *testing.T) {
+	l := New(1, 2, 3, 4, 5)
+	f := l.Filter(func(v interface{}) bool { return v != 2 })
+	if !f.Equal(New(1, 3, 4, 5)) || f.Next() != l.Drop(2) {
+		t.Fatal("expected the tail after the removed element to be shared", f)
+	}
+	if l.Filter(func(v interface{}) bool { return true }) != l {
+		t.Fatal("expected Filter keeping everything to return the list")
+	}
+}
+
+func TestListZip(t *testing.T) {
+	z := New(1, 2, 3).Zip(New("a", "b"))
+	if !z.Equal(New(New(1, "a"), New(2, "b"))) {
+		t.Fatal("unexpected zip", z)
+	}
+}
+
+func TestListSortDefault(t *testing.T) {
+	if s := New(3, 1, 2).Sort(nil); !s.Equal(New(1, 2, 3)) {
+		t.Fatal("unexpected sort", s)
+	}
+}
+
+func TestListSignatures(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		do   func()
+	}{
+		{"Nth", errOutOfBounds, func() { New(1).Nth(1) }},
+		{"Map", errMapSig, func() { New(1).Map(func() {}) }},
+		{"Filter", errFilterSig, func() { New(1).Filter(func(int) int { return 0 }) }},
+		{"Reduce", errReduceSig, func() { New(1).Reduce(1, 0) }},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			defer func() {
+				if r := recover(); r != test.err {
+					t.Fatal("expected", test.err, "got", r)
+				}
+			}()
+			test.do()
+		})
+	}
+}
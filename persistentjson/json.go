@@ -0,0 +1,67 @@
+// Package persistentjson decodes JSON into persistent collections.
+//
+// The collections of this module implement json.Marshaler and
+// json.Unmarshaler. By default the elements they decode follow
+// encoding/json, so nested arrays and objects become []interface{}
+// and map[string]interface{}. The functions here decode nested arrays
+// to *vector.Vector and nested objects to *hashmap.Map instead, so a
+// document is persistent all the way down.
+package persistentjson // import "jsouthworth.net/go/immutable/persistentjson"
+
+import (
+	"bytes"
+	"encoding/json"
+
+	"jsouthworth.net/go/immutable/hashmap"
+	"jsouthworth.net/go/immutable/vector"
+)
+
+// Decoder is implemented by collections that can decode their
+// elements with a supplied function. All of the collections in this
+// module implement it.
+type Decoder interface {
+	DecodeJSON(data []byte, decode func(data []byte) (interface{}, error)) error
+}
+
+// Decode decodes a JSON value. Arrays are decoded to *vector.Vector
+// and objects to *hashmap.Map with string keys, recursively. Other
+// values are decoded as encoding/json decodes into an empty
+// interface.
+func Decode(data []byte) (interface{}, error) {
+	trimmed := bytes.TrimSpace(data)
+	if len(trimmed) == 0 {
+		var out interface{}
+		return out, json.Unmarshal(data, &out)
+	}
+	switch trimmed[0] {
+	case '[':
+		out := new(vector.Vector)
+		return out, out.DecodeJSON(trimmed, Decode)
+	case '{':
+		out := new(hashmap.Map)
+		return out, out.DecodeJSON(trimmed, Decode)
+	default:
+		var out interface{}
+		return out, json.Unmarshal(trimmed, &out)
+	}
+}
+
+// Unmarshal decodes data into v. If v is a *interface{} it is set to
+// the result of Decode. If v is a Decoder, such as any of the
+// collections of this module, its elements are decoded with
+// Decode. Any other v is passed to json.Unmarshal.
+func Unmarshal(data []byte, v interface{}) error {
+	switch t := v.(type) {
+	case *interface{}:
+		out, err := Decode(data)
+		if err != nil {
+			return err
+		}
+		*t = out
+		return nil
+	case Decoder:
+		return t.DecodeJSON(data, Decode)
+	default:
+		return json.Unmarshal(data, v)
+	}
+}
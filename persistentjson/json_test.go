@@ -0,0 +1,52 @@
+package persistentjson
+
+import (
+	"testing"
+
+	"jsouthworth.net/go/immutable/hashmap"
+	"jsouthworth.net/go/immutable/treemap"
+	"jsouthworth.net/go/immutable/vector"
+)
+
+func TestDecode(t *testing.T) {
+	var out interface{}
+	err := Unmarshal([]byte(`{"a":[1,{"b":null}],"c":"d"}`), &out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m, ok := out.(*hashmap.Map)
+	if !ok || m.At("c") != "d" {
+		t.Fatal("expected a persistent map", out)
+	}
+	v, ok := m.At("a").(*vector.Vector)
+	if !ok || v.At(0) != 1.0 {
+		t.Fatal("expected a persistent vector", m.At("a"))
+	}
+	if inner, ok := v.At(1).(*hashmap.Map); !ok || !inner.Contains("b") {
+		t.Fatal("expected a nested persistent map", v.At(1))
+	}
+}
+
+func TestUnmarshalCollection(t *testing.T) {
+	tm := new(treemap.Map)
+	if err := Unmarshal([]byte(`{"b":[1],"a":{}}`), tm); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := tm.At("b").(*vector.Vector); !ok {
+		t.Fatal("expected a persistent vector", tm)
+	}
+	if _, ok := tm.At("a").(*hashmap.Map); !ok {
+		t.Fatal("expected a persistent map", tm)
+	}
+	var n int
+	if err := Unmarshal([]byte(`3`), &n); err != nil || n != 3 {
+		t.Fatal("expected other values to use encoding/json", n, err)
+	}
+	if err := Unmarshal([]byte(`[1`), &struct{}{}); err == nil {
+		t.Fatal("expected a syntax error")
+	}
+	var bad interface{}
+	if err := Unmarshal([]byte(`[1`), &bad); err == nil {
+		t.Fatal("expected a syntax error")
+	}
+}
@@ -0,0 +1,64 @@
+package pqueue
+
+import (
+	"encoding/gob"
+	"errors"
+
+	"jsouthworth.net/go/immutable/internal/gobenc"
+)
+
+var errDecodeEmpty = errors.New("can not decode into the shared empty queue")
+
+func init() {
+	gob.Register(&PQueue{})
+}
+
+// GobEncode encodes the queue for encoding/gob as its length
+// followed by the value and priority of each element in the order
+// they would be popped. Values and priorities held in interfaces must
+// be of types registered with gob.Register. The options of the queue
+// are not encoded.
+func (q *PQueue) GobEncode() ([]byte, error) {
+	return gobenc.EncodePairs(func(yield func(value, priority interface{}) bool) {
+		q.Range(func(value, priority interface{}) bool {
+			return yield(value, priority)
+		})
+	})
+}
+
+// GobDecode replaces the contents of the queue with data produced by
+// GobEncode. Elements of equal priority keep their order. GobDecode
+// overwrites the queue in place, so it must only be used on a queue
+// that is not shared, such as the one encoding/gob allocates when
+// decoding into a nil *PQueue. The comparison function of the queue
+// is kept if it was made with one.
+func (q *PQueue) GobDecode(data []byte) error {
+	if q == Empty() {
+		return errDecodeEmpty
+	}
+	out := Empty()
+	if q.order != nil {
+		out = &PQueue{order: q.order}
+	}
+	t := out.AsTransient()
+	err := gobenc.DecodePairs(data, func(value, priority interface{}) {
+		t = t.Insert(value, priority)
+	})
+	if err != nil {
+		return err
+	}
+	*q = *t.AsPersistent()
+	return nil
+}
+
+// MarshalBinary implements encoding.BinaryMarshaler with the
+// encoding used by GobEncode.
+func (q *PQueue) MarshalBinary() ([]byte, error) {
+	return q.GobEncode()
+}
+
+// UnmarshalBinary implements encoding.BinaryUnmarshaler. It is the
+// same as GobDecode.
+func (q *PQueue) UnmarshalBinary(data []byte) error {
+	return q.GobDecode(data)
+}
@@ -0,0 +1,50 @@
+package pqueue
+
+import (
+	"bytes"
+	"encoding/gob"
+	"testing"
+
+	"jsouthworth.net/go/dyn"
+)
+
+func TestGobKeepsTieOrder(t *testing.T) {
+	in := Empty().Insert("a", 2).Insert("b", 1).Insert("c", 1).Insert("d", 1)
+	b, err := in.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	out := new(PQueue)
+	if err := out.UnmarshalBinary(b); err != nil || out.String() != "[ b:1 c:1 d:1 a:2 ]" {
+		t.Fatal("expected elements of equal priority to keep their order", out, err)
+	}
+	if err := Empty().UnmarshalBinary(b); err != errDecodeEmpty {
+		t.Fatal("expected decoding into Empty() to fail", err)
+	}
+}
+
+func TestGobOptionsNotEncoded(t *testing.T) {
+	reverse := func(a, b interface{}) int {
+		return -dyn.Compare(a, b)
+	}
+	in := Empty(Compare(reverse)).Insert("low", 1).Insert("high", 10).Insert("mid", 5)
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(in); err != nil {
+		t.Fatal(err)
+	}
+	var out *PQueue
+	if err := gob.NewDecoder(&buf).Decode(&out); err != nil {
+		t.Fatal(err)
+	}
+	if out.String() != "[ low:1 mid:5 high:10 ]" {
+		t.Fatal("expected a fresh queue to use the default comparison", out)
+	}
+	b, err := in.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	out = Empty(Compare(reverse)).Insert("x", 0)
+	if err := out.UnmarshalBinary(b); err != nil || out.String() != in.String() {
+		t.Fatal("expected the decoded queue to keep its comparison", out, err)
+	}
+}
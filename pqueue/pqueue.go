@@ -0,0 +1,469 @@
+// Package pqueue implements a persistent min-priority queue.
+//
+// The queue is a leftist heap. Every operation copies only the nodes
+// along the right spine of the heaps involved, so Insert, PopMin and
+// Meld take logarithmic time in the worst case, even when old
+// versions of the queue are kept and reused.
+//
+// Priorities are compared with dyn.Compare unless the Compare option
+// is given. Elements with equal priorities that were inserted into
+// the same queue are popped in the order they were inserted.
+package pqueue // import "jsouthworth.net/go/immutable/pqueue"
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"jsouthworth.net/go/dyn"
+	"jsouthworth.net/go/seq"
+)
+
+var errRangeSig = errors.New("Range requires a function: func(v vT, p pT) bool or func(v vT, p pT)")
+var errTransformSig = errors.New("Transform requires functions: func(t *TPQueue) *TPQueue or func(t *TPQueue)")
+var errTafterP = errors.New("transient used after persistent call")
+
+// PQueue is a persistent min-priority queue.
+type PQueue struct {
+	root   *node
+	length int
+	// seq is the sequence number given to the next inserted
+	// element, used to break ties between equal priorities.
+	seq   uint64
+	order *ordering
+}
+
+type cmpFunc func(p1, p2 interface{}) int
+
+// ordering identifies the comparison function of a queue. Comparing
+// the functions themselves is not enough since every closure made by
+// the same function literal shares its code pointer.
+type ordering struct {
+	cmp cmpFunc
+}
+
+var defaultOrdering = &ordering{cmp: dyn.Compare}
+
+type node struct {
+	value    interface{}
+	priority interface{}
+	seq      uint64
+	// rank is the length of the right spine of the node.
+	rank        int
+	left, right *node
+}
+
+func (n *node) getRank() int {
+	if n == nil {
+		return 0
+	}
+	return n.rank
+}
+
+var empty = PQueue{
+	order: defaultOrdering,
+}
+
+type queueOptions struct {
+	order *ordering
+}
+
+// Option is a type that allows changes to pluggable parts of the
+// PQueue implementation.
+type Option func(*queueOptions)
+
+// Compare is an option to the Empty function that will allow
+// one to specify a different comparison operator instead
+// of the default which is from the dyn library. This is used
+// for priorities. Queues made with the same Option value returned by
+// Compare share an ordering which lets Meld merge them in logarithmic
+// time.
+func Compare(cmp func(p1, p2 interface{}) int) Option {
+	order := &ordering{cmp: cmp}
+	return func(o *queueOptions) {
+		o.order = order
+	}
+}
+
+// Empty returns a new empty priority queue, one may supply options
+// for the queue by using one of the option generating functions and
+// providing that to Empty.
+func Empty(options ...Option) *PQueue {
+	if len(options) == 0 {
+		return &empty
+	}
+	opts := queueOptions{
+		order: defaultOrdering,
+	}
+	for _, opt := range options {
+		opt(&opts)
+	}
+	return &PQueue{
+		order: opts.order,
+	}
+}
+
+// less reports whether a should be popped before b.
+func less(cmp cmpFunc, a, b *node) bool {
+	c := cmp(a.priority, b.priority)
+	return c < 0 || (c == 0 && a.seq < b.seq)
+}
+
+// merge combines two heaps by merging their right spines. Only the
+// nodes along the spines are copied.
+func merge(cmp cmpFunc, a, b *node) *node {
+	switch {
+	case a == nil:
+		return b
+	case b == nil:
+		return a
+	case less(cmp, b, a):
+		a, b = b, a
+	}
+	left, right := a.left, merge(cmp, a.right, b)
+	if left.getRank() < right.getRank() {
+		left, right = right, left
+	}
+	return &node{
+		value:    a.value,
+		priority: a.priority,
+		seq:      a.seq,
+		rank:     right.getRank() + 1,
+		left:     left,
+		right:    right,
+	}
+}
+
+// Insert returns a queue with value added at the given priority.
+func (q *PQueue) Insert(value, priority interface{}) *PQueue {
+	n := &node{
+		value:    value,
+		priority: priority,
+		seq:      q.seq,
+		rank:     1,
+	}
+	return &PQueue{
+		root:   merge(q.order.cmp, q.root, n),
+		length: q.length + 1,
+		seq:    q.seq + 1,
+		order:  q.order,
+	}
+}
+
+// PeekMin returns the value with the smallest priority along with
+// its priority. If the queue is empty nil is returned for both.
+func (q *PQueue) PeekMin() (value, priority interface{}) {
+	if q.root == nil {
+		return nil, nil
+	}
+	return q.root.value, q.root.priority
+}
+
+// PopMin returns a queue without the value with the smallest
+// priority. Popping an empty queue returns it unchanged.
+func (q *PQueue) PopMin() *PQueue {
+	if q.root == nil {
+		return q
+	}
+	return &PQueue{
+		root:   merge(q.order.cmp, q.root.left, q.root.right),
+		length: q.length - 1,
+		seq:    q.seq,
+		order:  q.order,
+	}
+}
+
+// Meld returns a queue holding the elements of both q and other. The
+// order in which elements of q and other with equal priorities are
+// popped is unspecified. Queues that share an ordering, see Compare,
+// are melded in logarithmic time. Otherwise the elements of other are
+// inserted into q one at a time and ordered by the comparison
+// function of q.
+func (q *PQueue) Meld(other *PQueue) *PQueue {
+	switch {
+	case q.order != other.order:
+		return q.Transform(func(t *TPQueue) {
+			other.Range(func(value, priority interface{}) {
+				t.Insert(value, priority)
+			})
+		})
+	case other.length == 0:
+		return q
+	case q.length == 0:
+		return other
+	}
+	seq := q.seq
+	if other.seq > seq {
+		seq = other.seq
+	}
+	return &PQueue{
+		root:   merge(q.order.cmp, q.root, other.root),
+		length: q.length + other.length,
+		seq:    seq,
+		order:  q.order,
+	}
+}
+
+// Length returns the number of elements in the queue.
+func (q *PQueue) Length() int {
+	return q.length
+}
+
+// Range calls the passed in function on each element of the queue
+// in priority order. The function passed in may be of many types:
+//
+// func(value, priority interface{}) bool:
+//
+//	Takes empty interfaces and returns if the loop should continue.
+//	Useful to avoid reflection or for hetrogenous queues.
+//
+// func(value, priority interface{}):
+//
+//	Takes empty interfaces.
+//	Useful to avoid reflection or for hetrogenous queues.
+//
+// func(v vT, p pT) bool
+//
+//	Takes a value of value type and a priority of priority type and returns if the loop should contiune.
+//	Is called with reflection and will panic if the vT and pT types are incorrect.
+//
+// func(v vT, p pT)
+//
+//	Takes a value of value type and a priority of priority type.
+//	Is called with reflection and will panic if the vT and pT types are incorrect.
+//
+// Range will panic if passed anything not matching these signatures.
+func (q *PQueue) Range(do interface{}) {
+	// NOTE: Update other functions using the same pattern
+	//       when modifying the below.
+	//       This code is inlined to avoid heap allocation of
+	//       the closure.
+	var f func(value, priority interface{}) bool
+	switch fn := do.(type) {
+	case func(value, priority interface{}) bool:
+		f = fn
+	case func(value, priority interface{}):
+		f = func(value, priority interface{}) bool {
+			fn(value, priority)
+			return true
+		}
+	default:
+		f = genRangeFunc(do)
+	}
+	cont := true
+	for queue := q; queue.root != nil && cont; queue = queue.PopMin() {
+		cont = f(queue.root.value, queue.root.priority)
+	}
+}
+
+func genRangeFunc(do interface{}) func(value, priority interface{}) bool {
+	rv := reflect.ValueOf(do)
+	if rv.Kind() != reflect.Func {
+		panic(errRangeSig)
+	}
+	rt := rv.Type()
+	if rt.NumIn() != 2 || rt.NumOut() > 1 {
+		panic(errRangeSig)
+	}
+	if rt.NumOut() == 1 &&
+		rt.Out(0).Kind() != reflect.Bool {
+		panic(errRangeSig)
+	}
+	return func(value, priority interface{}) bool {
+		out := dyn.Apply(do, value, priority)
+		if out != nil {
+			return out.(bool)
+		}
+		return true
+	}
+}
+
+// Seq returns the values of the queue as a sequence in priority
+// order.
+func (q *PQueue) Seq() seq.Sequence {
+	if q.root == nil {
+		return nil
+	}
+	return &queueSeq{
+		queue: q,
+	}
+}
+
+// String returns a representation of the queue as a string.
+func (q *PQueue) String() string {
+	b := new(strings.Builder)
+	fmt.Fprint(b, "[ ")
+	q.Range(func(value, priority interface{}) {
+		fmt.Fprintf(b, "%v:%v ", value, priority)
+	})
+	fmt.Fprint(b, "]")
+	return b.String()
+}
+
+// AsTransient returns a transient builder for the queue that shares
+// structure with the persistent queue.
+func (q *PQueue) AsTransient() *TPQueue {
+	return &TPQueue{
+		root:   q.root,
+		length: q.length,
+		seq:    q.seq,
+		order:  q.order,
+		orig:   q,
+	}
+}
+
+// MakeTransient is a generic version of AsTransient.
+func (q *PQueue) MakeTransient() interface{} {
+	return q.AsTransient()
+}
+
+// Transform takes a set of actions and performs them
+// on the persistent queue. It does this by making a transient
+// queue and calling each action on it, then converting it back
+// to a persistent queue.
+// Each action may be a func(*TPQueue) *TPQueue, whose result is passed to
+// the following action unless it is nil, or a func(*TPQueue).
+// Transform will panic if given any other type.
+func (q *PQueue) Transform(actions ...interface{}) *PQueue {
+	out := q.AsTransient()
+	for _, action := range actions {
+		switch fn := action.(type) {
+		case func(*TPQueue) *TPQueue:
+			if next := fn(out); next != nil {
+				out = next
+			}
+		case func(*TPQueue):
+			fn(out)
+		default:
+			panic(errTransformSig)
+		}
+	}
+	return out.AsPersistent()
+}
+
+type queueSeq struct {
+	queue *PQueue
+}
+
+func (s *queueSeq) First() interface{} {
+	value, _ := s.queue.PeekMin()
+	return value
+}
+
+func (s *queueSeq) Next() seq.Sequence {
+	return s.queue.PopMin().Seq()
+}
+
+func (s *queueSeq) String() string {
+	return seq.ConvertToString(s)
+}
+
+// TPQueue is a transient builder for a priority queue. Inserted
+// elements are gathered and combined into the heap in linear time
+// the next time the minimum is needed, which makes building a large
+// queue considerably cheaper than inserting into a persistent queue
+// one element at a time.
+type TPQueue struct {
+	root    *node
+	pending []*node
+	length  int
+	seq     uint64
+	order   *ordering
+
+	orig *PQueue
+	done bool
+}
+
+// Insert adds value to the queue at the given priority. t is
+// returned.
+func (t *TPQueue) Insert(value, priority interface{}) *TPQueue {
+	t.ensureEditable()
+	t.pending = append(t.pending, &node{
+		value:    value,
+		priority: priority,
+		seq:      t.seq,
+		rank:     1,
+	})
+	t.length++
+	t.seq++
+	return t
+}
+
+// PeekMin returns the value with the smallest priority along with
+// its priority. If the queue is empty nil is returned for both.
+func (t *TPQueue) PeekMin() (value, priority interface{}) {
+	t.ensureEditable()
+	t.flush()
+	if t.root == nil {
+		return nil, nil
+	}
+	return t.root.value, t.root.priority
+}
+
+// PopMin removes the value with the smallest priority. t is returned.
+func (t *TPQueue) PopMin() *TPQueue {
+	t.ensureEditable()
+	t.flush()
+	if t.root == nil {
+		return t
+	}
+	t.root = merge(t.order.cmp, t.root.left, t.root.right)
+	t.length--
+	return t
+}
+
+// Length returns the number of elements in the queue.
+func (t *TPQueue) Length() int {
+	t.ensureEditable()
+	return t.length
+}
+
+// AsPersistent returns the built queue. Any transient operations
+// performed after this will cause a panic.
+func (t *TPQueue) AsPersistent() *PQueue {
+	t.ensureEditable()
+	t.flush()
+	t.done = true
+	if t.root == t.orig.root {
+		return t.orig
+	}
+	return &PQueue{
+		root:   t.root,
+		length: t.length,
+		seq:    t.seq,
+		order:  t.order,
+	}
+}
+
+// MakePersistent is a generic version of AsPersistent.
+func (t *TPQueue) MakePersistent() interface{} {
+	return t.AsPersistent()
+}
+
+// flush merges the pending nodes into the heap. Merging them in
+// pairs, round after round, takes linear time overall.
+func (t *TPQueue) flush() {
+	if len(t.pending) == 0 {
+		return
+	}
+	heaps := append(t.pending, t.root)
+	for len(heaps) > 1 {
+		var next []*node
+		for i := 0; i+1 < len(heaps); i += 2 {
+			next = append(next, merge(t.order.cmp, heaps[i], heaps[i+1]))
+		}
+		if len(heaps)%2 == 1 {
+			next = append(next, heaps[len(heaps)-1])
+		}
+		heaps = next
+	}
+	t.root = heaps[0]
+	t.pending = nil
+}
+
+func (t *TPQueue) ensureEditable() {
+	if t.done {
+		panic(errTafterP)
+	}
+}
@@ -0,0 +1,203 @@
+package pqueue
+
+import (
+	"sort"
+	"testing"
+
+	"github.com/leanovate/gopter"
+	"github.com/leanovate/gopter/gen"
+	"github.com/leanovate/gopter/prop"
+	"jsouthworth.net/go/dyn"
+	"jsouthworth.net/go/seq"
+)
+
+// drain pops every element of q and returns the values in the order
+// they were popped.
+func drain(q *PQueue) []int {
+	var out []int
+	for q.Length() > 0 {
+		v, _ := q.PeekMin()
+		out = append(out, v.(int))
+		q = q.PopMin()
+	}
+	return out
+}
+
+// expected returns the indexes of prios sorted by priority and then
+// by index.
+func expected(prios []int) []int {
+	out := make([]int, len(prios))
+	for i := range out {
+		out[i] = i
+	}
+	sort.SliceStable(out, func(i, j int) bool {
+		return prios[out[i]] < prios[out[j]]
+	})
+	return out
+}
+
+func intsEqual(a, b []int) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func TestPQueue(t *testing.T) {
+	parameters := gopter.DefaultTestParameters()
+	properties := gopter.NewProperties(parameters)
+	prios := gen.SliceOf(gen.IntRange(0, 50))
+	properties.Property("PopMin yields priority order with stable ties", prop.ForAll(
+		func(ps []int) bool {
+			q := Empty()
+			for i, p := range ps {
+				q = q.Insert(i, p)
+			}
+			return q.Length() == len(ps) && intsEqual(drain(q), expected(ps))
+		},
+		prios,
+	))
+	properties.Property("the transient builder matches Insert", prop.ForAll(
+		func(ps []int) bool {
+			t := Empty().AsTransient()
+			for i, p := range ps {
+				t = t.Insert(i, p)
+				if i%7 == 6 {
+					t.PeekMin()
+				}
+			}
+			return intsEqual(drain(t.AsPersistent()), expected(ps))
+		},
+		prios,
+	))
+	properties.Property("Meld holds the elements of both queues", prop.ForAll(
+		func(as, bs []int) bool {
+			a, b := Empty(), Empty()
+			for i, p := range as {
+				a = a.Insert(i, p)
+			}
+			for i, p := range bs {
+				b = b.Insert(len(as)+i, p)
+			}
+			m := a.Meld(b)
+			all := append(append([]int{}, as...), bs...)
+			var got []int
+			m.Range(func(v, p int) {
+				got = append(got, p)
+			})
+			sorted := append([]int{}, all...)
+			sort.Ints(sorted)
+			return m.Length() == len(all) && intsEqual(got, sorted) &&
+				intsEqual(drain(a), expected(as))
+		},
+		prios, prios,
+	))
+	properties.Property("old versions are unaffected by PopMin", prop.ForAll(
+		func(ps []int) bool {
+			q := Empty()
+			for i, p := range ps {
+				q = q.Insert(i, p)
+			}
+			drain(q.PopMin())
+			q.PopMin().Insert(-1, -1)
+			return intsEqual(drain(q), expected(ps))
+		},
+		prios,
+	))
+	properties.TestingRun(t)
+}
+
+func TestPQueueCompare(t *testing.T) {
+	q := Empty(Compare(func(a, b interface{}) int {
+		return -dyn.Compare(a, b)
+	}))
+	q = q.Insert("low", 1).Insert("high", 10).Insert("mid", 5)
+	got := seq.Reduce(func(res, v interface{}) interface{} {
+		return res.(string) + v.(string) + " "
+	}, "", q.Seq())
+	if got != "high mid low " {
+		t.Fatal("unexpected order", got)
+	}
+	if q.String() != "[ high:10 mid:5 low:1 ]" {
+		t.Fatal("unexpected string", q.String())
+	}
+}
+
+func TestPQueueEmpty(t *testing.T) {
+	q := Empty()
+	if v, p := q.PeekMin(); v != nil || p != nil {
+		t.Fatal("expected nil from an empty queue")
+	}
+	if q.PopMin() != q || q.Seq() != nil {
+		t.Fatal("expected popping an empty queue to return it")
+	}
+}
+
+func TestMeldDistinctComparators(t *testing.T) {
+	// Both comparators are closures made by the same function
+	// literal so they can not be told apart by their code.
+	mod := func(n int) Option {
+		return Compare(func(a, b interface{}) int {
+			return dyn.Compare(a.(int)%n, b.(int)%n)
+		})
+	}
+	a, b := Empty(mod(7)), Empty(mod(5))
+	for i := 0; i < 50; i++ {
+		a = a.Insert(i, i)
+		b = b.Insert(i+50, i+50)
+	}
+	q := a.Meld(b)
+	if q.Length() != 100 {
+		t.Fatal("unexpected length", q.Length())
+	}
+	prev := -1
+	for ; q.Length() != 0; q = q.PopMin() {
+		_, p := q.PeekMin()
+		if p.(int)%7 < prev {
+			t.Fatal("priorities popped out of order", p, prev)
+		}
+		prev = p.(int) % 7
+	}
+
+	shared := mod(7)
+	q = Empty(shared).Insert("a", 3).Meld(Empty(shared).Insert("b", 9))
+	if v, _ := q.PeekMin(); v != "b" {
+		t.Fatal("expected queues made with one option to meld", v)
+	}
+}
+
+func TestTransientAfterPersistent(t *testing.T) {
+	tq := Empty().AsTransient().Insert(1, 1)
+	tq.AsPersistent()
+	defer func() {
+		if r := recover(); r != errTafterP {
+			t.Fatal("expected errTafterP got", r)
+		}
+	}()
+	tq.Insert(2, 2)
+}
+
+func TestTransformForms(t *testing.T) {
+	q := Empty().Insert("c", 3).Transform(
+		func(t *TPQueue) *TPQueue { return t.Insert("a", 1).Insert("d", 4) },
+		func(t *TPQueue) { t.PopMin() },
+		func(t *TPQueue) *TPQueue {
+			t.Insert("b", 2)
+			return nil
+		},
+	)
+	if v, p := q.PeekMin(); v != "b" || p != 2 || q.Length() != 3 {
+		t.Fatal("unexpected queue", v, p, q.Length())
+	}
+	defer func() {
+		if r := recover(); r != errTransformSig {
+			t.Fatal("expected errTransformSig got", r)
+		}
+	}()
+	q.Transform(q.AsTransient().PeekMin)
+}
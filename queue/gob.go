@@ -0,0 +1,51 @@
+package queue
+
+import (
+	"encoding/gob"
+
+	"jsouthworth.net/go/immutable/internal/gobenc"
+)
+
+func init() {
+	gob.Register(&Queue{})
+}
+
+// GobEncode encodes the queue for encoding/gob as its length
+// followed by its elements from front to back. Elements held in
+// interfaces must be of types registered with gob.Register.
+func (q *Queue) GobEncode() ([]byte, error) {
+	return gobenc.EncodeElems(func(yield func(interface{}) bool) {
+		q.Range(func(elem interface{}) bool {
+			return yield(elem)
+		})
+	})
+}
+
+// GobDecode replaces the contents of the queue with data produced by
+// GobEncode. Like UnmarshalJSON it overwrites the queue in place.
+func (q *Queue) GobDecode(data []byte) error {
+	if q == Empty() {
+		return errDecodeEmpty
+	}
+	out := Empty().AsTransient()
+	err := gobenc.DecodeElems(data, func(elem interface{}) {
+		out = out.Push(elem)
+	})
+	if err != nil {
+		return err
+	}
+	*q = *out.AsPersistent()
+	return nil
+}
+
+// MarshalBinary implements encoding.BinaryMarshaler with the
+// encoding used by GobEncode.
+func (q *Queue) MarshalBinary() ([]byte, error) {
+	return q.GobEncode()
+}
+
+// UnmarshalBinary implements encoding.BinaryUnmarshaler. It is the
+// same as GobDecode.
+func (q *Queue) UnmarshalBinary(data []byte) error {
+	return q.GobDecode(data)
+}
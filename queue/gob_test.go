@@ -0,0 +1,31 @@
+package queue
+
+import (
+	"bytes"
+	"encoding/gob"
+	"testing"
+)
+
+func TestGob(t *testing.T) {
+	// q holds elements in both its front stream and its rear list.
+	q := New(1, 2, 3).Pop().Push(4).Push(5)
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(struct{ Q *Queue }{q}); err != nil {
+		t.Fatal(err)
+	}
+	var out struct{ Q *Queue }
+	if err := gob.NewDecoder(&buf).Decode(&out); err != nil {
+		t.Fatal(err)
+	}
+	var got []interface{}
+	for d := out.Q; d.Length() > 0; d = d.Pop() {
+		got = append(got, d.First())
+	}
+	if !out.Q.Equal(q) || len(got) != 4 || got[0] != 2 || got[3] != 5 {
+		t.Fatal("expected the decoded queue to pop in order", got)
+	}
+	b, _ := q.MarshalBinary()
+	if err := Empty().UnmarshalBinary(b); err != errDecodeEmpty {
+		t.Fatal("expected decoding into Empty() to fail", err)
+	}
+}
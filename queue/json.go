@@ -0,0 +1,51 @@
+package queue
+
+import (
+	"errors"
+
+	"jsouthworth.net/go/immutable/internal/jsonenc"
+)
+
+var errDecodeEmpty = errors.New("can not decode into the shared empty queue")
+
+// MarshalJSON encodes the queue as a JSON array of its elements from
+// the front of the queue to the back.
+func (q *Queue) MarshalJSON() ([]byte, error) {
+	return jsonenc.EncodeArray(func(yield func(interface{}) bool) {
+		q.Range(func(elem interface{}) bool {
+			return yield(elem)
+		})
+	}, false)
+}
+
+// UnmarshalJSON replaces the contents of the queue with the elements
+// of a JSON array, the first element becoming the front of the
+// queue. Elements are decoded as encoding/json decodes into an empty
+// interface. Decoding null leaves the queue unchanged.
+//
+// UnmarshalJSON overwrites the queue in place, so it must only be
+// used on a queue that is not shared, such as the one encoding/json
+// allocates when decoding into a nil *Queue.
+func (q *Queue) UnmarshalJSON(data []byte) error {
+	return q.DecodeJSON(data, nil)
+}
+
+// DecodeJSON is UnmarshalJSON with each element decoded by decode
+// instead. A nil decode behaves like UnmarshalJSON.
+func (q *Queue) DecodeJSON(data []byte, decode func(data []byte) (interface{}, error)) error {
+	if q == Empty() {
+		return errDecodeEmpty
+	}
+	if jsonenc.IsNull(data) {
+		return nil
+	}
+	out := Empty().AsTransient()
+	err := jsonenc.DecodeArray(data, decode, func(elem interface{}) {
+		out = out.Push(elem)
+	})
+	if err != nil {
+		return err
+	}
+	*q = *out.AsPersistent()
+	return nil
+}
@@ -0,0 +1,21 @@
+package queue
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestJSON(t *testing.T) {
+	q := New("a", "b").Pop().Push("c").Push("d")
+	b, err := json.Marshal(q)
+	if err != nil || string(b) != `["b","c","d"]` {
+		t.Fatal("unexpected encoding", string(b), err)
+	}
+	var out *Queue
+	if err := json.Unmarshal(b, &out); err != nil || !out.Equal(q) || out.First() != "b" {
+		t.Fatal("unexpected decoding", out, err)
+	}
+	if err := Empty().UnmarshalJSON(b); err != errDecodeEmpty {
+		t.Fatal("expected decoding into Empty() to fail", err)
+	}
+}
@@ -8,20 +8,39 @@ import (
 	"strings"
 
 	"jsouthworth.net/go/dyn"
-	"jsouthworth.net/go/immutable/vector"
+	"jsouthworth.net/go/immutable/list"
 	"jsouthworth.net/go/seq"
 )
 
 var errRangeSig = errors.New("Range requires a function: func(v vT) bool or func(v vT)")
+var errReduceSig = errors.New("Reduce requires a function: func(init iT, v vT) oT")
+var errTransformSig = errors.New("Transform requires functions: func(t *TQueue) *TQueue or func(t *TQueue)")
+var errOutOfBounds = errors.New("out of bounds")
+var errTafterP = errors.New("transient used after persistent call")
 
 // Queue represents a persistent immutable queue structure.
+//
+// The queue is Okasaki's real-time queue. Elements are popped from a
+// lazily built front stream and pushed onto a rear list. Before the
+// rear grows longer than the front the two are joined by a rotation
+// that reverses the rear onto the end of the front one element at a
+// time. Every Push and Pop advances the rotation by a single step, so
+// both take constant time in the worst case, even when old versions
+// of the queue are kept and reused. Only the elements still in the
+// queue are reachable from it. The front is only empty when the whole
+// queue is.
 type Queue struct {
-	bv *vector.Slice
+	front *stream
+	// flen is the length of front.
+	flen int
+	// rear holds the most recently pushed element first.
+	rear *list.List
+	// sched is the part of front that has not been computed yet.
+	// Its length is always flen less the length of rear.
+	sched *stream
 }
 
-var empty = Queue{
-	bv: vector.Empty().Slice(0, 0),
-}
+var empty = Queue{}
 
 // Empty returns an empty queue.
 func Empty() *Queue {
@@ -30,11 +49,7 @@ func Empty() *Queue {
 
 // New returns a queue populated with elems.
 func New(elems ...interface{}) *Queue {
-	q := Empty()
-	for _, elem := range elems {
-		q = q.Push(elem)
-	}
-	return q
+	return Empty().PushAll(elems...)
 }
 
 // From returns a queue created from one of several go types:
@@ -75,11 +90,11 @@ func queueFromReflection(value interface{}) *Queue {
 	v := reflect.ValueOf(value)
 	switch v.Kind() {
 	case reflect.Slice:
-		out := Empty()
+		out := Empty().AsTransient()
 		for i := 0; i < v.Len(); i++ {
 			out = out.Push(v.Index(i).Interface())
 		}
-		return out
+		return out.AsPersistent()
 	default:
 		return Empty()
 	}
@@ -87,15 +102,54 @@ func queueFromReflection(value interface{}) *Queue {
 
 func queueFromSequence(coll seq.Sequence) *Queue {
 	return seq.Reduce(func(result, input interface{}) interface{} {
-		return result.(*Queue).Push(input)
-	}, Empty(), coll).(*Queue)
+		return result.(*TQueue).Push(input)
+	}, Empty().AsTransient(), coll).(*TQueue).AsPersistent()
 }
 
 // Push returns a Queue with the element added to the end.
 func (q *Queue) Push(elem interface{}) *Queue {
-	return &Queue{
-		bv: q.bv.Append(elem),
+	out := *q
+	out.push(elem)
+	return &out
+}
+
+func (q *Queue) push(elem interface{}) {
+	q.rear = q.rear.Cons(elem)
+	q.exec()
+}
+
+func (q *Queue) pop() {
+	q.front = q.front.tail()
+	q.flen--
+	q.exec()
+}
+
+// exec takes one step of the rotation after a push or pop, or starts
+// a new rotation when the previous one has finished and the rear has
+// become longer than the front.
+func (q *Queue) exec() {
+	if q.sched != nil {
+		q.sched = q.sched.tail()
+		return
+	}
+	q.front = rotate(q.front, q.rear, nil)
+	q.flen += q.rear.Length()
+	q.rear = nil
+	q.sched = q.front
+}
+
+// PushAll returns a Queue with the elements added to the end in
+// order. The elements are pushed onto a transient queue so only one
+// new Queue is allocated.
+func (q *Queue) PushAll(elems ...interface{}) *Queue {
+	if len(elems) == 0 {
+		return q
+	}
+	out := q.AsTransient()
+	for _, elem := range elems {
+		out = out.Push(elem)
 	}
+	return out.AsPersistent()
 }
 
 // Conj returns a Queue with the element added to the end.
@@ -106,19 +160,106 @@ func (q *Queue) Conj(elem interface{}) interface{} {
 
 // Pop returns a queue with the first element removed.
 func (q *Queue) Pop() *Queue {
-	new := q.bv.Slice(1, q.bv.Length())
-	if new.Length() == 0 {
+	if q.Length() <= 1 {
+		return Empty()
+	}
+	out := *q
+	out.pop()
+	return &out
+}
+
+// PopN returns a queue with the first n elements removed. If n is
+// at least the length of the queue the empty queue is returned.
+func (q *Queue) PopN(n int) *Queue {
+	switch {
+	case n <= 0:
+		return q
+	case n >= q.Length():
 		return Empty()
 	}
-	return &Queue{
-		bv: new,
+	out := *q
+	for ; n > 0; n-- {
+		out.pop()
 	}
+	return &out
+}
+
+// Drop returns a queue with the first n elements removed. It is
+// the same as PopN.
+func (q *Queue) Drop(n int) *Queue {
+	return q.PopN(n)
+}
+
+// Take returns a new queue holding the first n elements of q. If n
+// is at least the length of the queue, q is returned.
+func (q *Queue) Take(n int) *Queue {
+	switch {
+	case n <= 0:
+		return Empty()
+	case n >= q.Length():
+		return q
+	}
+	out := Empty().AsTransient()
+	for iter := q.iterator(); n > 0; n-- {
+		out = out.Push(iter.next())
+	}
+	return out.AsPersistent()
+}
+
+// At returns the i-th element of the queue counting from the first.
+// The element is found by walking the queue so At takes linear time.
+// At will panic if i is out of bounds.
+func (q *Queue) At(i int) interface{} {
+	if i < 0 || i >= q.Length() {
+		panic(errOutOfBounds)
+	}
+	if i < q.flen {
+		front := q.front
+		for ; i > 0; i-- {
+			front = front.tail()
+		}
+		return front.first
+	}
+	rear := q.rear
+	for i = q.Length() - 1 - i; i > 0; i-- {
+		rear = rear.Next()
+	}
+	return rear.First()
+}
+
+// Find whether the value exists in the queue by walking every value.
+// Values are compared with dyn.Equal. Returns the value and whether
+// or not it was found.
+func (q *Queue) Find(value interface{}) (interface{}, bool) {
+	var out interface{}
+	var found bool
+	q.Range(func(v interface{}) bool {
+		if dyn.Equal(v, value) {
+			out = v
+			found = true
+			return false
+		}
+		return true
+	})
+	return out, found
+}
+
+// AsNative returns the elements of the queue, first to last, as a
+// go slice.
+func (q *Queue) AsNative() []interface{} {
+	out := make([]interface{}, 0, q.Length())
+	for iter := q.iterator(); iter.hasNext(); {
+		out = append(out, iter.next())
+	}
+	return out
 }
 
 // First returns the first element of the queue.
 func (q *Queue) First() interface{} {
-	elem, _ := q.bv.Find(0)
-	return elem
+	if q.front == nil {
+		return nil
+	}
+	return q.front.first
 }
 
 // Range calls the passed in function on each element of the queue.
@@ -173,9 +314,8 @@ func (q *Queue) Range(do interface{}) {
 		}
 	}
 	cont := true
-	for queue := q; queue != Empty() && cont; queue = queue.Pop() {
-		value := queue.First()
-		cont = f(value)
+	for iter := q.iterator(); iter.hasNext() && cont; {
+		cont = f(iter.next())
 	}
 }
 
@@ -187,12 +327,45 @@ func (q *Queue) Range(do interface{}) {
 //
 // Reduce will panic if given any other function type.
 func (q *Queue) Reduce(fn interface{}, init interface{}) interface{} {
-	return q.bv.Reduce(fn, init)
+	// NOTE: Update other functions using the same pattern
+	//       when modifying the below.
+	//       this code is inlined to avoid heap allocation of
+	//       the closure.
+	var rFn func(r, v interface{}) interface{}
+	switch f := fn.(type) {
+	case func(res, val interface{}) interface{}:
+		rFn = f
+	default:
+		rFn = genReduceFunc(fn)
+	}
+
+	res := init
+	q.Range(func(v interface{}) {
+		res = rFn(res, v)
+	})
+	return res
+}
+
+func genReduceFunc(fn interface{}) func(r, v interface{}) interface{} {
+	rv := reflect.ValueOf(fn)
+	if rv.Kind() != reflect.Func {
+		panic(errReduceSig)
+	}
+	rt := rv.Type()
+	if rt.NumIn() != 2 {
+		panic(errReduceSig)
+	}
+	if rt.NumOut() != 1 {
+		panic(errReduceSig)
+	}
+	return func(r, v interface{}) interface{} {
+		return dyn.Apply(fn, r, v)
+	}
 }
 
 // Seq returns the queue as a sequence.
 func (q *Queue) Seq() seq.Sequence {
-	if q.bv.Length() == 0 {
+	if q.Length() == 0 {
 		return nil
 	}
 	return &queueSeq{
@@ -211,17 +384,98 @@ func (q *Queue) String() string {
 	return b.String()
 }
 
+// AsTransient returns a transient queue that shares structure with
+// the persistent queue.
+func (q *Queue) AsTransient() *TQueue {
+	return &TQueue{
+		queue: *q,
+	}
+}
+
+// MakeTransient is a generic version of AsTransient.
+func (q *Queue) MakeTransient() interface{} {
+	return q.AsTransient()
+}
+
+// Transform takes a set of actions and performs them
+// on the persistent queue. It does this by making a transient
+// queue and calling each action on it, then converting it back
+// to a persistent queue.
+// Each action may be a func(*TQueue) *TQueue, whose result is passed to
+// the following action unless it is nil, or a func(*TQueue).
+// Transform will panic if given any other type.
+func (q *Queue) Transform(actions ...interface{}) *Queue {
+	out := q.AsTransient()
+	for _, action := range actions {
+		switch fn := action.(type) {
+		case func(*TQueue) *TQueue:
+			if next := fn(out); next != nil {
+				out = next
+			}
+		case func(*TQueue):
+			fn(out)
+		default:
+			panic(errTransformSig)
+		}
+	}
+	return out.AsPersistent()
+}
+
 // Length returns the number of elements currently in the queue.
 func (q *Queue) Length() int {
-	return q.bv.Length()
+	return q.flen + q.rear.Length()
 }
 
 // Equal returns whether the other value passed in is a queue and the
 // values of that queue are equal to its values.
 func (q *Queue) Equal(other interface{}) bool {
 	oq, isQueue := other.(*Queue)
-	return isQueue &&
-		q.bv.Equal(oq.bv)
+	if !isQueue || q.Length() != oq.Length() {
+		return false
+	}
+	a, b := q.iterator(), oq.iterator()
+	for a.hasNext() {
+		if !dyn.Equal(a.next(), b.next()) {
+			return false
+		}
+	}
+	return true
+}
+
+// iterator walks the elements of a queue from first to last without
+// popping them.
+type iterator struct {
+	front *stream
+	// rear holds the elements of the rear list in queue order.
+	rear []interface{}
+}
+
+func (q *Queue) iterator() iterator {
+	rear := make([]interface{}, q.rear.Length())
+	i := len(rear)
+	for l := q.rear; l != nil; l = l.Next() {
+		i--
+		rear[i] = l.First()
+	}
+	return iterator{
+		front: q.front,
+		rear:  rear,
+	}
+}
+
+func (i *iterator) hasNext() bool {
+	return i.front != nil || len(i.rear) != 0
+}
+
+func (i *iterator) next() interface{} {
+	if i.front != nil {
+		out := i.front.first
+		i.front = i.front.tail()
+		return out
+	}
+	out := i.rear[0]
+	i.rear = i.rear[1:]
+	return out
 }
 
 type queueSeq struct {
@@ -234,7 +488,7 @@ func (q *queueSeq) First() interface{} {
 
 func (q *queueSeq) Next() seq.Sequence {
 	new := q.queue.Pop()
-	if new.bv.Length() == 0 {
+	if new.Length() == 0 {
 		return nil
 	}
 	return &queueSeq{
@@ -245,3 +499,69 @@ func (q *queueSeq) Next() seq.Sequence {
 func (q *queueSeq) String() string {
 	return seq.ConvertToString(q)
 }
+
+// TQueue is a transient version of a queue. Changes made to a
+// transient queue occur as mutations and do not affect the
+// persistent queue it was made from. Transient queues are useful
+// when pushing or popping many elements at once.
+type TQueue struct {
+	queue Queue
+	done  bool
+}
+
+// Push adds the element to the end of the queue. q is returned.
+func (q *TQueue) Push(elem interface{}) *TQueue {
+	q.ensureEditable()
+	q.queue.push(elem)
+	return q
+}
+
+// Conj adds the element to the end of the queue.
+// Conj implements a generic mechanism for building collections.
+func (q *TQueue) Conj(elem interface{}) interface{} {
+	return q.Push(elem)
+}
+
+// Pop removes the first element of the queue. q is returned.
+func (q *TQueue) Pop() *TQueue {
+	q.ensureEditable()
+	if q.queue.flen != 0 {
+		q.queue.pop()
+	}
+	return q
+}
+
+// First returns the first element of the queue.
+func (q *TQueue) First() interface{} {
+	q.ensureEditable()
+	return q.queue.First()
+}
+
+// Length returns the number of elements currently in the queue.
+func (q *TQueue) Length() int {
+	q.ensureEditable()
+	return q.queue.Length()
+}
+
+// AsPersistent returns an immutable version of the queue. Any
+// transient operations performed after this will cause a panic.
+func (q *TQueue) AsPersistent() *Queue {
+	q.ensureEditable()
+	q.done = true
+	if q.queue.Length() == 0 {
+		return Empty()
+	}
+	out := q.queue
+	return &out
+}
+
+// MakePersistent is a generic version of AsPersistent.
+func (q *TQueue) MakePersistent() interface{} {
+	return q.AsPersistent()
+}
+
+func (q *TQueue) ensureEditable() {
+	if q.done {
+		panic(errTafterP)
+	}
+}
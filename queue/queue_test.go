@@ -374,3 +374,259 @@ func TestQueueReduce(t *testing.T) {
 		t.Fatal("didn't get expected value", out)
 	}
 }
+
+func TestQueueInterleaved(t *testing.T) {
+	parameters := gopter.DefaultTestParameters()
+	properties := gopter.NewProperties(parameters)
+	properties.Property("interleaved Push and Pop preserve FIFO order", prop.ForAll(
+		func(ops []bool) bool {
+			q := Empty()
+			var model []int
+			next := 0
+			for _, push := range ops {
+				if push {
+					q = q.Push(next)
+					model = append(model, next)
+					next++
+					continue
+				}
+				q = q.Pop()
+				if len(model) > 0 {
+					model = model[1:]
+				}
+			}
+			if q.Length() != len(model) {
+				return false
+			}
+			if len(model) > 0 && q.First() != model[0] {
+				return false
+			}
+			var got []int
+			q.Range(func(v int) {
+				got = append(got, v)
+			})
+			if len(got) != len(model) {
+				return false
+			}
+			for i := range got {
+				if got[i] != model[i] {
+					return false
+				}
+			}
+			return q.Equal(New(q.Reduce(func(res []interface{}, v int) []interface{} {
+				return append(res, v)
+			}, []interface{}(nil)).([]interface{})...))
+		},
+		gen.SliceOf(gen.Bool()),
+	))
+	properties.TestingRun(t)
+}
+
+func TestQueueReleasesPopped(t *testing.T) {
+	q := Empty()
+	for i := 0; i < 1000; i++ {
+		q = q.Push(i).Pop()
+	}
+	if q.Length() != 0 || q.front != nil || q.rear != nil || q.sched != nil {
+		t.Fatal("popped elements are still held by the queue")
+	}
+	q = New(1, 2, 3).Pop()
+	if q.rear.Length() != 0 || q.flen != 2 {
+		t.Fatal("expected the rear to move to the front")
+	}
+}
+
+func TestQueueReusedVersion(t *testing.T) {
+	elems := make([]interface{}, 10000)
+	for i := range elems {
+		elems[i] = i
+	}
+	q := New(elems...)
+	s := q.Seq()
+	// Popping or walking the same version again must not repeat
+	// any work proportional to the length of the queue.
+	allocs := testing.AllocsPerRun(100, func() {
+		if q.Pop().First() != 1 || s.Next().First() != 1 {
+			t.Fatal("unexpected first element")
+		}
+	})
+	if allocs > 10 {
+		t.Fatal("expected Pop to take constant time, allocations:", allocs)
+	}
+	for i := 0; i < 3; i++ {
+		n := 0
+		for s := q.Seq(); s != nil; s = s.Next() {
+			if s.First() != n {
+				t.Fatal("unexpected element", s.First(), "expected", n)
+			}
+			n++
+		}
+		if n != len(elems) {
+			t.Fatal("unexpected length", n)
+		}
+	}
+}
+
+func TestQueueTransient(t *testing.T) {
+	parameters := gopter.DefaultTestParameters()
+	properties := gopter.NewProperties(parameters)
+	properties.Property("TQueue matches Queue", prop.ForAll(
+		func(ops []bool) bool {
+			q := Empty()
+			tq := Empty().AsTransient()
+			next := 0
+			for _, push := range ops {
+				if push {
+					q = q.Push(next)
+					tq = tq.Push(next)
+					next++
+				} else {
+					q = q.Pop()
+					tq = tq.Pop()
+				}
+				if q.Length() != tq.Length() || q.First() != tq.First() {
+					return false
+				}
+			}
+			return tq.AsPersistent().Equal(q)
+		},
+		gen.SliceOf(gen.Bool()),
+	))
+	properties.Property("PushAll(elems...) equals repeated Push", prop.ForAll(
+		func(start, elems []int) bool {
+			q := From(start)
+			expected := q
+			args := make([]interface{}, len(elems))
+			for i, elem := range elems {
+				expected = expected.Push(elem)
+				args[i] = elem
+			}
+			return q.PushAll(args...).Equal(expected)
+		},
+		gen.SliceOf(gen.Int()),
+		gen.SliceOf(gen.Int()),
+	))
+	properties.Property("PopN(n) equals n calls to Pop", prop.ForAll(
+		func(first, second []int, n int) bool {
+			q := From(first)
+			if q.Length() > 0 {
+				q = q.Pop()
+			}
+			for _, elem := range second {
+				q = q.Push(elem)
+			}
+			expected := q
+			for i := 0; i < n; i++ {
+				expected = expected.Pop()
+			}
+			return q.PopN(n).Equal(expected)
+		},
+		gen.SliceOf(gen.Int()),
+		gen.SliceOf(gen.Int()),
+		gen.IntRange(-1, 200),
+	))
+	properties.TestingRun(t)
+}
+
+func TestTransientAfterPersistent(t *testing.T) {
+	tests := []struct {
+		name string
+		do   func(*TQueue)
+	}{
+		{"Push", func(tq *TQueue) { tq.Push(3) }},
+		{"Pop", func(tq *TQueue) { tq.Pop() }},
+		{"First", func(tq *TQueue) { tq.First() }},
+		{"Length", func(tq *TQueue) { tq.Length() }},
+		{"AsPersistent", func(tq *TQueue) { tq.AsPersistent() }},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			// Drain the transient first so the check does not
+			// depend on what is left in it.
+			tq := New(1, 2).AsTransient().Pop().Pop()
+			tq.AsPersistent()
+			defer func() {
+				if r := recover(); r != errTafterP {
+					t.Fatal("expected errTafterP got", r)
+				}
+			}()
+			test.do(tq)
+		})
+	}
+}
+
+func TestQueueTransformForms(t *testing.T) {
+	q := Empty().Transform(
+		func(t *TQueue) *TQueue { return t.Push(1) },
+		func(t *TQueue) { t.Push(2).Pop().Push(3) },
+	)
+	if !q.Equal(New(2, 3)) {
+		t.Fatal("unexpected queue", q)
+	}
+	defer func() {
+		if r := recover(); r != errTransformSig {
+			t.Fatal("expected errTransformSig got", r)
+		}
+	}()
+	q.Transform(func() {})
+}
+
+func TestQueueIndexing(t *testing.T) {
+	parameters := gopter.DefaultTestParameters()
+	properties := gopter.NewProperties(parameters)
+	properties.Property("At, Take, Drop and AsNative match a slice", prop.ForAll(
+		func(n, popped, k int) bool {
+			q := Empty()
+			var model []interface{}
+			for i := 0; i < n; i++ {
+				q = q.Push(i)
+				model = append(model, i)
+				if i%3 == 2 && popped > 0 {
+					q = q.Pop()
+					model = model[1:]
+					popped--
+				}
+			}
+			native := q.AsNative()
+			if len(native) != len(model) {
+				return false
+			}
+			for i, v := range model {
+				if q.At(i) != v || native[i] != v {
+					return false
+				}
+			}
+			if k > len(model) {
+				k = len(model)
+			}
+			return q.Take(k).Equal(New(model[:k]...)) &&
+				q.Drop(k).Equal(New(model[k:]...))
+		},
+		gen.IntRange(0, 100),
+		gen.IntRange(0, 20),
+		gen.IntRange(0, 120),
+	))
+	properties.TestingRun(t)
+}
+
+func TestQueueFind(t *testing.T) {
+	q := New(1, "two", vector.New(3))
+	if v, ok := q.Find(vector.New(3)); !ok || !dyn.Equal(v, vector.New(3)) {
+		t.Fatal("expected to find an equal vector")
+	}
+	if v, ok := q.Find("two"); !ok || v != "two" {
+		t.Fatal("expected to find two")
+	}
+	if _, ok := q.Find(4); ok {
+		t.Fatal("didn't expect to find 4")
+	}
+}
+
+func TestQueueAtOutOfBounds(t *testing.T) {
+	defer func() {
+		if r := recover(); r != errOutOfBounds {
+			t.Fatal("expected errOutOfBounds got", r)
+		}
+	}()
+	New(1, 2).At(2)
+}
@@ -0,0 +1,44 @@
+package queue
+
+import (
+	"sync"
+
+	"jsouthworth.net/go/immutable/list"
+)
+
+// stream is a memoised lazy list. The first element is known when
+// the stream is made and rest computes the remainder of the stream
+// the first time tail is called. A nil stream is empty.
+type stream struct {
+	first interface{}
+	once  sync.Once
+	rest  func() *stream
+	next  *stream
+}
+
+func (s *stream) tail() *stream {
+	s.once.Do(func() {
+		if s.rest != nil {
+			s.next = s.rest()
+			s.rest = nil
+		}
+	})
+	return s.next
+}
+
+// rotate returns the stream of the elements of front, then of rear
+// in reverse, then of acc. Rear must hold exactly one more element
+// than front. Each call to tail on the result reverses one more
+// element of rear so no step takes more than constant time.
+func rotate(front *stream, rear *list.List, acc *stream) *stream {
+	if front == nil {
+		return &stream{first: rear.First(), next: acc}
+	}
+	return &stream{
+		first: front.first,
+		rest: func() *stream {
+			return rotate(front.tail(), rear.Next(),
+				&stream{first: rear.First(), next: acc})
+		},
+	}
+}
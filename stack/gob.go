@@ -0,0 +1,45 @@
+package stack
+
+import (
+	"encoding/gob"
+
+	"jsouthworth.net/go/immutable/vector"
+)
+
+func init() {
+	gob.Register(&Stack{})
+}
+
+// GobEncode encodes the stack for encoding/gob as its length
+// followed by its elements from the bottom of the stack to the top.
+// Elements held in interfaces must be of types registered with
+// gob.Register.
+func (s *Stack) GobEncode() ([]byte, error) {
+	return s.backingVector.GobEncode()
+}
+
+// GobDecode replaces the contents of the stack with data produced by
+// GobEncode. Like UnmarshalJSON it overwrites the stack in place.
+func (s *Stack) GobDecode(data []byte) error {
+	if s == Empty() {
+		return errDecodeEmpty
+	}
+	v := new(vector.Vector)
+	if err := v.GobDecode(data); err != nil {
+		return err
+	}
+	s.backingVector = v
+	return nil
+}
+
+// MarshalBinary implements encoding.BinaryMarshaler with the
+// encoding used by GobEncode.
+func (s *Stack) MarshalBinary() ([]byte, error) {
+	return s.GobEncode()
+}
+
+// UnmarshalBinary implements encoding.BinaryUnmarshaler. It is the
+// same as GobDecode.
+func (s *Stack) UnmarshalBinary(data []byte) error {
+	return s.GobDecode(data)
+}
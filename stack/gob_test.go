@@ -0,0 +1,21 @@
+package stack
+
+import "testing"
+
+func TestGob(t *testing.T) {
+	s := New(1, 2).Push(3).Pop().Push("top")
+	b, err := s.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	out := new(Stack)
+	if err := out.UnmarshalBinary(b); err != nil || !out.Equal(s) {
+		t.Fatal("unexpected decoding", out, err)
+	}
+	if out.Top() != "top" || out.Peek(2) != 1 || out.Pop().Top() != 2 {
+		t.Fatal("expected the decoded stack to keep its top", out)
+	}
+	if err := Empty().UnmarshalBinary(b); err != errDecodeEmpty {
+		t.Fatal("expected decoding into Empty() to fail", err)
+	}
+}
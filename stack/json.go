@@ -0,0 +1,45 @@
+package stack
+
+import (
+	"errors"
+
+	"jsouthworth.net/go/immutable/internal/jsonenc"
+	"jsouthworth.net/go/immutable/vector"
+)
+
+var errDecodeEmpty = errors.New("can not decode into the shared empty stack")
+
+// MarshalJSON encodes the stack as a JSON array of its elements from
+// the bottom of the stack to the top, the order New and From expect.
+func (s *Stack) MarshalJSON() ([]byte, error) {
+	return s.backingVector.MarshalJSON()
+}
+
+// UnmarshalJSON replaces the contents of the stack with the elements
+// of a JSON array, the last element becoming the top of the stack.
+// Elements are decoded as encoding/json decodes into an empty
+// interface. Decoding null leaves the stack unchanged.
+//
+// UnmarshalJSON overwrites the stack in place, so it must only be
+// used on a stack that is not shared, such as the one encoding/json
+// allocates when decoding into a nil *Stack.
+func (s *Stack) UnmarshalJSON(data []byte) error {
+	return s.DecodeJSON(data, nil)
+}
+
+// DecodeJSON is UnmarshalJSON with each element decoded by decode
+// instead. A nil decode behaves like UnmarshalJSON.
+func (s *Stack) DecodeJSON(data []byte, decode func(data []byte) (interface{}, error)) error {
+	if s == Empty() {
+		return errDecodeEmpty
+	}
+	if jsonenc.IsNull(data) {
+		return nil
+	}
+	v := new(vector.Vector)
+	if err := v.DecodeJSON(data, decode); err != nil {
+		return err
+	}
+	s.backingVector = v
+	return nil
+}
@@ -0,0 +1,29 @@
+package stack
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestJSON(t *testing.T) {
+	s := New("a", "b", "c")
+	b, err := json.Marshal(s)
+	if err != nil || string(b) != `["a","b","c"]` {
+		t.Fatal("unexpected encoding", string(b), err)
+	}
+	var out *Stack
+	if err := json.Unmarshal(b, &out); err != nil || !out.Equal(s) || out.Top() != "c" {
+		t.Fatal("unexpected decoding", out, err)
+	}
+	if err := json.Unmarshal([]byte(`[]`), &out); err != nil || out.Length() != 0 {
+		t.Fatal("unexpected empty decoding", out, err)
+	}
+	count := 0
+	out.Range(func(interface{}) { count++ })
+	if count != 0 {
+		t.Fatal("expected a decoded empty stack to range over nothing")
+	}
+	if err := Empty().UnmarshalJSON(b); err != errDecodeEmpty {
+		t.Fatal("expected decoding into Empty() to fail", err)
+	}
+}
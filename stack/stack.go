@@ -14,6 +14,8 @@ import (
 
 var errRangeSig = errors.New("Range requires a function: func(v vT) bool or func(v vT)")
 var errReduceSig = errors.New("Reduce requires a function: func(init iT, v vT) oT")
+var errTransformSig = errors.New("Transform requires functions: func(t *TStack) *TStack or func(t *TStack)")
+var errOutOfBounds = errors.New("out of bounds")
 
 // Stack is a persistent stack.
 type Stack struct {
@@ -104,13 +106,42 @@ func (s *Stack) Top() interface{} {
 	return s.backingVector.At(s.backingVector.Length() - 1)
 }
 
+// Peek returns the n-th element counting down from the top of the
+// stack; Peek(0) is the same as Top. Peek will panic if n is out of
+// bounds.
+func (s *Stack) Peek(n int) interface{} {
+	if n < 0 || n >= s.backingVector.Length() {
+		panic(errOutOfBounds)
+	}
+	return s.backingVector.At(s.backingVector.Length() - 1 - n)
+}
+
+// PopN returns a new stack without the top n elements. If n is at
+// least the length of the stack the empty stack is returned.
+func (s *Stack) PopN(n int) *Stack {
+	switch {
+	case n <= 0:
+		return s
+	case n >= s.Length():
+		return Empty()
+	}
+	return s.AsTransient().PopN(n).AsPersistent()
+}
+
+// Drop returns a new stack without the top n elements. It is the
+// same as PopN.
+func (s *Stack) Drop(n int) *Stack {
+	return s.PopN(n)
+}
+
 // Find whether the value exists in the stack by walking every value.
+// Values are compared with dyn.Equal.
 // Returns the value and whether or not it was found.
 func (s *Stack) Find(value interface{}) (interface{}, bool) {
 	var out interface{}
 	var found bool
 	s.Range(func(v interface{}) bool {
-		if v == value {
+		if dyn.Equal(v, value) {
 			out = v
 			found = true
 			return false
@@ -175,7 +206,7 @@ func (s *Stack) Range(do interface{}) {
 		f = genRangeFunc(do)
 	}
 	cont := true
-	for stack := s; stack != Empty() && cont; stack = stack.Pop() {
+	for stack := s; stack.Length() != 0 && cont; stack = stack.Pop() {
 		value := stack.Top()
 		cont = f(value)
 	}
@@ -272,15 +303,27 @@ func (s *Stack) String() string {
 // on the persistent stack. It does this by making a transient
 // stack and calling each action on it, then converting it back
 // to a persistent vector.
-func (s *Stack) Transform(actions ...func(*TStack) *TStack) *Stack {
+// Each action may be a func(*TStack) *TStack, whose result is passed to
+// the following action unless it is nil, or a func(*TStack).
+// Transform will panic if given any other type.
+func (s *Stack) Transform(actions ...interface{}) *Stack {
 	out := s.AsTransient()
 	for _, action := range actions {
-		out = action(out)
+		switch fn := action.(type) {
+		case func(*TStack) *TStack:
+			if next := fn(out); next != nil {
+				out = next
+			}
+		case func(*TStack):
+			fn(out)
+		default:
+			panic(errTransformSig)
+		}
 	}
 	return out.AsPersistent()
 }
 
-// Equal tests if two sets are Equal by comparing the entries of each.
+// Equal tests if two stacks are Equal by comparing the entries of each.
 // Equal implements the Equaler which allows for deep
 // comparisons.
 func (s *Stack) Equal(o interface{}) bool {
@@ -334,13 +377,33 @@ func (s *TStack) Top() interface{} {
 	return s.backingVector.At(s.backingVector.Length() - 1)
 }
 
+// Peek returns the n-th element counting down from the top of the
+// stack; Peek(0) is the same as Top. Peek will panic if n is out of
+// bounds.
+func (s *TStack) Peek(n int) interface{} {
+	if n < 0 || n >= s.backingVector.Length() {
+		panic(errOutOfBounds)
+	}
+	return s.backingVector.At(s.backingVector.Length() - 1 - n)
+}
+
+// PopN removes the top n elements of the stack, or all of them if
+// there are fewer than n. s is returned.
+func (s *TStack) PopN(n int) *TStack {
+	for ; n > 0 && s.backingVector.Length() > 0; n-- {
+		s.backingVector = s.backingVector.Pop()
+	}
+	return s
+}
+
 // Find whether the value exists in the stack by walking every value.
+// Values are compared with dyn.Equal.
 // Returns the value and whether or not it was found.
 func (s *TStack) Find(value interface{}) (interface{}, bool) {
 	var out interface{}
 	var found bool
 	s.Range(func(v interface{}) bool {
-		if v == value {
+		if dyn.Equal(v, value) {
 			out = v
 			found = true
 			return false
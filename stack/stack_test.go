@@ -8,6 +8,8 @@ import (
 	"github.com/leanovate/gopter"
 	"github.com/leanovate/gopter/gen"
 	"github.com/leanovate/gopter/prop"
+	"jsouthworth.net/go/immutable/hashmap"
+	"jsouthworth.net/go/immutable/vector"
 	"jsouthworth.net/go/seq"
 )
 
@@ -454,6 +456,77 @@ func TestEqual(t *testing.T) {
 	if s1.Equal(10) {
 		t.Fatal("Stack should not have been equal to an int")
 	}
+	v := vector.New(1, 2, 3)
+	if s1.Equal(v) || v.Equal(s1) {
+		t.Fatal("Stack should not have been equal to a vector")
+	}
+	if !s1.Equal(From(v)) {
+		t.Fatal("Stack should equal the stack made from its vector")
+	}
+}
+
+func TestFind(t *testing.T) {
+	m := hashmap.New(1, 2)
+	s := New(m, 2, 3)
+	if v, ok := s.Find(hashmap.New(1, 2)); !ok || v != m {
+		t.Fatal("expected to find an equal map")
+	}
+	if v, ok := s.AsTransient().Find(hashmap.New(1, 2)); !ok || v != m {
+		t.Fatal("expected to find an equal map in the transient")
+	}
+	if _, ok := s.Find(4); ok {
+		t.Fatal("didn't expect to find 4")
+	}
+}
+
+func TestPeekPopN(t *testing.T) {
+	parameters := gopter.DefaultTestParameters()
+	properties := gopter.NewProperties(parameters)
+	properties.Property("Peek(i) is the i-th element from the top",
+		prop.ForAll(
+			func(n int) bool {
+				s := Empty()
+				for i := 0; i < n; i++ {
+					s = s.Push(i)
+				}
+				ts := s.AsTransient()
+				for i := 0; i < n; i++ {
+					if s.Peek(i) != n-1-i || ts.Peek(i) != n-1-i {
+						return false
+					}
+				}
+				return true
+			},
+			gen.IntRange(0, 100),
+		))
+	properties.Property("PopN(k) is k calls to Pop",
+		prop.ForAll(
+			func(n, k int) bool {
+				s := Empty()
+				for i := 0; i < n; i++ {
+					s = s.Push(i)
+				}
+				want := s
+				for i := 0; i < k && i < n; i++ {
+					want = want.Pop()
+				}
+				return s.PopN(k).Equal(want) && s.Drop(k).Equal(want) &&
+					s.AsTransient().PopN(k).AsPersistent().Equal(want) &&
+					s.Length() == n
+			},
+			gen.IntRange(0, 100),
+			gen.IntRange(0, 120),
+		))
+	properties.TestingRun(t)
+}
+
+func TestPeekOutOfBounds(t *testing.T) {
+	defer func() {
+		if r := recover(); r != errOutOfBounds {
+			t.Fatal("expected errOutOfBounds got", r)
+		}
+	}()
+	New(1, 2).Peek(2)
 }
 
 func TestStackLength(t *testing.T) {
@@ -539,3 +612,23 @@ func TestReduce(t *testing.T) {
 		}
 	})
 }
+
+func TestTransformForms(t *testing.T) {
+	s := New("a", "b", "c").Transform(
+		func(t *TStack) *TStack { return t.PopN(2) },
+		func(t *TStack) { t.Push("x").Push("y") },
+		func(t *TStack) *TStack {
+			t.Pop()
+			return nil
+		},
+	)
+	if s.Length() != 2 || s.Top() != "x" || s.Peek(1) != "a" {
+		t.Fatalf("unexpected stack %s", s)
+	}
+	defer func() {
+		if r := recover(); r != errTransformSig {
+			t.Fatalf("expected errTransformSig, got %v", r)
+		}
+	}()
+	s.Transform(func(t *TStack) interface{} { return t.Top() })
+}
@@ -0,0 +1,53 @@
+package treemap
+
+import (
+	"encoding/gob"
+
+	"jsouthworth.net/go/immutable/internal/gobenc"
+)
+
+func init() {
+	gob.Register(&Map{})
+}
+
+// GobEncode encodes the map for encoding/gob as its length followed
+// by the key and value of each entry in sorted key order. Keys and
+// values held in interfaces must be of types registered with
+// gob.Register. The options of the map are not encoded.
+func (m *Map) GobEncode() ([]byte, error) {
+	return gobenc.EncodePairs(func(yield func(key, value interface{}) bool) {
+		m.Range(func(key, value interface{}) bool {
+			return yield(key, value)
+		})
+	})
+}
+
+// GobDecode replaces the contents of the map with data produced by
+// GobEncode. Like UnmarshalJSON it overwrites the map in place and
+// keeps the options of the map if it was made with any.
+func (m *Map) GobDecode(data []byte) error {
+	if m == Empty() {
+		return errDecodeEmpty
+	}
+	t := m.emptyLike().AsTransient()
+	err := gobenc.DecodePairs(data, func(key, value interface{}) {
+		t = t.Assoc(key, value)
+	})
+	if err != nil {
+		return err
+	}
+	*m = *t.AsPersistent()
+	return nil
+}
+
+// MarshalBinary implements encoding.BinaryMarshaler with the
+// encoding used by GobEncode.
+func (m *Map) MarshalBinary() ([]byte, error) {
+	return m.GobEncode()
+}
+
+// UnmarshalBinary implements encoding.BinaryUnmarshaler. It is the
+// same as GobDecode.
+func (m *Map) UnmarshalBinary(data []byte) error {
+	return m.GobDecode(data)
+}
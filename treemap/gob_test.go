@@ -0,0 +1,36 @@
+package treemap
+
+import (
+	"bytes"
+	"encoding/gob"
+	"testing"
+)
+
+func TestGobOptionsNotEncoded(t *testing.T) {
+	reverse := func(a, b interface{}) int {
+		return -defaultCompare(entry{key: a}, entry{key: b})
+	}
+	in := Empty(Compare(reverse)).Assoc(1, "a").Assoc(3, "c").Assoc(2, "b")
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(in); err != nil {
+		t.Fatal(err)
+	}
+	var out *Map
+	if err := gob.NewDecoder(&buf).Decode(&out); err != nil {
+		t.Fatal(err)
+	}
+	if out.String() != New(1, "a", 2, "b", 3, "c").String() {
+		t.Fatal("expected a fresh map to use the default comparison", out)
+	}
+	b, err := in.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	out = Empty(Compare(reverse)).Assoc(0, "x")
+	if err := out.UnmarshalBinary(b); err != nil || out.String() != in.String() {
+		t.Fatal("expected the decoded map to keep its comparison", out, err)
+	}
+	if err := Empty().UnmarshalBinary(b); err != errDecodeEmpty {
+		t.Fatal("expected decoding into Empty() to fail", err)
+	}
+}
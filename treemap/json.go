@@ -0,0 +1,57 @@
+package treemap
+
+import (
+	"errors"
+
+	"jsouthworth.net/go/immutable/internal/jsonenc"
+)
+
+var errDecodeEmpty = errors.New("can not decode into the shared empty map")
+
+// MarshalJSON encodes the map in sorted key order. A map whose keys
+// are all strings is encoded as a JSON object. Any other map is
+// encoded as a JSON array of [key, value] pairs, for example
+// [[1,"a"],[2,"b"]].
+func (m *Map) MarshalJSON() ([]byte, error) {
+	return jsonenc.EncodeMap(func(yield func(key, value interface{}) bool) {
+		m.Range(func(key, value interface{}) bool {
+			return yield(key, value)
+		})
+	}, false)
+}
+
+// UnmarshalJSON replaces the contents of the map with the entries of
+// a JSON object or of a JSON array of [key, value] pairs. Object keys
+// are decoded as strings; all other keys and values are decoded as
+// encoding/json decodes into an empty interface, so the comparison
+// function of the map must accept them. If the map was made with
+// options the decoded map keeps them, otherwise the default options
+// are used. Decoding null leaves the map unchanged.
+//
+// UnmarshalJSON overwrites the map in place, so it must only be used
+// on a map that is not shared, such as the one encoding/json
+// allocates when decoding into a nil *Map.
+func (m *Map) UnmarshalJSON(data []byte) error {
+	return m.DecodeJSON(data, nil)
+}
+
+// DecodeJSON is UnmarshalJSON with each value, and each key of the
+// pair form, decoded by decode instead. A nil decode behaves like
+// UnmarshalJSON.
+func (m *Map) DecodeJSON(data []byte, decode func(data []byte) (interface{}, error)) error {
+	if m == Empty() {
+		return errDecodeEmpty
+	}
+	if jsonenc.IsNull(data) {
+		return nil
+	}
+	t := m.emptyLike().AsTransient()
+	err := jsonenc.DecodeMap(data, decode, func(key, value interface{}) {
+		t = t.Assoc(key, value)
+	})
+	if err != nil {
+		return err
+	}
+	*m = *t.AsPersistent()
+	return nil
+}
@@ -0,0 +1,45 @@
+package treemap
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestJSON(t *testing.T) {
+	tests := []struct {
+		m    *Map
+		want string
+	}{
+		{Empty(), `{}`},
+		{New("b", 2, "a", 1), `{"a":1,"b":2}`},
+		{New(2, "b", 1, "a"), `[[1,"a"],[2,"b"]]`},
+	}
+	for _, test := range tests {
+		b, err := json.Marshal(test.m)
+		if err != nil || string(b) != test.want {
+			t.Fatal("unexpected encoding", test.m, string(b), err)
+		}
+		var out *Map
+		if err := json.Unmarshal(b, &out); err != nil ||
+			out.Length() != test.m.Length() {
+			t.Fatal("unexpected decoding", out, err)
+		}
+	}
+	if err := Empty().UnmarshalJSON([]byte(`{"a":1}`)); err != errDecodeEmpty {
+		t.Fatal("expected decoding into Empty() to fail", err)
+	}
+}
+
+func TestJSONKeepsOptions(t *testing.T) {
+	reverse := func(a, b interface{}) int {
+		return -defaultCompare(entry{key: a}, entry{key: b})
+	}
+	out := Empty(Compare(reverse)).Assoc(0.0, "x")
+	if err := json.Unmarshal([]byte(`[[1,"a"],[3,"c"],[2,"b"]]`), out); err != nil {
+		t.Fatal(err)
+	}
+	b, _ := json.Marshal(out)
+	if string(b) != `[[3,"c"],[2,"b"],[1,"a"]]` {
+		t.Fatal("expected the decoded map to keep its comparison", string(b))
+	}
+}
@@ -12,8 +12,17 @@ import (
 )
 
 var errOddElements = errors.New("must supply an even number elements")
+var errOutOfBounds = errors.New("out of bounds")
+var errOverlap = errors.New("joined maps must not overlap")
+var errCompareMismatch = errors.New("joined maps must share an ordering")
+var errNodeSizeMismatch = errors.New("joined maps must use the same node size")
+
+// ErrUnsorted is returned by FromSorted when the entries are not in
+// strictly increasing key order.
+var ErrUnsorted = errors.New("entries are not in strictly increasing key order")
 var errRangeSig = errors.New("Range requires a function: func(k kT, v vT) bool or func(k kT, v vT)")
 var errReduceSig = errors.New("Reduce requires a function: func(init iT, k kT, v vT) oT or func(init iT, e Entry) oT")
+var errTransformSig = errors.New("Transform requires functions: func(t *TMap) *TMap or func(t *TMap)")
 
 // Entry is a map entry. Each entry consists of a key and value.
 type Entry interface {
@@ -49,6 +58,7 @@ func (e entry) String() string {
 type Map struct {
 	root *btree.BTree
 	eq   eqFunc
+	cmp  cmpFunc
 }
 
 type cmpFunc func(k1, k2 interface{}) int
@@ -68,17 +78,23 @@ func defaultEqual(a, b interface{}) bool {
 		dyn.Equal(ae.value, be.value)
 }
 
+var defaultOrder = btree.Compare(defaultCompare)
+
 var empty = Map{
 	root: btree.Empty(
-		btree.Compare(defaultCompare),
+		defaultOrder,
 		btree.Equal(defaultEqual),
 	),
-	eq: dyn.Equal,
+	eq:  dyn.Equal,
+	cmp: dyn.Compare,
 }
 
 type mapOptions struct {
-	compare cmpFunc
-	equal   eqFunc
+	compare      cmpFunc
+	order        btree.Option
+	equal        eqFunc
+	assumeSorted bool
+	nodeSize     int
 }
 
 // Option is a type that allows changes to pluggable parts of the
@@ -88,10 +104,32 @@ type Option func(*mapOptions)
 // Compare is an option to the Empty function that will allow
 // one to specify a different comparison operator instead
 // of the default which is from the dyn library. This is used
-// for keys.
+// for keys. Maps made with the same Option value returned by Compare
+// share an ordering; only maps that share an ordering may be joined
+// with Join.
 func Compare(cmp func(k1, k2 interface{}) int) Option {
+	order := btree.Compare(func(a, b interface{}) int {
+		ae := a.(entry)
+		be := b.(entry)
+		return cmp(ae.key, be.key)
+	})
+	return func(o *mapOptions) {
+		o.compare = cmp
+		o.order = order
+	}
+}
+
+// CompareOf is an option to the Empty function that gives the new map
+// the ordering of m. Unlike supplying m.Comparator() to Compare, the
+// maps share an ordering and so may be joined with Join.
+func CompareOf(m *Map) Option {
+	if m.root == nil {
+		m = Empty()
+	}
+	cmp, order := m.cmp, m.root.Ordering()
 	return func(o *mapOptions) {
 		o.compare = cmp
+		o.order = order
 	}
 }
 
@@ -105,6 +143,26 @@ func Equal(eq func(v1, v2 interface{}) bool) Option {
 	}
 }
 
+// NodeSize is an option to the Empty function that sets the maximum
+// number of entries held by each node of the underlying B-tree.
+// Wider nodes suit keys that are cheap to compare while narrower
+// nodes suit keys that are expensive to compare. The size must be
+// between 16 and 1024, Empty will panic otherwise. The default is 64.
+func NodeSize(size int) Option {
+	return func(o *mapOptions) {
+		o.nodeSize = size
+	}
+}
+
+// AssumeSorted is an option to the FromSorted function that skips
+// checking the order of the entries. Supplying entries out of order
+// with this option results in a map that behaves unpredictably.
+func AssumeSorted() Option {
+	return func(o *mapOptions) {
+		o.assumeSorted = true
+	}
+}
+
 // Empty returns a new empty persistent map, one may supply options
 // for the map by using one of the option generating functions and
 // providing that to Empty.
@@ -114,18 +172,15 @@ func Empty(options ...Option) *Map {
 	}
 
 	opts := mapOptions{
-		compare: dyn.Compare,
-		equal:   dyn.Equal,
+		compare:  dyn.Compare,
+		order:    defaultOrder,
+		equal:    dyn.Equal,
+		nodeSize: btree.DefaultNodeSize,
 	}
 	for _, opt := range options {
 		opt(&opts)
 	}
 
-	cmp := func(a, b interface{}) int {
-		ae := a.(entry)
-		be := b.(entry)
-		return opts.compare(ae.key, be.key)
-	}
 	eq := func(a, b interface{}) bool {
 		ae, aok := a.(entry)
 		be, bok := b.(entry)
@@ -136,10 +191,25 @@ func Empty(options ...Option) *Map {
 
 	return &Map{
 		root: btree.Empty(
-			btree.Compare(cmp),
+			opts.order,
 			btree.Equal(eq),
+			btree.NodeSize(opts.nodeSize),
 		),
-		eq: opts.equal,
+		eq:  opts.equal,
+		cmp: opts.compare,
+	}
+}
+
+// emptyLike returns an empty map with the options of m, or with the
+// default options if m is the zero Map.
+func (m *Map) emptyLike() *Map {
+	if m.root == nil {
+		return Empty()
+	}
+	return &Map{
+		root: m.root.Clear(),
+		eq:   m.eq,
+		cmp:  m.cmp,
 	}
 }
 
@@ -204,6 +274,32 @@ func From(value interface{}, options ...Option) *Map {
 	}
 }
 
+// FromSorted builds a map from entries that are in strictly
+// increasing key order. The map is built bottom-up in linear time
+// with each node filled to capacity, which is considerably faster
+// than associating the entries one at a time. ErrUnsorted is returned
+// if the entries are out of order unless the AssumeSorted option is
+// supplied.
+func FromSorted(entries []Entry, options ...Option) (*Map, error) {
+	var opts mapOptions
+	for _, opt := range options {
+		opt(&opts)
+	}
+	out := Empty(options...)
+	keys := make([]interface{}, len(entries))
+	for i, e := range entries {
+		keys[i] = entry{key: e.Key(), value: e.Value()}
+	}
+	if !opts.assumeSorted && !out.root.IsSorted(keys) {
+		return nil, ErrUnsorted
+	}
+	return &Map{
+		root: out.root.FromSorted(keys),
+		eq:   out.eq,
+		cmp:  out.cmp,
+	}, nil
+}
+
 func mapFromReflection(value interface{}, options ...Option) *Map {
 	v := reflect.ValueOf(value)
 	switch v.Kind() {
@@ -248,6 +344,60 @@ func (m *Map) EntryAt(key interface{}) Entry {
 	return ent
 }
 
+// Comparator returns the function used to order the keys of the
+// map. Supplying it to Empty with the Compare option creates a map
+// whose keys are sorted the same way, but as Compare makes a new
+// ordering each time it is called the maps can not be joined. Use
+// CompareOf to make a map that shares the ordering of m.
+func (m *Map) Comparator() func(k1, k2 interface{}) int {
+	return m.cmp
+}
+
+// Rank returns the number of keys in the map that are less than key.
+// If key is in the map this is its index in sorted order.
+func (m *Map) Rank(key interface{}) int {
+	return m.root.Rank(entry{key: key})
+}
+
+// Nth returns the entry at index i of the map in sorted key order.
+// Nth will panic if i is out of bounds.
+func (m *Map) Nth(i int) Entry {
+	if i < 0 || i >= m.root.Length() {
+		panic(errOutOfBounds)
+	}
+	return m.root.Nth(i).(entry)
+}
+
+// SplitAt divides the map into the entries whose keys are less than
+// key and the entries whose keys are not. Both maps share structure
+// with m and are produced in logarithmic time.
+func (m *Map) SplitAt(key interface{}) (*Map, *Map) {
+	below, above := m.root.Split(entry{key: key})
+	return &Map{root: below, eq: m.eq, cmp: m.cmp},
+		&Map{root: above, eq: m.eq, cmp: m.cmp}
+}
+
+// Join concatenates two maps in logarithmic time. Every key in left
+// must be less than every key in right, the maps must share an
+// ordering, see Compare, and they must use the same node size. Join
+// will panic otherwise.
+func Join(left, right *Map) *Map {
+	if !left.root.SameOrder(right.root) {
+		panic(errCompareMismatch)
+	}
+	if !left.root.SameNodeSize(right.root) {
+		panic(errNodeSizeMismatch)
+	}
+	if !left.root.Before(right.root) {
+		panic(errOverlap)
+	}
+	return &Map{
+		root: btree.Join(left.root, right.root),
+		eq:   left.eq,
+		cmp:  left.cmp,
+	}
+}
+
 // Contains will test if the key exists in the map.
 func (m *Map) Contains(key interface{}) bool {
 	return m.root.Contains(entry{key: key})
@@ -278,6 +428,7 @@ func (m *Map) Assoc(key, value interface{}) *Map {
 		return &Map{
 			root: root,
 			eq:   m.eq,
+			cmp:  m.cmp,
 		}
 	}
 }
@@ -297,6 +448,7 @@ func (m *Map) Delete(key interface{}) *Map {
 	return &Map{
 		root: root,
 		eq:   m.eq,
+		cmp:  m.cmp,
 	}
 }
 
@@ -454,6 +606,14 @@ func (m *Map) Iterator() Iterator {
 	}
 }
 
+// ReverseIterator provides a mutable iterator over the map that
+// visits the entries from the largest key to the smallest.
+func (m *Map) ReverseIterator() Iterator {
+	return Iterator{
+		impl: m.root.ReverseIterator(),
+	}
+}
+
 // Seq returns a seralized sequence of Entry
 // corresponding to the maps entries.
 func (m *Map) Seq() seq.Sequence {
@@ -543,12 +703,48 @@ func (i *Iterator) HasNext() bool {
 	return i.impl.HasNext()
 }
 
+// Prev provides the previous key value pair and decrements the cursor.
+func (i *Iterator) Prev() (interface{}, interface{}) {
+	out := i.impl.Prev()
+	ent := out.(entry)
+	return ent.key, ent.value
+}
+
+// PrevEntry provides the previous entry and decrements the cursor.
+func (i *Iterator) PrevEntry() Entry {
+	out := i.impl.Prev()
+	ent := out.(entry)
+	return ent
+}
+
+// HasPrev is true when there are elements before the cursor.
+func (i *Iterator) HasPrev() bool {
+	return i.impl.HasPrev()
+}
+
+// Seek moves the cursor so that the next call to Next returns the
+// entry with the smallest key not less than key. For reverse
+// iterators it is the entry with the largest key not greater than
+// key.
+func (i *Iterator) Seek(key interface{}) {
+	i.impl.Seek(entry{key: key})
+}
+
+// Clone returns a copy of the iterator at the same position. The
+// copy advances independently of the original.
+func (i *Iterator) Clone() Iterator {
+	return Iterator{
+		impl: i.impl.Clone(),
+	}
+}
+
 // AsTransient will return a transient map that shares
 // structure with the persistent map.
 func (m *Map) AsTransient() *TMap {
 	return &TMap{
 		root: m.root.AsTransient(),
 		eq:   m.eq,
+		cmp:  m.cmp,
 		orig: m,
 	}
 }
@@ -562,10 +758,22 @@ func (m *Map) MakeTransient() interface{} {
 // on the persistent map. It does this by making a transient
 // map and calling each action on it, then converting it back
 // to a persistent map.
-func (m *Map) Transform(actions ...func(*TMap)) *Map {
+// Each action may be a func(*TMap) *TMap, whose result is passed to
+// the following action unless it is nil, or a func(*TMap).
+// Transform will panic if given any other type.
+func (m *Map) Transform(actions ...interface{}) *Map {
 	out := m.AsTransient()
 	for _, action := range actions {
-		action(out)
+		switch fn := action.(type) {
+		case func(*TMap) *TMap:
+			if next := fn(out); next != nil {
+				out = next
+			}
+		case func(*TMap):
+			fn(out)
+		default:
+			panic(errTransformSig)
+		}
 	}
 	return out.AsPersistent()
 }
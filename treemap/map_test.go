@@ -10,6 +10,7 @@ import (
 	"github.com/leanovate/gopter/gen"
 	"github.com/leanovate/gopter/prop"
 	"jsouthworth.net/go/dyn"
+	"jsouthworth.net/go/immutable/compare"
 )
 
 func assert(t *testing.T, b bool, msg string) {
@@ -1115,3 +1116,202 @@ func TestTransientString(t *testing.T) {
 	))
 	properties.TestingRun(t)
 }
+
+func TestRankNth(t *testing.T) {
+	m := makeIntMap(1000)
+	parameters := gopter.DefaultTestParameters()
+	properties := gopter.NewProperties(parameters)
+	properties.Property("Nth(Rank(k)).Key() == k", prop.ForAll(
+		func(k int) bool {
+			e := m.Nth(m.Rank(k))
+			return e.Key() == k && e.Value() == k*k
+		},
+		gen.IntRange(0, 999),
+	))
+	properties.Property("Rank of a missing key counts the smaller keys", prop.ForAll(
+		func(k int) bool {
+			return m.Delete(k).Rank(k) == k
+		},
+		gen.IntRange(0, 999),
+	))
+	properties.TestingRun(t)
+}
+
+func TestNthOutOfBounds(t *testing.T) {
+	defer func() {
+		if r := recover(); r != errOutOfBounds {
+			t.Fatalf("expected errOutOfBounds, got %v", r)
+		}
+	}()
+	makeIntMap(10).Nth(10)
+}
+
+func TestFromSorted(t *testing.T) {
+	entries := make([]Entry, 1000)
+	for i := range entries {
+		entries[i] = EntryNew(i, i*i)
+	}
+	m, err := FromSorted(entries)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !m.Equal(makeIntMap(1000)) {
+		t.Fatal("FromSorted did not produce the expected map")
+	}
+	entries[10], entries[11] = entries[11], entries[10]
+	if _, err := FromSorted(entries); err != ErrUnsorted {
+		t.Fatalf("expected ErrUnsorted, got %v", err)
+	}
+	entries[10] = entries[11]
+	if _, err := FromSorted(entries); err != ErrUnsorted {
+		t.Fatalf("expected ErrUnsorted for duplicate keys, got %v", err)
+	}
+	m, err = FromSorted(entries[:10], AssumeSorted())
+	if err != nil || m.Length() != 10 {
+		t.Fatalf("unexpected result with AssumeSorted %v %v", m, err)
+	}
+}
+
+func TestSplitAtJoin(t *testing.T) {
+	m := makeIntMap(1000)
+	parameters := gopter.DefaultTestParameters()
+	properties := gopter.NewProperties(parameters)
+	properties.Property("SplitAt(k) divides the keys at k", prop.ForAll(
+		func(k int) bool {
+			below, above := m.SplitAt(k)
+			ok := below.Length()+above.Length() == 1000
+			below.Range(func(key, value interface{}) {
+				ok = ok && key.(int) < k && value == m.At(key)
+			})
+			above.Range(func(key, value interface{}) {
+				ok = ok && key.(int) >= k && value == m.At(key)
+			})
+			return ok
+		},
+		gen.IntRange(-10, 1010),
+	))
+	properties.Property("Join(SplitAt(k)) == m", prop.ForAll(
+		func(k int) bool {
+			return Join(m.SplitAt(k)).Equal(m)
+		},
+		gen.IntRange(-10, 1010),
+	))
+	properties.TestingRun(t)
+}
+
+func TestJoinOverlap(t *testing.T) {
+	defer func() {
+		if r := recover(); r != errOverlap {
+			t.Fatalf("expected errOverlap, got %v", r)
+		}
+	}()
+	Join(New(1, 1, 5, 5), New(3, 3))
+}
+
+func TestJoinMismatch(t *testing.T) {
+	// Reversed closures share their code but not their order.
+	byKey := func(a, b interface{}) int { return dyn.Compare(a, b) }
+	byNeg := func(a, b interface{}) int { return dyn.Compare(b, a) }
+	tests := []struct {
+		name        string
+		left, right *Map
+		err         error
+	}{
+		{
+			"ordering",
+			Empty(Compare(compare.Reverse(byKey))).Assoc(1, 1),
+			Empty(Compare(compare.Reverse(byNeg))).Assoc(2, 2),
+			errCompareMismatch,
+		},
+		{
+			"node size",
+			Empty(NodeSize(16)).Assoc(1, 1),
+			Empty(NodeSize(32)).Assoc(2, 2),
+			errNodeSizeMismatch,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			defer func() {
+				if r := recover(); r != test.err {
+					t.Fatal("expected", test.err, "got", r)
+				}
+			}()
+			Join(test.left, test.right)
+		})
+	}
+
+	reverse := Compare(compare.Reverse(byKey))
+	m := Join(Empty(reverse).Assoc(2, 2), Empty(reverse).Assoc(1, 1))
+	if m.Length() != 2 || m.Nth(0).Key() != 2 {
+		t.Fatal("expected maps made with one option to join", m)
+	}
+}
+
+func TestTransformForms(t *testing.T) {
+	m := New("c", 3, "a", 1).Transform(
+		func(t *TMap) { t.Delete("c").Assoc("b", 2) },
+		func(t *TMap) *TMap { return t.Assoc("a", 0) },
+		func(t *TMap) *TMap {
+			t.Delete("missing").Assoc("d", 4)
+			return nil
+		},
+	)
+	if m.String() != "{ [a 0] [b 2] [d 4] }" {
+		t.Fatalf("unexpected map %s", m)
+	}
+	defer func() {
+		if r := recover(); r != errTransformSig {
+			t.Fatalf("expected errTransformSig, got %v", r)
+		}
+	}()
+	m.Transform(func(t *TMap) Iterator { return t.Iterator() })
+}
+
+func TestComparator(t *testing.T) {
+	reverse := func(a, b interface{}) int {
+		return dyn.Compare(b, a)
+	}
+	m := New(1, 1, 2, 2, 3, 3)
+	if m.Comparator()(1, 2) >= 0 {
+		t.Fatal("default comparator is not ascending")
+	}
+	r := Empty(Compare(reverse)).Transform(func(t *TMap) {
+		t.Assoc(1, 1)
+		t.Assoc(4, 4)
+	})
+	derived := Empty(Compare(r.Comparator())).Assoc(1, 1).Assoc(5, 5)
+	if derived.String() != "{ [5 5] [1 1] }" {
+		t.Fatalf("derived map did not keep the comparator %s", derived)
+	}
+	below, _ := r.SplitAt(2)
+	if below.Comparator()(1, 2) <= 0 {
+		t.Fatal("SplitAt did not keep the comparator")
+	}
+	joined := Join(r, Empty(CompareOf(r)).Assoc(0, 0))
+	if joined.String() != "{ [4 4] [1 1] [0 0] }" {
+		t.Fatal("maps made with CompareOf could not be joined", joined)
+	}
+}
+
+func TestNodeSize(t *testing.T) {
+	m := Empty(NodeSize(16)).AsTransient()
+	for i := 0; i < 10000; i++ {
+		m = m.Assoc(i, i*i)
+	}
+	p := m.AsPersistent()
+	for i := 0; i < 10000; i++ {
+		if p.At(i) != i*i {
+			t.Fatalf("expected %d at %d, got %v", i*i, i, p.At(i))
+		}
+	}
+	if p.Nth(5000).Key() != 5000 {
+		t.Fatalf("expected key 5000, got %v", p.Nth(5000).Key())
+	}
+	defer func() {
+		if r := recover(); r == nil {
+			t.Fatal("expected invalid node size to panic")
+		}
+	}()
+	Empty(NodeSize(8))
+}
@@ -16,16 +16,17 @@ func sequenceNew(iter btree.Iterator) *sequence {
 }
 
 func (s *sequence) First() interface{} {
-	return s.iter.Next()
+	iter := s.iter.Clone()
+	return iter.Next()
 }
 
 func (s *sequence) Next() seq.Sequence {
-	new := &(*s)
-	hasNext := new.iter.HasNext()
-	if !hasNext {
+	iter := s.iter.Clone()
+	iter.Next()
+	if !iter.HasNext() {
 		return nil
 	}
-	return new
+	return sequenceNew(iter)
 }
 
 func (s *sequence) String() string {
@@ -17,6 +17,7 @@ import (
 type TMap struct {
 	root *btree.TBTree
 	eq   eqFunc
+	cmp  cmpFunc
 
 	orig *Map
 }
@@ -67,6 +68,7 @@ func (m *TMap) AsPersistent() *Map {
 	return &Map{
 		root: newRoot,
 		eq:   m.eq,
+		cmp:  m.cmp,
 	}
 }
 
@@ -248,3 +250,11 @@ func (m *TMap) Iterator() Iterator {
 		impl: m.root.Iterator(),
 	}
 }
+
+// ReverseIterator provides a mutable iterator over the map that
+// visits the entries from the largest key to the smallest.
+func (m *TMap) ReverseIterator() Iterator {
+	return Iterator{
+		impl: m.root.ReverseIterator(),
+	}
+}
@@ -0,0 +1,238 @@
+package treemap
+
+import (
+	"fmt"
+	"strings"
+
+	"jsouthworth.net/go/immutable/internal/btree"
+	"jsouthworth.net/go/seq"
+)
+
+// View is a lazy, read-only window onto the entries of a Map whose
+// keys lie between two bounds. Creating a view copies nothing;
+// iteration over a view seeks directly to the lower bound and stops
+// at the upper bound.
+type View struct {
+	root   *btree.BTree
+	eq     eqFunc
+	cmp    cmpFunc
+	lo, hi btree.Bound
+}
+
+// Subrange returns a view of the entries whose keys lie between from
+// and to. The inclusive flags determine whether from and to
+// themselves are part of the view.
+func (m *Map) Subrange(
+	from, to interface{},
+	fromInclusive, toInclusive bool,
+) *View {
+	return &View{
+		root: m.root,
+		eq:   m.eq,
+		cmp:  m.cmp,
+		lo:   makeBound(from, fromInclusive),
+		hi:   makeBound(to, toInclusive),
+	}
+}
+
+// HeadMap returns a view of the entries whose keys are strictly less
+// than to.
+func (m *Map) HeadMap(to interface{}) *View {
+	return &View{
+		root: m.root,
+		eq:   m.eq,
+		cmp:  m.cmp,
+		lo:   btree.Unbounded(),
+		hi:   btree.Exclusive(entry{key: to}),
+	}
+}
+
+// TailMap returns a view of the entries whose keys are greater than
+// or equal to from.
+func (m *Map) TailMap(from interface{}) *View {
+	return &View{
+		root: m.root,
+		eq:   m.eq,
+		cmp:  m.cmp,
+		lo:   btree.Inclusive(entry{key: from}),
+		hi:   btree.Unbounded(),
+	}
+}
+
+func makeBound(key interface{}, inclusive bool) btree.Bound {
+	if inclusive {
+		return btree.Inclusive(entry{key: key})
+	}
+	return btree.Exclusive(entry{key: key})
+}
+
+func (v *View) contains(key interface{}) bool {
+	return v.root.InRange(entry{key: key}, v.lo, v.hi)
+}
+
+// At returns the value associated with the key.
+// If one is not found or the key is outside of the view, nil is returned.
+func (v *View) At(key interface{}) interface{} {
+	value, _ := v.Find(key)
+	return value
+}
+
+// EntryAt returns the entry (key, value pair) of the key.
+// If one is not found or the key is outside of the view, nil is returned.
+func (v *View) EntryAt(key interface{}) Entry {
+	if !v.contains(key) {
+		return nil
+	}
+	out, ok := v.root.Find(entry{key: key})
+	if !ok {
+		return nil
+	}
+	return out.(entry)
+}
+
+// Contains will test if the key exists in the view.
+func (v *View) Contains(key interface{}) bool {
+	return v.contains(key) && v.root.Contains(entry{key: key})
+}
+
+// Find will return the value for a key if it exists in the view and
+// whether the key exists in the view.
+func (v *View) Find(key interface{}) (value interface{}, exists bool) {
+	if !v.contains(key) {
+		return nil, false
+	}
+	out, ok := v.root.Find(entry{key: key})
+	if !ok {
+		return nil, false
+	}
+	return out.(entry).value, true
+}
+
+// Length returns the number of entries in the view.
+func (v *View) Length() int {
+	return v.root.CountRange(v.lo, v.hi)
+}
+
+// Range will loop over the entries in the View and call 'do' on each
+// entry. The 'do' function may be of any of the types accepted by
+// Map.Range.
+func (v *View) Range(do interface{}) {
+	// NOTE: Update other functions using the same pattern
+	//       when modifying the below.
+	//       This code is inlined to avoid heap allocation of
+	//       the closure.
+	var f func(e Entry) bool
+	switch fn := do.(type) {
+	case func(key, value interface{}) bool:
+		f = func(entry Entry) bool {
+			return fn(entry.Key(), entry.Value())
+		}
+	case func(key, value interface{}):
+		f = func(entry Entry) bool {
+			fn(entry.Key(), entry.Value())
+			return true
+		}
+	case func(e Entry) bool:
+		f = fn
+	case func(e Entry):
+		f = func(entry Entry) bool {
+			fn(entry)
+			return true
+		}
+	default:
+		f = genRangeFunc(do)
+	}
+
+	iter := v.Iterator()
+	var cont = true
+	for iter.HasNext() && cont {
+		entry := iter.NextEntry()
+		cont = f(entry)
+	}
+}
+
+// Reduce is a fast mechanism for reducing a View. Reduce takes the
+// same function types as Map.Reduce.
+func (v *View) Reduce(fn interface{}, init interface{}) interface{} {
+	// NOTE: Update other functions using the same pattern
+	//       when modifying the below.
+	//       This code is inlined to avoid heap allocation of
+	//       the closure.
+	var rFn func(interface{}, Entry) interface{}
+	switch f := fn.(type) {
+	case func(interface{}, Entry) interface{}:
+		rFn = f
+	case func(interface{}, interface{}) interface{}:
+		rFn = func(init interface{}, entry Entry) interface{} {
+			return f(init, entry)
+		}
+	case func(interface{}, interface{}, interface{}) interface{}:
+		rFn = func(init interface{}, entry Entry) interface{} {
+			return f(init, entry.Key(), entry.Value())
+		}
+	default:
+		rFn = genReduceFunc(fn)
+	}
+	res := init
+	iter := v.Iterator()
+	for iter.HasNext() {
+		entry := iter.NextEntry()
+		res = rFn(res, entry)
+	}
+	return res
+}
+
+// Iterator provides a mutable iterator over the view. The iterator
+// starts at the first entry in the view and stops after the last.
+func (v *View) Iterator() Iterator {
+	return Iterator{
+		impl: v.root.IteratorRange(v.lo, v.hi),
+	}
+}
+
+// ReverseIterator provides a mutable iterator over the view that
+// starts at the last entry in the view and stops after the first.
+func (v *View) ReverseIterator() Iterator {
+	return Iterator{
+		impl: v.root.ReverseIteratorRange(v.lo, v.hi),
+	}
+}
+
+// Seq returns a seralized sequence of Entry
+// corresponding to the view's entries.
+func (v *View) Seq() seq.Sequence {
+	iter := v.root.IteratorRange(v.lo, v.hi)
+	if !iter.HasNext() {
+		return nil
+	}
+	return sequenceNew(iter)
+}
+
+// String returns a string representation of the view.
+func (v *View) String() string {
+	var b strings.Builder
+	fmt.Fprint(&b, "{ ")
+	iter := v.Iterator()
+	for iter.HasNext() {
+		entry := iter.NextEntry()
+		fmt.Fprintf(&b, "%s ", entry)
+	}
+	fmt.Fprint(&b, "}")
+	return b.String()
+}
+
+// AsMap copies the entries of the view into a new persistent
+// map that uses the same comparison and equality functions as the
+// map the view was taken from.
+func (v *View) AsMap() *Map {
+	out := v.root.Clear().AsTransient()
+	iter := v.root.IteratorRange(v.lo, v.hi)
+	for iter.HasNext() {
+		out = out.Add(iter.Next())
+	}
+	return &Map{
+		root: out.AsPersistent(),
+		eq:   v.eq,
+		cmp:  v.cmp,
+	}
+}
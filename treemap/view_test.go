@@ -0,0 +1,173 @@
+package treemap
+
+import (
+	"testing"
+
+	"github.com/leanovate/gopter"
+	"github.com/leanovate/gopter/gen"
+	"github.com/leanovate/gopter/prop"
+)
+
+func makeIntMap(n int) *Map {
+	m := Empty().AsTransient()
+	for i := 0; i < n; i++ {
+		m = m.Assoc(i, i*i)
+	}
+	return m.AsPersistent()
+}
+
+func TestSubrange(t *testing.T) {
+	m := makeIntMap(1000)
+	parameters := gopter.DefaultTestParameters()
+	properties := gopter.NewProperties(parameters)
+	properties.Property("Subrange(a, b, true, false) has keys in [a, b)", prop.ForAll(
+		func(a, b int) bool {
+			v := m.Subrange(a, b, true, false)
+			expected := a
+			if expected < 0 {
+				expected = 0
+			}
+			ok := true
+			v.Range(func(k, val int) bool {
+				ok = k == expected && val == k*k && k < b
+				expected++
+				return ok
+			})
+			return ok && (expected >= b || expected == 1000)
+		},
+		gen.IntRange(-10, 1010),
+		gen.IntRange(-10, 1010),
+	))
+	properties.Property("Subrange(a, b, false, true) has keys in (a, b]", prop.ForAll(
+		func(a, b int) bool {
+			v := m.Subrange(a, b, false, true)
+			count := 0
+			for i := 0; i < 1000; i++ {
+				if i > a && i <= b {
+					count++
+				}
+			}
+			return v.Length() == count
+		},
+		gen.IntRange(-10, 1010),
+		gen.IntRange(-10, 1010),
+	))
+	properties.Property("view.Contains(k) respects bounds", prop.ForAll(
+		func(a, b, k int) bool {
+			v := m.Subrange(a, b, true, true)
+			inRange := k >= a && k <= b && k >= 0 && k < 1000
+			_, found := v.Find(k)
+			return v.Contains(k) == inRange && found == inRange &&
+				(v.EntryAt(k) != nil) == inRange
+		},
+		gen.IntRange(-10, 1010),
+		gen.IntRange(-10, 1010),
+		gen.IntRange(-10, 1010),
+	))
+	properties.Property("HeadMap(k) has keys < k", prop.ForAll(
+		func(k int) bool {
+			v := m.HeadMap(k)
+			return v.Reduce(func(res, key, _ int) int {
+				if key >= k {
+					return -1
+				}
+				return res + 1
+			}, 0) == v.Length()
+		},
+		gen.IntRange(-10, 1010),
+	))
+	properties.Property("TailMap(k) has keys >= k", prop.ForAll(
+		func(k int) bool {
+			v := m.TailMap(k)
+			return v.Reduce(func(res, key, _ int) int {
+				if key < k {
+					return -1
+				}
+				return res + 1
+			}, 0) == v.Length()
+		},
+		gen.IntRange(-10, 1010),
+	))
+	properties.Property("HeadMap(k).Length()+TailMap(k).Length()==m.Length()", prop.ForAll(
+		func(k int) bool {
+			return m.HeadMap(k).Length()+m.TailMap(k).Length() ==
+				m.Length()
+		},
+		gen.IntRange(-10, 1010),
+	))
+	properties.Property("view.AsMap() equals the filtered map", prop.ForAll(
+		func(a, b int) bool {
+			expected := Empty().AsTransient()
+			for i := a; i < b; i++ {
+				if i >= 0 && i < 1000 {
+					expected.Assoc(i, i*i)
+				}
+			}
+			return m.Subrange(a, b, true, false).AsMap().
+				Equal(expected.AsPersistent())
+		},
+		gen.IntRange(-10, 1010),
+		gen.IntRange(-10, 1010),
+	))
+	properties.TestingRun(t)
+}
+
+func TestViewSeq(t *testing.T) {
+	m := makeIntMap(10)
+	v := m.Subrange(3, 6, true, false)
+	if v.String() != "{ [3 9] [4 16] [5 25] }" {
+		t.Fatal("unexpected view string", v.String())
+	}
+	var keys []interface{}
+	for s := v.Seq(); s != nil; s = s.Next() {
+		keys = append(keys, s.First().(Entry).Key())
+	}
+	if len(keys) != 3 || keys[0] != 3 || keys[2] != 5 {
+		t.Fatal("unexpected view sequence", keys)
+	}
+	if m.Subrange(20, 30, true, true).Seq() != nil {
+		t.Fatal("expected empty view to have nil sequence")
+	}
+}
+
+func TestIteratorSeek(t *testing.T) {
+	m := makeIntMap(100)
+	iter := m.Iterator()
+	iter.Seek(50)
+	k, v := iter.Next()
+	if k != 50 || v != 2500 {
+		t.Fatalf("expected 50 after Seek(50), got %v=%v", k, v)
+	}
+	if e := iter.PrevEntry(); e.Key() != 50 {
+		t.Fatalf("expected Prev to return 50, got %v", e.Key())
+	}
+	if k, _ := iter.Prev(); k != 49 {
+		t.Fatalf("expected Prev to return 49, got %v", k)
+	}
+	rev := m.ReverseIterator()
+	var keys []interface{}
+	for rev.HasNext() {
+		k, _ := rev.Next()
+		keys = append(keys, k)
+	}
+	if len(keys) != 100 || keys[0] != 99 || keys[99] != 0 {
+		t.Fatalf("unexpected reverse iteration %v", keys)
+	}
+	view := m.Subrange(10, 20, true, false).ReverseIterator()
+	view.Seek(100)
+	if k, _ := view.Next(); k != 19 {
+		t.Fatalf("expected reverse view to clamp to 19, got %v", k)
+	}
+}
+
+func TestSeqPersistent(t *testing.T) {
+	s := makeIntMap(10).Seq()
+	if s.First().(Entry).Key() != s.First().(Entry).Key() {
+		t.Fatal("First is not stable")
+	}
+	next := s.Next()
+	if s.First().(Entry).Key() != 0 ||
+		next.First().(Entry).Key() != 1 {
+		t.Fatal("sequence was mutated by Next")
+	}
+}
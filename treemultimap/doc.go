@@ -0,0 +1,15 @@
+// Package treemultimap implements a sorted multimap on top of a
+// persistent B-tree.
+//
+// A multimap may associate many values with a single key. Entries are
+// ordered first by key and then by the order in which the values of
+// the key were put. Each distinct (key, value) pair is stored at most
+// once; putting a value equal, by dyn.Equal, to one the key already
+// has leaves the map unchanged. Keys must be comparable by the
+// supplied comparison function, which defaults to dyn.Compare. One may
+// implement Compare(other interface{}) int to override the default
+// comparable restrictions. Values need only support dyn.Equal unless
+// the map is made with ValueCompare, in which case the values of each
+// key are kept in sorted order instead and values comparing equal are
+// the same value.
+package treemultimap
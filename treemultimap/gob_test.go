@@ -0,0 +1,41 @@
+package treemultimap
+
+import (
+	"bytes"
+	"encoding/gob"
+	"strings"
+	"testing"
+)
+
+func TestGobKeepsOptions(t *testing.T) {
+	reverse := func(a, b interface{}) int {
+		return strings.Compare(b.(string), a.(string))
+	}
+	in := Empty(Compare(reverse), ValueCompare(reverse)).
+		Put("a", "x").Put("a", "y").Put("b", "z")
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(in); err != nil {
+		t.Fatal(err)
+	}
+	var out *Map
+	if err := gob.NewDecoder(&buf).Decode(&out); err != nil {
+		t.Fatal(err)
+	}
+	if out.String() != "{ [a y] [a x] [b z] }" {
+		t.Fatal("expected a fresh map to use the default comparisons", out)
+	}
+	b, err := in.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	out = Empty(Compare(reverse), ValueCompare(reverse)).Put("c", "w")
+	if err := out.UnmarshalBinary(b); err != nil || out.String() != in.String() {
+		t.Fatal("expected the decoded map to keep its comparisons", out, err)
+	}
+	if out.CountOf("a") != 2 || !out.ContainsEntry("a", "y") {
+		t.Fatal("expected every value of a key to be decoded", out)
+	}
+	if err := Empty().UnmarshalBinary(b); err != errDecodeEmpty {
+		t.Fatal("expected decoding into Empty() to fail", err)
+	}
+}
@@ -0,0 +1,559 @@
+package treemultimap // import "jsouthworth.net/go/immutable/treemultimap"
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"jsouthworth.net/go/dyn"
+	"jsouthworth.net/go/immutable/internal/btree"
+	"jsouthworth.net/go/seq"
+)
+
+var errOddElements = errors.New("must supply an even number elements")
+var errRangeSig = errors.New("Range requires a function: func(k kT, v vT) bool or func(k kT, v vT)")
+var errTransformSig = errors.New("Transform requires functions: func(t *TMap) *TMap or func(t *TMap)")
+
+// Entry is a multimap entry. Each entry consists of a key and value.
+type Entry interface {
+	Key() interface{}
+	Value() interface{}
+}
+
+// EntryNew returns an Entry
+func EntryNew(key, value interface{}) Entry {
+	return entry{key: key, value: value}
+}
+
+// entry is the element stored in the tree. Seq is the position of the
+// entry in the order the entries were put, which orders the values of
+// a key unless the map was made with ValueCompare. Entries with a
+// non-zero probe are never stored; they sort before (lowest) or after
+// (highest) every value of their key and are used to bound searches
+// over all of the values of a key.
+type entry struct {
+	key   interface{}
+	value interface{}
+	seq   uint64
+	probe int
+}
+
+const (
+	lowest  = -1
+	highest = 1
+)
+
+func (e entry) Key() interface{} {
+	return e.key
+}
+
+func (e entry) Value() interface{} {
+	return e.value
+}
+
+func (e entry) String() string {
+	return fmt.Sprintf("[%v %v]", e.key, e.value)
+}
+
+func lowerProbe(key interface{}) entry {
+	return entry{key: key, probe: lowest}
+}
+
+func upperProbe(key interface{}) entry {
+	return entry{key: key, probe: highest}
+}
+
+// Map is a persistent immutable multimap based on B-trees. Operations
+// on the map return a new map that shares much of the structure with
+// the original map.
+type Map struct {
+	root *btree.BTree
+	vcmp cmpFunc
+	seq  uint64
+}
+
+type cmpFunc func(a, b interface{}) int
+
+// makeCompare orders entries by key and then by value with vcmp, or
+// by the order they were put if vcmp is nil.
+func makeCompare(kcmp, vcmp cmpFunc) func(a, b interface{}) int {
+	return func(a, b interface{}) int {
+		ae := a.(entry)
+		be := b.(entry)
+		if c := kcmp(ae.key, be.key); c != 0 {
+			return c
+		}
+		if ae.probe != 0 || be.probe != 0 {
+			return ae.probe - be.probe
+		}
+		switch {
+		case vcmp != nil:
+			return vcmp(ae.value, be.value)
+		case ae.seq < be.seq:
+			return -1
+		case ae.seq > be.seq:
+			return 1
+		default:
+			return 0
+		}
+	}
+}
+
+// tree is the part of the persistent and transient B-trees used to
+// find an entry.
+type tree interface {
+	Find(key interface{}) (interface{}, bool)
+	IteratorRange(lo, hi btree.Bound) btree.Iterator
+}
+
+// find returns the stored entry associating value with key. Without
+// a value comparison function the values of key are searched in turn
+// and compared with dyn.Equal.
+func find(t tree, vcmp cmpFunc, key, value interface{}) (entry, bool) {
+	if vcmp != nil {
+		found, ok := t.Find(entry{key: key, value: value})
+		if !ok {
+			return entry{}, false
+		}
+		return found.(entry), true
+	}
+	iter := t.IteratorRange(keyBounds(key))
+	for iter.HasNext() {
+		if e := iter.Next().(entry); dyn.Equal(e.value, value) {
+			return e, true
+		}
+	}
+	return entry{}, false
+}
+
+func makeTree(kcmp, vcmp cmpFunc) *btree.BTree {
+	cmp := makeCompare(kcmp, vcmp)
+	return btree.Empty(
+		btree.Compare(cmp),
+		btree.Equal(func(a, b interface{}) bool {
+			return cmp(a, b) == 0
+		}),
+	)
+}
+
+var empty = Map{
+	root: makeTree(dyn.Compare, nil),
+}
+
+type mapOptions struct {
+	compare      cmpFunc
+	valueCompare cmpFunc
+}
+
+// Option is a type that allows changes to pluggable parts of the
+// Map implementation.
+type Option func(*mapOptions)
+
+// Compare is an option to the Empty function that will allow
+// one to specify a different comparison operator instead
+// of the default which is from the dyn library. This is used
+// for keys.
+func Compare(cmp func(k1, k2 interface{}) int) Option {
+	return func(o *mapOptions) {
+		o.compare = cmp
+	}
+}
+
+// ValueCompare is an option to the Empty function that will allow
+// one to specify a different comparison operator instead of the
+// default of keeping the values of each key in the order they were
+// put. Values comparing equal with cmp are the same value, so only
+// one of them is kept for each key.
+func ValueCompare(cmp func(v1, v2 interface{}) int) Option {
+	return func(o *mapOptions) {
+		o.valueCompare = cmp
+	}
+}
+
+// Empty returns a new empty persistent multimap, one may supply
+// options for the map by using one of the option generating functions
+// and providing that to Empty.
+func Empty(options ...Option) *Map {
+	if len(options) == 0 {
+		return &empty
+	}
+
+	opts := mapOptions{
+		compare: dyn.Compare,
+	}
+	for _, opt := range options {
+		opt(&opts)
+	}
+
+	return &Map{
+		root: makeTree(opts.compare, opts.valueCompare),
+		vcmp: opts.valueCompare,
+	}
+}
+
+// New converts a list of elements to a persistent multimap by
+// associating them pairwise. New will panic if the number of
+// elements is not even.
+func New(elems ...interface{}) *Map {
+	if len(elems)%2 != 0 {
+		panic(errOddElements)
+	}
+	out := Empty().AsTransient()
+	for i := 0; i < len(elems); i += 2 {
+		out = out.Put(elems[i], elems[i+1])
+	}
+	return out.AsPersistent()
+}
+
+// Put associates value with key in addition to any values already
+// associated with key. If value is already associated with key, as
+// reported by dyn.Equal or by the ValueCompare function, the original
+// map is returned.
+func (m *Map) Put(key, value interface{}) *Map {
+	if m.ContainsEntry(key, value) {
+		return m
+	}
+	return m.withRoot(m.root.Add(entry{key: key, value: value, seq: m.seq}),
+		m.seq+1)
+}
+
+func (m *Map) withRoot(root *btree.BTree, seq uint64) *Map {
+	return &Map{
+		root: root,
+		vcmp: m.vcmp,
+		seq:  seq,
+	}
+}
+
+// Conj takes a value that must be an Entry and puts it in the map.
+// Conj implements a generic mechanism for building collections.
+func (m *Map) Conj(elem interface{}) interface{} {
+	entry := elem.(Entry)
+	return m.Put(entry.Key(), entry.Value())
+}
+
+// Remove removes the association between key and value leaving any
+// other values of key in place.
+func (m *Map) Remove(key, value interface{}) *Map {
+	found, ok := find(m.root, m.vcmp, key, value)
+	if !ok {
+		return m
+	}
+	return m.withRoot(m.root.Delete(found), m.seq)
+}
+
+// RemoveAll removes every value associated with key. The entries are
+// cut out of the tree in logarithmic time regardless of how many
+// values key has.
+func (m *Map) RemoveAll(key interface{}) *Map {
+	if m.CountOf(key) == 0 {
+		return m
+	}
+	below, rest := m.root.Split(lowerProbe(key))
+	_, above := rest.Split(upperProbe(key))
+	return m.withRoot(btree.Join(below, above), m.seq)
+}
+
+// Get returns a sequence of the values associated with key in the
+// order they were put, or sorted by the ValueCompare function if the
+// map was made with one. If key has no values nil is returned.
+func (m *Map) Get(key interface{}) seq.Sequence {
+	iter := m.root.IteratorRange(keyBounds(key))
+	if !iter.HasNext() {
+		return nil
+	}
+	return valueSequenceNew(iter)
+}
+
+// CountOf returns the number of values associated with key.
+func (m *Map) CountOf(key interface{}) int {
+	return m.root.CountRange(keyBounds(key))
+}
+
+func keyBounds(key interface{}) (btree.Bound, btree.Bound) {
+	return btree.Inclusive(lowerProbe(key)),
+		btree.Inclusive(upperProbe(key))
+}
+
+// Contains will test if the key has any values in the map.
+func (m *Map) Contains(key interface{}) bool {
+	return m.CountOf(key) != 0
+}
+
+// ContainsEntry will test if value is associated with key in the map.
+func (m *Map) ContainsEntry(key, value interface{}) bool {
+	_, ok := find(m.root, m.vcmp, key, value)
+	return ok
+}
+
+// Length returns the number of entries in the map.
+func (m *Map) Length() int {
+	return m.root.Length()
+}
+
+// Range will loop over the entries in the Map and call 'do' on each entry.
+// The 'do' function may be of many types:
+//
+// func(key, value interface{}) bool:
+//
+//	Takes empty interfaces and returns if the loop should continue.
+//	Useful to avoid reflection or for hetrogenous maps.
+//
+// func(key, value interface{}):
+//
+//	Takes empty interfaces.
+//	Useful to avoid reflection or for hetrogenous maps.
+//
+// func(entry Entry) bool:
+//
+//	Takes the Entry type and returns if the loop should continue
+//	Is called directly and avoids entry unpacking if not necessary.
+//
+// func(entry Entry):
+//
+//	Takes the Entry type.
+//	Is called directly and avoids entry unpacking if not necessary.
+//
+// func(k kT, v vT) bool
+//
+//	Takes a key of key type and a value of value type and returns if the loop should contiune.
+//	Is called with reflection and will panic if the kT and vT types are incorrect.
+//
+// func(k kT, v vT)
+//
+//	Takes a key of key type and a value of value type.
+//	Is called with reflection and will panic if the kT and vT types are incorrect.
+//
+// Range will panic if passed anything not matching these signatures.
+func (m *Map) Range(do interface{}) {
+	// NOTE: Update other functions using the same pattern
+	//       when modifying the below.
+	//       This code is inlined to avoid heap allocation of
+	//       the closure.
+	var f func(e Entry) bool
+	switch fn := do.(type) {
+	case func(key, value interface{}) bool:
+		f = func(entry Entry) bool {
+			return fn(entry.Key(), entry.Value())
+		}
+	case func(key, value interface{}):
+		f = func(entry Entry) bool {
+			fn(entry.Key(), entry.Value())
+			return true
+		}
+	case func(e Entry) bool:
+		f = fn
+	case func(e Entry):
+		f = func(entry Entry) bool {
+			fn(entry)
+			return true
+		}
+	default:
+		f = genRangeFunc(do)
+	}
+
+	iter := m.Iterator()
+	var cont = true
+	for iter.HasNext() && cont {
+		entry := iter.NextEntry()
+		cont = f(entry)
+	}
+}
+
+func genRangeFunc(do interface{}) func(Entry) bool {
+	rv := reflect.ValueOf(do)
+	if rv.Kind() != reflect.Func {
+		panic(errRangeSig)
+	}
+	rt := rv.Type()
+	if rt.NumIn() != 2 || rt.NumOut() > 1 {
+		panic(errRangeSig)
+	}
+	if rt.NumOut() == 1 &&
+		rt.Out(0).Kind() != reflect.Bool {
+		panic(errRangeSig)
+	}
+	return func(entry Entry) bool {
+		out := dyn.Apply(do, entry.Key(), entry.Value())
+		if out != nil {
+			return out.(bool)
+		}
+		return true
+	}
+}
+
+// Iterator provides a mutable iterator over the map. This allows
+// efficient, heap allocation-less access to the contents. Iterators
+// are not safe for concurrent access so they may not be shared
+// by reference between goroutines.
+func (m *Map) Iterator() Iterator {
+	return Iterator{
+		impl: m.root.Iterator(),
+	}
+}
+
+// ReverseIterator provides a mutable iterator over the map that
+// visits the entries from the largest to the smallest.
+func (m *Map) ReverseIterator() Iterator {
+	return Iterator{
+		impl: m.root.ReverseIterator(),
+	}
+}
+
+// Seq returns a seralized sequence of Entry
+// corresponding to the maps entries.
+func (m *Map) Seq() seq.Sequence {
+	iter := m.root.Iterator()
+	if !iter.HasNext() {
+		return nil
+	}
+	return sequenceNew(iter)
+}
+
+// String returns a string representation of the map.
+func (m *Map) String() string {
+	var b strings.Builder
+	fmt.Fprint(&b, "{ ")
+	iter := m.Iterator()
+	for iter.HasNext() {
+		entry := iter.NextEntry()
+		fmt.Fprintf(&b, "%s ", entry)
+	}
+	fmt.Fprint(&b, "}")
+	return b.String()
+}
+
+// Equal tests if two maps are Equal by comparing the entries of each.
+// The order in which the values of a key were put is not compared.
+// Equal implements the Equaler which allows for deep
+// comparisons when there are maps of maps
+func (m *Map) Equal(o interface{}) bool {
+	other, ok := o.(*Map)
+	if !ok {
+		return ok
+	}
+	if m.Length() != other.Length() {
+		return false
+	}
+	iter := m.root.Iterator()
+	for iter.HasNext() {
+		e := iter.Next().(entry)
+		if !other.ContainsEntry(e.key, e.value) {
+			return false
+		}
+	}
+	return true
+}
+
+// Apply takes an arbitrary number of arguments and returns the
+// values Get of the first argument. Apply allows map to be called
+// as a function by the 'dyn' library.
+func (m *Map) Apply(args ...interface{}) interface{} {
+	k := args[0]
+	return m.Get(k)
+}
+
+// Iterator is a mutable iterator for a map. It has a fixed size
+// stack, the size of which is computed from the maximum number of
+// nested nodes possible based on the branching factor.
+type Iterator struct {
+	impl btree.Iterator
+}
+
+// Next provides the next key value pair and increments the cursor.
+func (i *Iterator) Next() (interface{}, interface{}) {
+	out := i.impl.Next()
+	ent := out.(entry)
+	return ent.key, ent.value
+}
+
+// NextEntry provides the next entry and increments the cursor.
+func (i *Iterator) NextEntry() Entry {
+	out := i.impl.Next()
+	ent := out.(entry)
+	return ent
+}
+
+// HasNext is true when there are more elements to be iterated over.
+func (i *Iterator) HasNext() bool {
+	return i.impl.HasNext()
+}
+
+// Prev provides the previous key value pair and decrements the cursor.
+func (i *Iterator) Prev() (interface{}, interface{}) {
+	out := i.impl.Prev()
+	ent := out.(entry)
+	return ent.key, ent.value
+}
+
+// PrevEntry provides the previous entry and decrements the cursor.
+func (i *Iterator) PrevEntry() Entry {
+	out := i.impl.Prev()
+	ent := out.(entry)
+	return ent
+}
+
+// HasPrev is true when there are elements before the cursor.
+func (i *Iterator) HasPrev() bool {
+	return i.impl.HasPrev()
+}
+
+// Seek moves the cursor so that the next call to Next returns the
+// first entry whose key is not less than key. For reverse iterators
+// it is the last entry whose key is not greater than key.
+func (i *Iterator) Seek(key interface{}) {
+	if i.impl.Reverse() {
+		i.impl.Seek(upperProbe(key))
+		return
+	}
+	i.impl.Seek(lowerProbe(key))
+}
+
+// Clone returns a copy of the iterator at the same position. The
+// copy advances independently of the original.
+func (i *Iterator) Clone() Iterator {
+	return Iterator{
+		impl: i.impl.Clone(),
+	}
+}
+
+// AsTransient will return a transient map that shares
+// structure with the persistent map.
+func (m *Map) AsTransient() *TMap {
+	return &TMap{
+		root: m.root.AsTransient(),
+		vcmp: m.vcmp,
+		seq:  m.seq,
+		orig: m,
+	}
+}
+
+// MakeTransient is a generic version of AsTransient.
+func (m *Map) MakeTransient() interface{} {
+	return m.AsTransient()
+}
+
+// Transform takes a set of actions and performs them
+// on the persistent map. It does this by making a transient
+// map and calling each action on it, then converting it back
+// to a persistent map.
+// Each action may be a func(*TMap) *TMap, whose result is passed to
+// the following action unless it is nil, or a func(*TMap).
+// Transform will panic if given any other type.
+func (m *Map) Transform(actions ...interface{}) *Map {
+	out := m.AsTransient()
+	for _, action := range actions {
+		switch fn := action.(type) {
+		case func(*TMap) *TMap:
+			if next := fn(out); next != nil {
+				out = next
+			}
+		case func(*TMap):
+			fn(out)
+		default:
+			panic(errTransformSig)
+		}
+	}
+	return out.AsPersistent()
+}
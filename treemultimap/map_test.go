@@ -0,0 +1,278 @@
+package treemultimap
+
+import (
+	"testing"
+
+	"github.com/leanovate/gopter"
+	"github.com/leanovate/gopter/gen"
+	"github.com/leanovate/gopter/prop"
+	"jsouthworth.net/go/dyn"
+	"jsouthworth.net/go/immutable/vector"
+	"jsouthworth.net/go/seq"
+)
+
+// makeModel builds a multimap and the equivalent go map, holding the
+// distinct values of each key in the order they were put, from pairs
+// of keys and values.
+func makeModel(keys, values []int) (*Map, map[int][]int) {
+	m := Empty()
+	model := make(map[int][]int)
+	for i := range keys {
+		k, v := keys[i], values[i%len(values)]
+		m = m.Put(k, v)
+		if indexOf(model[k], v) < 0 {
+			model[k] = append(model[k], v)
+		}
+	}
+	return m, model
+}
+
+func indexOf(vals []int, v int) int {
+	for i, val := range vals {
+		if val == v {
+			return i
+		}
+	}
+	return -1
+}
+
+// without returns vals without v.
+func without(vals []int, v int) []int {
+	i := indexOf(vals, v)
+	if i < 0 {
+		return vals
+	}
+	return append(vals[:i:i], vals[i+1:]...)
+}
+
+func seqInts(s seq.Sequence) []int {
+	var out []int
+	for ; s != nil; s = s.Next() {
+		out = append(out, s.First().(int))
+	}
+	return out
+}
+
+func intsEqual(a, b []int) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func modelLength(model map[int][]int) int {
+	var n int
+	for _, vals := range model {
+		n += len(vals)
+	}
+	return n
+}
+
+func TestMultimap(t *testing.T) {
+	parameters := gopter.DefaultTestParameters()
+	properties := gopter.NewProperties(parameters)
+	keys := gen.SliceOf(gen.IntRange(0, 20))
+	values := gen.SliceOfN(10, gen.IntRange(0, 100))
+	properties.Property("Get(k) returns the values of k in the order put", prop.ForAll(
+		func(ks, vs []int, k int) bool {
+			m, model := makeModel(ks, vs)
+			return intsEqual(seqInts(m.Get(k)), model[k]) &&
+				m.CountOf(k) == len(model[k]) &&
+				m.Contains(k) == (len(model[k]) != 0)
+		},
+		keys, values, gen.IntRange(0, 20),
+	))
+	properties.Property("Length counts distinct pairs", prop.ForAll(
+		func(ks, vs []int) bool {
+			m, model := makeModel(ks, vs)
+			return m.Length() == modelLength(model)
+		},
+		keys, values,
+	))
+	properties.Property("Put of an existing pair returns the map", prop.ForAll(
+		func(ks, vs []int) bool {
+			m, _ := makeModel(ks, vs)
+			if len(ks) == 0 {
+				return true
+			}
+			return m.Put(ks[0], vs[0]) == m
+		},
+		keys, values,
+	))
+	properties.Property("Remove(k, v) removes only that pair", prop.ForAll(
+		func(ks, vs []int, k, v int) bool {
+			m, model := makeModel(ks, vs)
+			r := m.Remove(k, v)
+			model[k] = without(model[k], v)
+			return !r.ContainsEntry(k, v) &&
+				intsEqual(seqInts(r.Get(k)), model[k]) &&
+				r.Length() == modelLength(model)
+		},
+		keys, values, gen.IntRange(0, 20), gen.IntRange(0, 100),
+	))
+	properties.Property("RemoveAll(k) removes every value of k", prop.ForAll(
+		func(ks, vs []int, k int) bool {
+			m, model := makeModel(ks, vs)
+			r := m.RemoveAll(k)
+			delete(model, k)
+			ok := r.Get(k) == nil && r.CountOf(k) == 0 &&
+				r.Length() == modelLength(model)
+			for key, vals := range model {
+				ok = ok && intsEqual(seqInts(r.Get(key)), vals)
+			}
+			return ok
+		},
+		keys, values, gen.IntRange(0, 20),
+	))
+	properties.Property("entries are ordered by key", prop.ForAll(
+		func(ks, vs []int) bool {
+			m, _ := makeModel(ks, vs)
+			ok := true
+			lastK := -1
+			m.Range(func(k, v int) bool {
+				ok = k >= lastK
+				lastK = k
+				return ok
+			})
+			return ok
+		},
+		keys, values,
+	))
+	properties.Property("Equal ignores the order values were put", prop.ForAll(
+		func(ks, vs []int) bool {
+			m, _ := makeModel(ks, vs)
+			r := Empty()
+			for i := len(ks) - 1; i >= 0; i-- {
+				r = r.Put(ks[i], vs[i%len(vs)])
+			}
+			return m.Equal(r) && r.Equal(m)
+		},
+		keys, values,
+	))
+	properties.Property("transient matches persistent", prop.ForAll(
+		func(ks, vs []int, k int) bool {
+			m, _ := makeModel(ks, vs)
+			t := Empty().AsTransient()
+			for i := range ks {
+				t.Put(ks[i], vs[i%len(vs)])
+			}
+			ok := t.CountOf(k) == m.CountOf(k) &&
+				intsEqual(seqInts(t.Get(k)), seqInts(m.Get(k)))
+			t.RemoveAll(k)
+			return ok && t.AsPersistent().Equal(m.RemoveAll(k))
+		},
+		keys, values, gen.IntRange(0, 20),
+	))
+	properties.Property("Subrange(a, b, true, false) has keys in [a, b)", prop.ForAll(
+		func(ks, vs []int, a, b int) bool {
+			m, model := makeModel(ks, vs)
+			v := m.Subrange(a, b, true, false)
+			expected := 0
+			for k, vals := range model {
+				if k >= a && k < b {
+					expected += len(vals)
+				}
+			}
+			ok := true
+			count := 0
+			v.Range(func(k, _ int) bool {
+				ok = k >= a && k < b
+				count++
+				return ok
+			})
+			return ok && count == expected && v.Length() == expected &&
+				v.AsMap().Length() == expected
+		},
+		keys, values, gen.IntRange(-1, 21), gen.IntRange(-1, 21),
+	))
+	properties.Property("Subrange(a, b, false, true) respects bounds", prop.ForAll(
+		func(ks, vs []int, a, b, k int) bool {
+			m, model := makeModel(ks, vs)
+			v := m.Subrange(a, b, false, true)
+			expected := 0
+			if k > a && k <= b {
+				expected = len(model[k])
+			}
+			return v.CountOf(k) == expected &&
+				len(seqInts(v.Get(k))) == expected
+		},
+		keys, values, gen.IntRange(-1, 21), gen.IntRange(-1, 21),
+		gen.IntRange(0, 20),
+	))
+	properties.TestingRun(t)
+}
+
+func TestIteratorSeek(t *testing.T) {
+	m := New(1, 10, 1, 11, 2, 20, 2, 21, 3, 30)
+	iter := m.Iterator()
+	iter.Seek(2)
+	if k, v := iter.Next(); k != 2 || v != 20 {
+		t.Fatalf("expected [2 20] got [%v %v]", k, v)
+	}
+	rev := m.ReverseIterator()
+	rev.Seek(2)
+	if k, v := rev.Next(); k != 2 || v != 21 {
+		t.Fatalf("expected [2 21] got [%v %v]", k, v)
+	}
+}
+
+func TestValueCompare(t *testing.T) {
+	m := Empty(ValueCompare(func(a, b interface{}) int {
+		return -dyn.Compare(a, b)
+	}))
+	m = m.Put("a", 1).Put("a", 3).Put("a", 2)
+	got := seqInts(m.Get("a"))
+	if !intsEqual(got, []int{3, 2, 1}) {
+		t.Fatalf("expected [3 2 1] got %v", got)
+	}
+}
+
+func TestUnorderedValues(t *testing.T) {
+	type point struct{ x, y int }
+	m := New("a", point{1, 2}, "a", vector.New(1), "b", point{0, 0})
+	m = m.Put("a", point{1, 2}).Put("a", vector.New(1)).Put("a", point{0, 1})
+	if m.CountOf("a") != 3 || m.Length() != 4 {
+		t.Fatal("expected equal values to be kept once", m)
+	}
+	got := seq.Reduce(func(res, v interface{}) interface{} {
+		return append(res.([]interface{}), v)
+	}, []interface{}(nil), m.Get("a")).([]interface{})
+	if got[0] != (point{1, 2}) || !vector.New(1).Equal(got[1]) ||
+		got[2] != (point{0, 1}) {
+		t.Fatal("expected the values in the order they were put", got)
+	}
+	r := m.Remove("a", vector.New(1))
+	if r.CountOf("a") != 2 || r.ContainsEntry("a", vector.New(1)) ||
+		!m.ContainsEntry("a", vector.New(1)) {
+		t.Fatal("unexpected removal", r)
+	}
+	last := r.Put("a", vector.New(2)).Get("a").Next().Next().First()
+	if !vector.New(2).Equal(last) {
+		t.Fatal("expected new values to follow the others", last)
+	}
+}
+
+func TestTransformForms(t *testing.T) {
+	m := New(1, "a", 2, "b").Transform(
+		func(t *TMap) *TMap { return t.Put(1, "c").Put(1, "a") },
+		func(t *TMap) { t.RemoveAll(2) },
+		func(t *TMap) *TMap {
+			t.Put(3, "d").Remove(1, "a")
+			return nil
+		},
+	)
+	if m.String() != "{ [1 c] [3 d] }" {
+		t.Fatalf("unexpected map %s", m)
+	}
+	defer func() {
+		if r := recover(); r != errTransformSig {
+			t.Fatalf("expected errTransformSig got %v", r)
+		}
+	}()
+	m.Transform(func(t *TMap) seq.Sequence { return t.Get(1) })
+}
@@ -0,0 +1,52 @@
+package treemultimap
+
+import (
+	"jsouthworth.net/go/immutable/internal/btree"
+	"jsouthworth.net/go/seq"
+)
+
+// sequence is a persistent sequence over the entries of an
+// iterator. When values is set only the value of each entry is
+// produced.
+type sequence struct {
+	iter   btree.Iterator
+	values bool
+}
+
+func sequenceNew(iter btree.Iterator) *sequence {
+	return &sequence{
+		iter: iter,
+	}
+}
+
+func valueSequenceNew(iter btree.Iterator) *sequence {
+	return &sequence{
+		iter:   iter,
+		values: true,
+	}
+}
+
+func (s *sequence) First() interface{} {
+	iter := s.iter.Clone()
+	ent := iter.Next().(entry)
+	if s.values {
+		return ent.value
+	}
+	return ent
+}
+
+func (s *sequence) Next() seq.Sequence {
+	iter := s.iter.Clone()
+	iter.Next()
+	if !iter.HasNext() {
+		return nil
+	}
+	return &sequence{
+		iter:   iter,
+		values: s.values,
+	}
+}
+
+func (s *sequence) String() string {
+	return seq.ConvertToString(s)
+}
@@ -0,0 +1,181 @@
+package treemultimap
+
+import (
+	"fmt"
+	"strings"
+
+	"jsouthworth.net/go/immutable/internal/btree"
+	"jsouthworth.net/go/seq"
+)
+
+// TMap is a transient version of a multimap. Changes made to a
+// transient map will not effect the original persistent
+// structure. Changes to a transient map occur as mutations. These
+// mutations are then made persistent when the transient is
+// transformed into a persistent structure. These are useful when
+// appling multiple transforms to a persistent map where the
+// intermediate results will not be seen or stored anywhere.
+type TMap struct {
+	root *btree.TBTree
+	vcmp cmpFunc
+	seq  uint64
+
+	orig *Map
+}
+
+// Put associates value with key in addition to any values already
+// associated with key. The transient map is modified and then
+// returned.
+func (m *TMap) Put(key, value interface{}) *TMap {
+	if m.ContainsEntry(key, value) {
+		return m
+	}
+	m.root = m.root.Add(entry{key: key, value: value, seq: m.seq})
+	m.seq++
+	return m
+}
+
+// Conj takes a value that must be an Entry. Conj implements
+// a generic mechanism for building collections.
+func (m *TMap) Conj(value interface{}) interface{} {
+	entry := value.(Entry)
+	return m.Put(entry.Key(), entry.Value())
+}
+
+// Remove removes the association between key and value leaving any
+// other values of key in place.
+func (m *TMap) Remove(key, value interface{}) *TMap {
+	if found, ok := find(m.root, m.vcmp, key, value); ok {
+		m.root = m.root.Delete(found)
+	}
+	return m
+}
+
+// RemoveAll removes every value associated with key.
+func (m *TMap) RemoveAll(key interface{}) *TMap {
+	var found []interface{}
+	iter := m.root.IteratorRange(keyBounds(key))
+	for iter.HasNext() {
+		found = append(found, iter.Next())
+	}
+	for _, ent := range found {
+		m.root = m.root.Delete(ent)
+	}
+	return m
+}
+
+// Get returns a sequence of the values associated with key in the
+// same order as Map.Get. If key has no values nil is returned. The
+// sequence must not be used after the transient map is modified.
+func (m *TMap) Get(key interface{}) seq.Sequence {
+	iter := m.root.IteratorRange(keyBounds(key))
+	if !iter.HasNext() {
+		return nil
+	}
+	return valueSequenceNew(iter)
+}
+
+// CountOf returns the number of values associated with key.
+func (m *TMap) CountOf(key interface{}) int {
+	return m.root.CountRange(keyBounds(key))
+}
+
+// Contains will test if the key has any values in the map.
+func (m *TMap) Contains(key interface{}) bool {
+	return m.CountOf(key) != 0
+}
+
+// ContainsEntry will test if value is associated with key in the map.
+func (m *TMap) ContainsEntry(key, value interface{}) bool {
+	_, ok := find(m.root, m.vcmp, key, value)
+	return ok
+}
+
+// Length returns the number of entries in the map.
+func (m *TMap) Length() int {
+	return m.root.Length()
+}
+
+// AsPersistent will transform this transient map into a persistent map.
+// Once this occurs any additional actions on the transient map will fail.
+func (m *TMap) AsPersistent() *Map {
+	newRoot := m.root.AsPersistent()
+	if newRoot == m.orig.root {
+		return m.orig
+	}
+	return m.orig.withRoot(newRoot, m.seq)
+}
+
+// MakePersistent is a generic version of AsPersistent.
+func (m *TMap) MakePersistent() interface{} {
+	return m.AsPersistent()
+}
+
+// Range will loop over the entries in the Map and call 'do' on each
+// entry. The 'do' function may be of any of the types accepted by
+// Map.Range.
+func (m *TMap) Range(do interface{}) {
+	// NOTE: Update other functions using the same pattern
+	//       when modifying the below.
+	//       This code is inlined to avoid heap allocation of
+	//       the closure.
+	var f func(Entry) bool
+	switch fn := do.(type) {
+	case func(key, value interface{}) bool:
+		f = func(entry Entry) bool {
+			return fn(entry.Key(), entry.Value())
+		}
+	case func(key, value interface{}):
+		f = func(entry Entry) bool {
+			fn(entry.Key(), entry.Value())
+			return true
+		}
+	case func(e Entry) bool:
+		f = fn
+	case func(e Entry):
+		f = func(entry Entry) bool {
+			fn(entry)
+			return true
+		}
+	default:
+		f = genRangeFunc(do)
+	}
+
+	iter := m.Iterator()
+	cont := true
+	for iter.HasNext() && cont {
+		entry := iter.NextEntry()
+		cont = f(entry)
+	}
+}
+
+// String returns a string representation of the map.
+func (m *TMap) String() string {
+	var b strings.Builder
+	fmt.Fprint(&b, "{ ")
+	iter := m.Iterator()
+	for iter.HasNext() {
+		entry := iter.NextEntry()
+		fmt.Fprintf(&b, "%s ", entry)
+	}
+	fmt.Fprint(&b, "}")
+	return b.String()
+}
+
+// Iterator provides a mutable iterator over the map. This allows
+// efficient, heap allocation-less access to the contents. Iterators
+// are not safe for concurrent access so they may not be shared
+// by reference between goroutines.
+func (m *TMap) Iterator() Iterator {
+	return Iterator{
+		impl: m.root.Iterator(),
+	}
+}
+
+// ReverseIterator provides a mutable iterator over the map that
+// visits the entries from the largest to the smallest.
+func (m *TMap) ReverseIterator() Iterator {
+	return Iterator{
+		impl: m.root.ReverseIterator(),
+	}
+}
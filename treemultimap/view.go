@@ -0,0 +1,197 @@
+package treemultimap
+
+import (
+	"fmt"
+	"strings"
+
+	"jsouthworth.net/go/immutable/internal/btree"
+	"jsouthworth.net/go/seq"
+)
+
+// View is a lazy, read-only window onto the entries of a Map whose
+// keys lie between two bounds. Creating a view copies nothing;
+// iteration over a view seeks directly to the lower bound and stops
+// at the upper bound.
+type View struct {
+	root   *btree.BTree
+	from   *Map
+	lo, hi btree.Bound
+}
+
+// Subrange returns a view of the entries whose keys lie between from
+// and to. The inclusive flags determine whether the values of from
+// and to themselves are part of the view.
+func (m *Map) Subrange(
+	from, to interface{},
+	fromInclusive, toInclusive bool,
+) *View {
+	lo := btree.Exclusive(upperProbe(from))
+	if fromInclusive {
+		lo = btree.Inclusive(lowerProbe(from))
+	}
+	hi := btree.Exclusive(lowerProbe(to))
+	if toInclusive {
+		hi = btree.Inclusive(upperProbe(to))
+	}
+	return &View{
+		root: m.root,
+		from: m,
+		lo:   lo,
+		hi:   hi,
+	}
+}
+
+// HeadMap returns a view of the entries whose keys are strictly less
+// than to.
+func (m *Map) HeadMap(to interface{}) *View {
+	return &View{
+		root: m.root,
+		from: m,
+		lo:   btree.Unbounded(),
+		hi:   btree.Exclusive(lowerProbe(to)),
+	}
+}
+
+// TailMap returns a view of the entries whose keys are greater than
+// or equal to from.
+func (m *Map) TailMap(from interface{}) *View {
+	return &View{
+		root: m.root,
+		from: m,
+		lo:   btree.Inclusive(lowerProbe(from)),
+		hi:   btree.Unbounded(),
+	}
+}
+
+func (v *View) contains(key interface{}) bool {
+	return v.root.InRange(lowerProbe(key), v.lo, v.hi)
+}
+
+// Get returns a sequence of the values associated with key in the
+// same order as Map.Get. If key has no values or is outside of the
+// view nil is returned.
+func (v *View) Get(key interface{}) seq.Sequence {
+	if !v.contains(key) {
+		return nil
+	}
+	iter := v.root.IteratorRange(keyBounds(key))
+	if !iter.HasNext() {
+		return nil
+	}
+	return valueSequenceNew(iter)
+}
+
+// CountOf returns the number of values associated with key, or zero
+// if key is outside of the view.
+func (v *View) CountOf(key interface{}) int {
+	if !v.contains(key) {
+		return 0
+	}
+	return v.root.CountRange(keyBounds(key))
+}
+
+// Contains will test if the key has any values in the view.
+func (v *View) Contains(key interface{}) bool {
+	return v.CountOf(key) != 0
+}
+
+// ContainsEntry will test if value is associated with key in the
+// view.
+func (v *View) ContainsEntry(key, value interface{}) bool {
+	return v.contains(key) && v.from.ContainsEntry(key, value)
+}
+
+// Length returns the number of entries in the view.
+func (v *View) Length() int {
+	return v.root.CountRange(v.lo, v.hi)
+}
+
+// Range will loop over the entries in the View and call 'do' on each
+// entry. The 'do' function may be of any of the types accepted by
+// Map.Range.
+func (v *View) Range(do interface{}) {
+	// NOTE: Update other functions using the same pattern
+	//       when modifying the below.
+	//       This code is inlined to avoid heap allocation of
+	//       the closure.
+	var f func(e Entry) bool
+	switch fn := do.(type) {
+	case func(key, value interface{}) bool:
+		f = func(entry Entry) bool {
+			return fn(entry.Key(), entry.Value())
+		}
+	case func(key, value interface{}):
+		f = func(entry Entry) bool {
+			fn(entry.Key(), entry.Value())
+			return true
+		}
+	case func(e Entry) bool:
+		f = fn
+	case func(e Entry):
+		f = func(entry Entry) bool {
+			fn(entry)
+			return true
+		}
+	default:
+		f = genRangeFunc(do)
+	}
+
+	iter := v.Iterator()
+	var cont = true
+	for iter.HasNext() && cont {
+		entry := iter.NextEntry()
+		cont = f(entry)
+	}
+}
+
+// Iterator provides a mutable iterator over the view. The iterator
+// starts at the first entry in the view and stops after the last.
+func (v *View) Iterator() Iterator {
+	return Iterator{
+		impl: v.root.IteratorRange(v.lo, v.hi),
+	}
+}
+
+// ReverseIterator provides a mutable iterator over the view that
+// starts at the last entry in the view and stops after the first.
+func (v *View) ReverseIterator() Iterator {
+	return Iterator{
+		impl: v.root.ReverseIteratorRange(v.lo, v.hi),
+	}
+}
+
+// Seq returns a seralized sequence of Entry
+// corresponding to the view's entries.
+func (v *View) Seq() seq.Sequence {
+	iter := v.root.IteratorRange(v.lo, v.hi)
+	if !iter.HasNext() {
+		return nil
+	}
+	return sequenceNew(iter)
+}
+
+// String returns a string representation of the view.
+func (v *View) String() string {
+	var b strings.Builder
+	fmt.Fprint(&b, "{ ")
+	iter := v.Iterator()
+	for iter.HasNext() {
+		entry := iter.NextEntry()
+		fmt.Fprintf(&b, "%s ", entry)
+	}
+	fmt.Fprint(&b, "}")
+	return b.String()
+}
+
+// AsMap copies the entries of the view into a new persistent
+// multimap that uses the same comparison functions, and keeps the
+// order of the values of each key, of the map the view was taken
+// from.
+func (v *View) AsMap() *Map {
+	keys := make([]interface{}, 0, v.Length())
+	iter := v.root.IteratorRange(v.lo, v.hi)
+	for iter.HasNext() {
+		keys = append(keys, iter.Next())
+	}
+	return v.from.withRoot(v.root.FromSorted(keys), v.from.seq)
+}
@@ -0,0 +1,285 @@
+package treeset
+
+import (
+	"errors"
+	"math/bits"
+
+	"jsouthworth.net/go/immutable/internal/btree"
+)
+
+var errOrderMismatch = errors.New("set operations require both sets to share an ordering")
+
+// The set operations below take the options of the result, and the
+// element kept when both sets hold equal elements, from s. They panic
+// if the sets do not share an ordering, see Compare.
+
+// Union returns a set holding the elements that are in either s or
+// other.
+func (s *Set) Union(other *Set) *Set {
+	s.checkOrder(other)
+	switch {
+	case other.Length() == 0:
+		return s
+	case isSmall(other.Length(), s.Length()):
+		return s.addAll(other)
+	case isSmall(s.Length(), other.Length()) &&
+		s.root.SameNodeSize(other.root):
+		return s.adopt(other).putAll(s)
+	}
+	out := make([]interface{}, 0, s.Length()+other.Length())
+	merge(s, other, func(elem interface{}, inS, inOther bool) bool {
+		out = append(out, elem)
+		return true
+	})
+	return s.fromSorted(out)
+}
+
+// Intersection returns a set holding the elements that are in both
+// s and other.
+func (s *Set) Intersection(other *Set) *Set {
+	s.checkOrder(other)
+	switch {
+	case isSmall(s.Length(), other.Length()):
+		return s.filter(other, true)
+	case isSmall(other.Length(), s.Length()):
+		var out []interface{}
+		iter := other.root.Iterator()
+		for iter.HasNext() {
+			if elem, ok := s.root.Find(iter.Next()); ok {
+				out = append(out, elem)
+			}
+		}
+		return s.fromSorted(out)
+	}
+	var out []interface{}
+	merge(s, other, func(elem interface{}, inS, inOther bool) bool {
+		if inS && inOther {
+			out = append(out, elem)
+		}
+		return true
+	})
+	return s.fromSorted(out)
+}
+
+// Difference returns a set holding the elements of s that are not
+// in other.
+func (s *Set) Difference(other *Set) *Set {
+	s.checkOrder(other)
+	switch {
+	case other.Length() == 0:
+		return s
+	case isSmall(other.Length(), s.Length()):
+		return s.Transform(func(t *TSet) {
+			iter := other.root.Iterator()
+			for iter.HasNext() {
+				t.Delete(iter.Next())
+			}
+		})
+	case isSmall(s.Length(), other.Length()):
+		return s.filter(other, false)
+	}
+	var out []interface{}
+	merge(s, other, func(elem interface{}, inS, inOther bool) bool {
+		if inS && !inOther {
+			out = append(out, elem)
+		}
+		return true
+	})
+	return s.fromSorted(out)
+}
+
+// SymmetricDifference returns a set holding the elements that are in
+// exactly one of s and other.
+func (s *Set) SymmetricDifference(other *Set) *Set {
+	s.checkOrder(other)
+	switch {
+	case isSmall(other.Length(), s.Length()):
+		return s.toggleAll(other)
+	case isSmall(s.Length(), other.Length()) &&
+		s.root.SameNodeSize(other.root):
+		return s.adopt(other).toggleAll(s)
+	}
+	var out []interface{}
+	merge(s, other, func(elem interface{}, inS, inOther bool) bool {
+		if inS != inOther {
+			out = append(out, elem)
+		}
+		return true
+	})
+	return s.fromSorted(out)
+}
+
+// IsSubset returns true if every element of s is in other.
+func (s *Set) IsSubset(other *Set) bool {
+	s.checkOrder(other)
+	if s.Length() > other.Length() {
+		return false
+	}
+	if isSmall(s.Length(), other.Length()) {
+		iter := s.root.Iterator()
+		for iter.HasNext() {
+			if !other.root.Contains(iter.Next()) {
+				return false
+			}
+		}
+		return true
+	}
+	subset := true
+	merge(s, other, func(elem interface{}, inS, inOther bool) bool {
+		subset = !inS || inOther
+		return subset
+	})
+	return subset
+}
+
+// Disjoint returns true if s and other have no elements in common.
+func (s *Set) Disjoint(other *Set) bool {
+	s.checkOrder(other)
+	small, large := s, other
+	if small.Length() > large.Length() {
+		small, large = large, small
+	}
+	if isSmall(small.Length(), large.Length()) {
+		iter := small.root.Iterator()
+		for iter.HasNext() {
+			if large.root.Contains(iter.Next()) {
+				return false
+			}
+		}
+		return true
+	}
+	disjoint := true
+	merge(s, other, func(elem interface{}, inS, inOther bool) bool {
+		disjoint = !(inS && inOther)
+		return disjoint
+	})
+	return disjoint
+}
+
+func (s *Set) checkOrder(other *Set) {
+	if !s.root.SameOrder(other.root) {
+		panic(errOrderMismatch)
+	}
+}
+
+func (s *Set) fromSorted(elems []interface{}) *Set {
+	return &Set{
+		root: s.root.FromSorted(elems),
+		eq:   s.eq,
+	}
+}
+
+// adopt returns a set holding the elements of other with the options
+// of s. The set shares the structure of other so the sets must use
+// the same node size.
+func (s *Set) adopt(other *Set) *Set {
+	return &Set{
+		root: s.root.Adopt(other.root),
+		eq:   s.eq,
+	}
+}
+
+// addAll adds the elements of other that are not in s to s.
+func (s *Set) addAll(other *Set) *Set {
+	return s.Transform(func(t *TSet) {
+		iter := other.root.Iterator()
+		for iter.HasNext() {
+			if elem := iter.Next(); !t.Contains(elem) {
+				t.Add(elem)
+			}
+		}
+	})
+}
+
+// putAll adds the elements of other to s, replacing any equal
+// elements of s.
+func (s *Set) putAll(other *Set) *Set {
+	return s.Transform(func(t *TSet) {
+		iter := other.root.Iterator()
+		for iter.HasNext() {
+			elem := iter.Next()
+			t.Delete(elem)
+			t.Add(elem)
+		}
+	})
+}
+
+// toggleAll removes the elements of other that are in s from s and
+// adds the rest.
+func (s *Set) toggleAll(other *Set) *Set {
+	return s.Transform(func(t *TSet) {
+		iter := other.root.Iterator()
+		for iter.HasNext() {
+			elem := iter.Next()
+			if t.Contains(elem) {
+				t.Delete(elem)
+			} else {
+				t.Add(elem)
+			}
+		}
+	})
+}
+
+// filter keeps the elements of s whose membership in other matches
+// keep.
+func (s *Set) filter(other *Set, keep bool) *Set {
+	var out []interface{}
+	iter := s.root.Iterator()
+	for iter.HasNext() {
+		elem := iter.Next()
+		if other.root.Contains(elem) == keep {
+			out = append(out, elem)
+		}
+	}
+	return s.fromSorted(out)
+}
+
+// isSmall reports whether probing a set of size n once for each of m
+// elements is cheaper than merging the two sets.
+func isSmall(m, n int) bool {
+	return m*bits.Len(uint(n)) < m+n
+}
+
+// merge walks the elements of s and other together in order calling
+// fn with each distinct element and which of the sets hold it. When
+// both sets hold an element the one from s is passed. The walk stops
+// early if fn returns false.
+func merge(s, other *Set, fn func(elem interface{}, inS, inOther bool) bool) {
+	a, b := s.root.Iterator(), other.root.Iterator()
+	x, hasX := next(&a)
+	y, hasY := next(&b)
+	for hasX || hasY {
+		var c int
+		switch {
+		case !hasY:
+			c = -1
+		case !hasX:
+			c = 1
+		default:
+			c = s.root.Compare(x, y)
+		}
+		var cont bool
+		switch {
+		case c < 0:
+			cont = fn(x, true, false)
+			x, hasX = next(&a)
+		case c > 0:
+			cont = fn(y, false, true)
+			y, hasY = next(&b)
+		default:
+			cont = fn(x, true, true)
+			x, hasX = next(&a)
+			y, hasY = next(&b)
+		}
+		if !cont {
+			return
+		}
+	}
+}
+
+func next(iter *btree.Iterator) (interface{}, bool) {
+	if !iter.HasNext() {
+		return nil, false
+	}
+	return iter.Next(), true
+}
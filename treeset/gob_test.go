@@ -0,0 +1,37 @@
+package treeset
+
+import (
+	"bytes"
+	"encoding/gob"
+	"strings"
+	"testing"
+)
+
+func TestGobOptionsNotEncoded(t *testing.T) {
+	reverse := func(a, b interface{}) int {
+		return strings.Compare(b.(string), a.(string))
+	}
+	in := Empty(Compare(reverse)).Add("a").Add("c").Add("b")
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(in); err != nil {
+		t.Fatal(err)
+	}
+	var out *Set
+	if err := gob.NewDecoder(&buf).Decode(&out); err != nil {
+		t.Fatal(err)
+	}
+	if out.String() != "{ a b c }" {
+		t.Fatal("expected a fresh set to use the default comparison", out)
+	}
+	b, err := in.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	out = Empty(Compare(reverse)).Add("x")
+	if err := out.UnmarshalBinary(b); err != nil || out.String() != "{ c b a }" {
+		t.Fatal("expected the decoded set to keep its comparison", out, err)
+	}
+	if err := Empty().UnmarshalBinary(b); err != errDecodeEmpty {
+		t.Fatal("expected decoding into Empty() to fail", err)
+	}
+}
@@ -0,0 +1,56 @@
+package treeset
+
+import (
+	"errors"
+
+	"jsouthworth.net/go/immutable/internal/jsonenc"
+)
+
+var errDecodeEmpty = errors.New("can not decode into the shared empty set")
+
+// MarshalJSON encodes the set as a JSON array of its elements in
+// sorted order.
+func (s *Set) MarshalJSON() ([]byte, error) {
+	return jsonenc.EncodeArray(func(yield func(interface{}) bool) {
+		s.Range(func(elem interface{}) bool {
+			return yield(elem)
+		})
+	}, false)
+}
+
+// UnmarshalJSON replaces the contents of the set with the elements
+// of a JSON array. Elements are decoded as encoding/json decodes
+// into an empty interface. If the set was made with options the
+// decoded set keeps them, otherwise the default options are used.
+// Decoding null leaves the set unchanged.
+//
+// UnmarshalJSON overwrites the set in place, so it must only be used
+// on a set that is not shared, such as the one encoding/json
+// allocates when decoding into a nil *Set.
+func (s *Set) UnmarshalJSON(data []byte) error {
+	return s.DecodeJSON(data, nil)
+}
+
+// DecodeJSON is UnmarshalJSON with each element decoded by decode
+// instead. A nil decode behaves like UnmarshalJSON.
+func (s *Set) DecodeJSON(data []byte, decode func(data []byte) (interface{}, error)) error {
+	if s == Empty() {
+		return errDecodeEmpty
+	}
+	if jsonenc.IsNull(data) {
+		return nil
+	}
+	out := Empty()
+	if s.root != nil {
+		out = s.emptyLike(nil)
+	}
+	t := out.AsTransient()
+	err := jsonenc.DecodeArray(data, decode, func(elem interface{}) {
+		t = t.Add(elem)
+	})
+	if err != nil {
+		return err
+	}
+	*s = *t.AsPersistent()
+	return nil
+}
@@ -0,0 +1,36 @@
+package treeset
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestJSON(t *testing.T) {
+	s := New("c", "a", "b")
+	b, err := json.Marshal(s)
+	if err != nil || string(b) != `["a","b","c"]` {
+		t.Fatal("unexpected encoding", string(b), err)
+	}
+	var out *Set
+	if err := json.Unmarshal(b, &out); err != nil || !out.Equal(s) {
+		t.Fatal("unexpected decoding", out, err)
+	}
+	if err := Empty().UnmarshalJSON(b); err != errDecodeEmpty {
+		t.Fatal("expected decoding into Empty() to fail", err)
+	}
+}
+
+func TestJSONKeepsOptions(t *testing.T) {
+	reverse := func(a, b interface{}) int {
+		return strings.Compare(b.(string), a.(string))
+	}
+	out := Empty(Compare(reverse)).Add("x")
+	if err := json.Unmarshal([]byte(`["a","c","b"]`), out); err != nil {
+		t.Fatal(err)
+	}
+	b, _ := json.Marshal(out)
+	if string(b) != `["c","b","a"]` {
+		t.Fatal("expected the decoded set to keep its comparison", string(b))
+	}
+}
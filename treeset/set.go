@@ -16,7 +16,19 @@ import (
 	"jsouthworth.net/go/seq"
 )
 
+var errOutOfBounds = errors.New("out of bounds")
+var errOverlap = errors.New("joined sets must not overlap")
+var errCompareMismatch = errors.New("joined sets must share an ordering")
+var errNodeSizeMismatch = errors.New("joined sets must use the same node size")
+
+// ErrUnsorted is returned by FromSorted when the elements are not in
+// strictly increasing order.
+var ErrUnsorted = errors.New("elements are not in strictly increasing order")
 var errRangeSig = errors.New("Range requires a function: func(v vT) bool or func(v vT)")
+var errReduceSig = errors.New("Reduce requires a function: func(init iT, v vT) oT")
+var errFilterSig = errors.New("Filter requires a function: func(v vT) bool")
+var errMapSig = errors.New("Map requires a function: func(v vT) oT")
+var errTransformSig = errors.New("Transform requires functions: func(t *TSet) *TSet or func(t *TSet)")
 
 // Set is a persistent ordered set implementation.
 type Set struct {
@@ -35,16 +47,21 @@ func defaultEqual(a, b interface{}) bool {
 	return dyn.Compare(a, b) == 0
 }
 
+var defaultOrder = btree.Compare(defaultCompare)
+
 var empty = Set{
 	root: btree.Empty(
-		btree.Compare(defaultCompare),
+		defaultOrder,
 		btree.Equal(defaultEqual),
 	),
 	eq: defaultEqual,
 }
 
 type setOptions struct {
-	compare cmpFunc
+	compare      cmpFunc
+	order        btree.Option
+	assumeSorted bool
+	nodeSize     int
 }
 
 // Option is a type that allows changes to pluggable parts of the
@@ -54,10 +71,47 @@ type Option func(*setOptions)
 // Compare is an option to the Empty function that will allow
 // one to specify a different comparison operator instead
 // of the default which is from the dyn library. This is used
-// for keys.
+// for keys. Sets made with the same Option value returned by Compare,
+// or with CompareOf, share an ordering. Union and the other set
+// operations panic on sets with different orderings, even if their
+// comparison functions agree.
 func Compare(cmp func(k1, k2 interface{}) int) Option {
+	order := btree.Compare(cmp)
 	return func(o *setOptions) {
 		o.compare = cmp
+		o.order = order
+	}
+}
+
+// CompareOf is an option to the Empty function that gives the new set
+// the ordering of s. Unlike supplying s.Comparator() to Compare, the
+// sets share an ordering and so may be joined with Join or merged by
+// the set operations.
+func CompareOf(s *Set) Option {
+	cmp, order := s.Comparator(), s.root.Ordering()
+	return func(o *setOptions) {
+		o.compare = cmp
+		o.order = order
+	}
+}
+
+// NodeSize is an option to the Empty function that sets the maximum
+// number of elements held by each node of the underlying B-tree.
+// Wider nodes suit elements that are cheap to compare while narrower
+// nodes suit elements that are expensive to compare. The size must be
+// between 16 and 1024, Empty will panic otherwise. The default is 64.
+func NodeSize(size int) Option {
+	return func(o *setOptions) {
+		o.nodeSize = size
+	}
+}
+
+// AssumeSorted is an option to the FromSorted function that skips
+// checking the order of the elements. Supplying elements out of order
+// with this option results in a set that behaves unpredictably.
+func AssumeSorted() Option {
+	return func(o *setOptions) {
+		o.assumeSorted = true
 	}
 }
 
@@ -70,7 +124,9 @@ func Empty(options ...Option) *Set {
 	}
 
 	opts := setOptions{
-		compare: defaultCompare,
+		compare:  defaultCompare,
+		order:    defaultOrder,
+		nodeSize: btree.DefaultNodeSize,
 	}
 	for _, opt := range options {
 		opt(&opts)
@@ -82,8 +138,9 @@ func Empty(options ...Option) *Set {
 
 	return &Set{
 		root: btree.Empty(
-			btree.Compare(opts.compare),
+			opts.order,
 			btree.Equal(eq),
+			btree.NodeSize(opts.nodeSize),
 		),
 		eq: eq,
 	}
@@ -149,6 +206,27 @@ func From(value interface{}, options ...Option) *Set {
 	}
 }
 
+// FromSorted builds a set from elements that are in strictly
+// increasing order. The set is built bottom-up in linear time with
+// each node filled to capacity, which is considerably faster than
+// adding the elements one at a time. ErrUnsorted is returned if the
+// elements are out of order unless the AssumeSorted option is
+// supplied.
+func FromSorted(elems []interface{}, options ...Option) (*Set, error) {
+	var opts setOptions
+	for _, opt := range options {
+		opt(&opts)
+	}
+	out := Empty(options...)
+	if !opts.assumeSorted && !out.root.IsSorted(elems) {
+		return nil, ErrUnsorted
+	}
+	return &Set{
+		root: out.root.FromSorted(elems),
+		eq:   out.eq,
+	}, nil
+}
+
 func setFromSequence(coll seq.Sequence, options ...Option) *Set {
 	if coll == nil {
 		return Empty(options...)
@@ -208,6 +286,58 @@ func (s *Set) At(elem interface{}) interface{} {
 	return v
 }
 
+// Comparator returns the function used to order the elements of the
+// set. Supplying it to Empty with the Compare option creates a set
+// whose elements are sorted the same way, but as Compare makes a new
+// ordering each time it is called the sets do not share an ordering.
+// Use CompareOf to make a set that does.
+func (s *Set) Comparator() func(k1, k2 interface{}) int {
+	return s.root.Comparator()
+}
+
+// Rank returns the number of elements in the set that are less than
+// elem. If elem is in the set this is its index in sorted order.
+func (s *Set) Rank(elem interface{}) int {
+	return s.root.Rank(elem)
+}
+
+// Nth returns the element at index i of the set in sorted order.
+// Nth will panic if i is out of bounds.
+func (s *Set) Nth(i int) interface{} {
+	if i < 0 || i >= s.root.Length() {
+		panic(errOutOfBounds)
+	}
+	return s.root.Nth(i)
+}
+
+// SplitAt divides the set into the elements that are less than elem
+// and the elements that are not. Both sets share structure with s and
+// are produced in logarithmic time.
+func (s *Set) SplitAt(elem interface{}) (*Set, *Set) {
+	below, above := s.root.Split(elem)
+	return &Set{root: below, eq: s.eq}, &Set{root: above, eq: s.eq}
+}
+
+// Join concatenates two sets in logarithmic time. Every element in
+// left must be less than every element in right, the sets must share
+// an ordering, see Compare, and they must use the same node size. Join
+// will panic otherwise.
+func Join(left, right *Set) *Set {
+	if !left.root.SameOrder(right.root) {
+		panic(errCompareMismatch)
+	}
+	if !left.root.SameNodeSize(right.root) {
+		panic(errNodeSizeMismatch)
+	}
+	if !left.root.Before(right.root) {
+		panic(errOverlap)
+	}
+	return &Set{
+		root: btree.Join(left.root, right.root),
+		eq:   left.eq,
+	}
+}
+
 // Contains returns true if the element is in the set, false otherwise.
 func (s *Set) Contains(elem interface{}) bool {
 	return s.root.Contains(elem)
@@ -293,6 +423,141 @@ func (s *Set) Range(do interface{}) {
 	}
 }
 
+// Reduce is a fast mechanism for reducing a Set. Reduce visits the
+// elements in order and can take the following types as the fn:
+//
+// func(init interface{}, value interface{}) interface{}
+// func(init iT, v vT) oT
+//
+// Reduce will panic if given any other function type.
+func (s *Set) Reduce(fn interface{}, init interface{}) interface{} {
+	// NOTE: Update other functions using the same pattern
+	//       when modifying the below.
+	//       This code is inlined to avoid heap allocation of
+	//       the closure.
+	var rFn func(r, v interface{}) interface{}
+	switch f := fn.(type) {
+	case func(res, val interface{}) interface{}:
+		rFn = f
+	default:
+		rFn = genReduceFunc(fn)
+	}
+	res := init
+	iter := s.Iterator()
+	for iter.HasNext() {
+		res = rFn(res, iter.Next())
+	}
+	return res
+}
+
+func genReduceFunc(fn interface{}) func(r, v interface{}) interface{} {
+	rv := reflect.ValueOf(fn)
+	if rv.Kind() != reflect.Func {
+		panic(errReduceSig)
+	}
+	rt := rv.Type()
+	if rt.NumIn() != 2 {
+		panic(errReduceSig)
+	}
+	if rt.NumOut() != 1 {
+		panic(errReduceSig)
+	}
+	return func(r, v interface{}) interface{} {
+		return dyn.Apply(fn, r, v)
+	}
+}
+
+// Filter returns a set holding the elements for which pred returns
+// true. The result uses the same comparison function as s. Filter
+// can take the following types as the pred:
+//
+// func(value interface{}) bool
+// func(value T) bool
+//
+// Filter will panic if given any other function type.
+func (s *Set) Filter(pred interface{}) *Set {
+	var keep func(interface{}) bool
+	switch f := pred.(type) {
+	case func(value interface{}) bool:
+		keep = f
+	default:
+		keep = genFilterFunc(pred)
+	}
+	var out []interface{}
+	iter := s.Iterator()
+	for iter.HasNext() {
+		elem := iter.Next()
+		if keep(elem) {
+			out = append(out, elem)
+		}
+	}
+	if len(out) == s.Length() {
+		return s
+	}
+	return s.fromSorted(out)
+}
+
+func genFilterFunc(fn interface{}) func(v interface{}) bool {
+	rv := reflect.ValueOf(fn)
+	if rv.Kind() != reflect.Func {
+		panic(errFilterSig)
+	}
+	rt := rv.Type()
+	if rt.NumIn() != 1 || rt.NumOut() != 1 ||
+		rt.Out(0).Kind() != reflect.Bool {
+		panic(errFilterSig)
+	}
+	return func(v interface{}) bool {
+		return dyn.Apply(fn, v).(bool)
+	}
+}
+
+// Map returns a set holding the result of calling fn on each element
+// of s. The result uses the same comparison function as s unless
+// options are supplied, in which case they are used as they would be
+// by Empty. Map can take the following types as the fn:
+//
+// func(value interface{}) interface{}
+// func(value T) oT
+//
+// Map will panic if given any other function type.
+func (s *Set) Map(fn interface{}, options ...Option) *Set {
+	mapFn := genMapFunc(fn)
+	out := s.emptyLike(options).AsTransient()
+	iter := s.Iterator()
+	for iter.HasNext() {
+		out = out.Add(mapFn(iter.Next()))
+	}
+	return out.AsPersistent()
+}
+
+func (s *Set) emptyLike(options []Option) *Set {
+	if len(options) != 0 {
+		return Empty(options...)
+	}
+	return &Set{
+		root: s.root.Clear(),
+		eq:   s.eq,
+	}
+}
+
+func genMapFunc(fn interface{}) func(v interface{}) interface{} {
+	if f, ok := fn.(func(value interface{}) interface{}); ok {
+		return f
+	}
+	rv := reflect.ValueOf(fn)
+	if rv.Kind() != reflect.Func {
+		panic(errMapSig)
+	}
+	rt := rv.Type()
+	if rt.NumIn() != 1 || rt.NumOut() != 1 {
+		panic(errMapSig)
+	}
+	return func(v interface{}) interface{} {
+		return dyn.Apply(fn, v)
+	}
+}
+
 // Length returns the elements in the set.
 func (s *Set) Length() int {
 	return s.root.Length()
@@ -360,6 +625,14 @@ func (s *Set) Iterator() Iterator {
 	}
 }
 
+// ReverseIterator provides a mutable iterator over the set that
+// visits the elements from largest to smallest.
+func (s *Set) ReverseIterator() Iterator {
+	return Iterator{
+		impl: s.root.ReverseIterator(),
+	}
+}
+
 // AsTransient will return a transient map that shares
 // structure with the persistent set.
 func (s *Set) AsTransient() *TSet {
@@ -379,10 +652,22 @@ func (s *Set) MakeTransient() interface{} {
 // on the persistent set. It does this by making a transient
 // set and calling each action on it, then converting it back
 // to a persistent set.
-func (m *Set) Transform(actions ...func(*TSet)) *Set {
+// Each action may be a func(*TSet) *TSet, whose result is passed to
+// the following action unless it is nil, or a func(*TSet).
+// Transform will panic if given any other type.
+func (m *Set) Transform(actions ...interface{}) *Set {
 	out := m.AsTransient()
 	for _, action := range actions {
-		action(out)
+		switch fn := action.(type) {
+		case func(*TSet) *TSet:
+			if next := fn(out); next != nil {
+				out = next
+			}
+		case func(*TSet):
+			fn(out)
+		default:
+			panic(errTransformSig)
+		}
 	}
 	return out.AsPersistent()
 }
@@ -404,6 +689,31 @@ func (i *Iterator) HasNext() bool {
 	return i.impl.HasNext()
 }
 
+// Prev provides the previous element and decrements the cursor.
+func (i *Iterator) Prev() interface{} {
+	return i.impl.Prev()
+}
+
+// HasPrev is true when there are elements before the cursor.
+func (i *Iterator) HasPrev() bool {
+	return i.impl.HasPrev()
+}
+
+// Seek moves the cursor so that the next call to Next returns the
+// smallest element not less than elem. For reverse iterators it is
+// the largest element not greater than elem.
+func (i *Iterator) Seek(elem interface{}) {
+	i.impl.Seek(elem)
+}
+
+// Clone returns a copy of the iterator at the same position. The
+// copy advances independently of the original.
+func (i *Iterator) Clone() Iterator {
+	return Iterator{
+		impl: i.impl.Clone(),
+	}
+}
+
 type sequence struct {
 	iter btree.Iterator
 }
@@ -415,16 +725,17 @@ func sequenceNew(iter btree.Iterator) *sequence {
 }
 
 func (s *sequence) First() interface{} {
-	return s.iter.Next()
+	iter := s.iter.Clone()
+	return iter.Next()
 }
 
 func (s *sequence) Next() seq.Sequence {
-	new := &(*s)
-	hasNext := new.iter.HasNext()
-	if !hasNext {
+	iter := s.iter.Clone()
+	iter.Next()
+	if !iter.HasNext() {
 		return nil
 	}
-	return new
+	return sequenceNew(iter)
 }
 
 func (s *sequence) String() string {
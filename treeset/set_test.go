@@ -3,12 +3,15 @@ package treeset
 import (
 	"fmt"
 	"reflect"
+	"strconv"
+	"strings"
 	"testing"
 
 	"github.com/leanovate/gopter"
 	"github.com/leanovate/gopter/gen"
 	"github.com/leanovate/gopter/prop"
 	"jsouthworth.net/go/dyn"
+	"jsouthworth.net/go/immutable/compare"
 	"jsouthworth.net/go/immutable/vector"
 	"jsouthworth.net/go/seq"
 )
@@ -556,3 +559,425 @@ func TestCustomComparator(t *testing.T) {
 		t.Fatal("Sets should not have been equal")
 	}
 }
+
+func TestRankNth(t *testing.T) {
+	s := makeIntSet(1000)
+	parameters := gopter.DefaultTestParameters()
+	properties := gopter.NewProperties(parameters)
+	properties.Property("Nth(i) == i", prop.ForAll(
+		func(i int) bool {
+			return s.Nth(i) == i && s.Rank(i) == i
+		},
+		gen.IntRange(0, 999),
+	))
+	properties.Property("Nth after Delete shifts later elements", prop.ForAll(
+		func(i int) bool {
+			d := s.Delete(i)
+			return i == 999 || d.Nth(i) == i+1
+		},
+		gen.IntRange(0, 999),
+	))
+	properties.TestingRun(t)
+}
+
+func TestNthOutOfBounds(t *testing.T) {
+	defer func() {
+		if r := recover(); r != errOutOfBounds {
+			t.Fatalf("expected errOutOfBounds, got %v", r)
+		}
+	}()
+	makeIntSet(10).Nth(-1)
+}
+
+func TestFromSorted(t *testing.T) {
+	elems := make([]interface{}, 1000)
+	for i := range elems {
+		elems[i] = 999 - i
+	}
+	s, err := FromSorted(elems, Compare(func(a, b interface{}) int {
+		return dyn.Compare(b, a)
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.Length() != 1000 || s.Nth(0) != 999 || s.Nth(999) != 0 {
+		t.Fatal("FromSorted did not produce the expected set")
+	}
+	if _, err := FromSorted(elems); err != ErrUnsorted {
+		t.Fatalf("expected ErrUnsorted, got %v", err)
+	}
+}
+
+func TestSetAlgebra(t *testing.T) {
+	native := func(s *Set) map[int]bool {
+		out := make(map[int]bool)
+		s.Range(func(elem interface{}) {
+			out[elem.(int)] = true
+		})
+		return out
+	}
+	fromInts := func(elems []int) *Set {
+		out := Empty().AsTransient()
+		for _, elem := range elems {
+			out.Add(elem)
+		}
+		return out.AsPersistent()
+	}
+	expect := func(got *Set, want func(inA, inB bool) bool, a, b map[int]bool) bool {
+		g := native(got)
+		for k := range a {
+			if g[k] != want(true, b[k]) {
+				return false
+			}
+		}
+		for k := range b {
+			if g[k] != want(a[k], true) {
+				return false
+			}
+		}
+		for k := range g {
+			if !a[k] && !b[k] {
+				return false
+			}
+		}
+		return got.Length() == len(g)
+	}
+	genInts := gen.SliceOf(gen.IntRange(0, 2000))
+	genSizedInts := gen.IntRange(0, 3000).FlatMap(func(n interface{}) gopter.Gen {
+		return gen.SliceOfN(n.(int), gen.IntRange(0, 2000))
+	}, reflect.TypeOf([]int{}))
+	ops := []struct {
+		name string
+		op   func(a, b *Set) *Set
+		want func(inA, inB bool) bool
+	}{
+		{"Union", (*Set).Union, func(a, b bool) bool { return a || b }},
+		{"Intersection", (*Set).Intersection, func(a, b bool) bool { return a && b }},
+		{"Difference", (*Set).Difference, func(a, b bool) bool { return a && !b }},
+		{"SymmetricDifference", (*Set).SymmetricDifference, func(a, b bool) bool { return a != b }},
+	}
+	parameters := gopter.DefaultTestParameters()
+	properties := gopter.NewProperties(parameters)
+	for _, op := range ops {
+		op := op
+		properties.Property(op.name+" agrees with native sets", prop.ForAll(
+			func(as, bs []int, swap bool) bool {
+				a, b := fromInts(as), fromInts(bs)
+				if swap {
+					a, b = b, a
+				}
+				return expect(op.op(a, b), op.want, native(a), native(b))
+			},
+			genSizedInts,
+			genInts,
+			gen.Bool(),
+		))
+	}
+	properties.Property("IsSubset agrees with native sets", prop.ForAll(
+		func(as, bs []int) bool {
+			a, b := fromInts(as), fromInts(bs)
+			na, nb := native(a), native(b)
+			subset := true
+			for k := range na {
+				subset = subset && nb[k]
+			}
+			return a.IsSubset(b) == subset &&
+				a.IsSubset(a.Union(b)) &&
+				a.Intersection(b).IsSubset(b)
+		},
+		genInts,
+		genSizedInts,
+	))
+	properties.Property("Disjoint agrees with Intersection", prop.ForAll(
+		func(as, bs []int) bool {
+			a, b := fromInts(as), fromInts(bs)
+			return a.Disjoint(b) == (a.Intersection(b).Length() == 0) &&
+				a.Difference(b).Disjoint(b)
+		},
+		genSizedInts,
+		genInts,
+	))
+	properties.TestingRun(t)
+}
+
+func TestSetAlgebraOrderMismatch(t *testing.T) {
+	// Both comparators are closures made by the same function
+	// literals so they can not be told apart by their code.
+	mod10 := func(v interface{}) interface{} { return v.(int) % 10 }
+	div10 := func(v interface{}) interface{} { return v.(int) / 10 }
+	byMod := Empty(Compare(compare.Lexicographic(
+		compare.ByField(mod10, dyn.Compare), dyn.Compare))).Add(1)
+	byDiv := Empty(Compare(compare.Lexicographic(
+		compare.ByField(div10, dyn.Compare), dyn.Compare))).Add(1)
+	// Equal comparators that were supplied separately do not share
+	// an ordering either.
+	separate := Empty(Compare(dyn.Compare)).Add(1)
+	tests := []struct {
+		name string
+		do   func(a, b *Set)
+	}{
+		{"Union", func(a, b *Set) { a.Union(b) }},
+		{"Intersection", func(a, b *Set) { a.Intersection(b) }},
+		{"Difference", func(a, b *Set) { a.Difference(b) }},
+		{"SymmetricDifference", func(a, b *Set) { a.SymmetricDifference(b) }},
+		{"IsSubset", func(a, b *Set) { a.IsSubset(b) }},
+		{"Disjoint", func(a, b *Set) { a.Disjoint(b) }},
+	}
+	for _, test := range tests {
+		for _, pair := range [][2]*Set{{byMod, byDiv}, {New(2), separate}} {
+			t.Run(test.name, func(t *testing.T) {
+				defer func() {
+					if r := recover(); r != errOrderMismatch {
+						t.Fatal("expected", errOrderMismatch, "got", r)
+					}
+				}()
+				test.do(pair[0], pair[1])
+			})
+		}
+	}
+}
+
+// tagged is an element whose tag is ignored by the ordering so that
+// sets can hold distinct but equal elements.
+type tagged struct {
+	key int
+	tag string
+}
+
+func TestSetAlgebraKeepsReceiver(t *testing.T) {
+	base := Empty(Compare(func(a, b interface{}) int {
+		return dyn.Compare(a.(tagged).key, b.(tagged).key)
+	}))
+	build := func(n int, tag string) *Set {
+		return base.Transform(func(t *TSet) {
+			for i := 0; i < n; i++ {
+				t.Add(tagged{i * 2, tag})
+			}
+		})
+	}
+	for _, sizes := range [][2]int{{3, 1000}, {1000, 3}, {500, 600}} {
+		s, other := build(sizes[0], "s"), build(sizes[1], "other")
+		for name, got := range map[string]*Set{
+			"Union":               s.Union(other),
+			"Intersection":        s.Intersection(other),
+			"SymmetricDifference": s.SymmetricDifference(other.Add(tagged{-2, "other"})),
+		} {
+			got.Range(func(elem interface{}) {
+				e := elem.(tagged)
+				if e.key/2 < sizes[0] && e.key >= 0 && e.tag != "s" {
+					t.Fatalf("%s%v: expected %v to be taken from s", name, sizes, e)
+				}
+			})
+		}
+	}
+}
+
+func TestSplitAtJoin(t *testing.T) {
+	s := makeIntSet(1000)
+	parameters := gopter.DefaultTestParameters()
+	properties := gopter.NewProperties(parameters)
+	properties.Property("SplitAt(k) divides the elements at k", prop.ForAll(
+		func(k int) bool {
+			below, above := s.SplitAt(k)
+			ok := below.Length()+above.Length() == 1000
+			below.Range(func(elem interface{}) {
+				ok = ok && elem.(int) < k
+			})
+			above.Range(func(elem interface{}) {
+				ok = ok && elem.(int) >= k
+			})
+			return ok && Join(below, above).Equal(s)
+		},
+		gen.IntRange(-10, 1010),
+	))
+	properties.TestingRun(t)
+}
+
+func TestJoinOverlap(t *testing.T) {
+	defer func() {
+		if r := recover(); r != errOverlap {
+			t.Fatalf("expected errOverlap, got %v", r)
+		}
+	}()
+	Join(New(1, 5), New(3))
+}
+
+func TestJoinMismatch(t *testing.T) {
+	tests := []struct {
+		name        string
+		left, right *Set
+		err         error
+	}{
+		{
+			"ordering",
+			Empty(Compare(compare.Reverse(dyn.Compare))).Add(2),
+			Empty(Compare(compare.Reverse(dyn.Compare))).Add(1),
+			errCompareMismatch,
+		},
+		{
+			"node size",
+			Empty(NodeSize(16)).Add(1),
+			Empty(NodeSize(32)).Add(2),
+			errNodeSizeMismatch,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			defer func() {
+				if r := recover(); r != test.err {
+					t.Fatal("expected", test.err, "got", r)
+				}
+			}()
+			Join(test.left, test.right)
+		})
+	}
+}
+
+func TestReduceFilterMap(t *testing.T) {
+	s := makeIntSet(100)
+	parameters := gopter.DefaultTestParameters()
+	properties := gopter.NewProperties(parameters)
+	properties.Property("Reduce visits elements in order", prop.ForAll(
+		func(n int) bool {
+			set := makeIntSet(n)
+			out := set.Reduce(func(res, val interface{}) interface{} {
+				return append(res.([]interface{}), val)
+			}, []interface{}(nil)).([]interface{})
+			sum := set.Reduce(func(res, val int) int {
+				return res + val
+			}, 0)
+			ok := len(out) == n && sum == n*(n-1)/2
+			for i, v := range out {
+				ok = ok && v == i
+			}
+			return ok
+		},
+		gen.IntRange(0, 1000),
+	))
+	properties.Property("Filter keeps matching elements", prop.ForAll(
+		func(m int) bool {
+			even := s.Filter(func(v int) bool {
+				return v%m == 0
+			})
+			ok := even.Length() == (99/m)+1
+			even.Range(func(v int) {
+				ok = ok && v%m == 0
+			})
+			return ok
+		},
+		gen.IntRange(1, 100),
+	))
+	properties.Property("Map applies fn to each element", prop.ForAll(
+		func(m int) bool {
+			mod := s.Map(func(v interface{}) interface{} {
+				return v.(int) % m
+			})
+			return mod.Length() == m && mod.Nth(m-1) == m-1
+		},
+		gen.IntRange(1, 100),
+	))
+	properties.TestingRun(t)
+}
+
+func TestMapWithCompare(t *testing.T) {
+	s := New(1, 2, 3).Map(strconv.Itoa, Compare(func(a, b interface{}) int {
+		return strings.Compare(b.(string), a.(string))
+	}))
+	if s.String() != "{ 3 2 1 }" {
+		t.Fatalf("unexpected set %s", s)
+	}
+}
+
+func TestTransientReduceFilterMap(t *testing.T) {
+	tr := makeIntSet(10).AsTransient()
+	tr.Filter(func(v int) bool { return v < 5 })
+	if tr.Length() != 5 {
+		t.Fatalf("expected 5 elements, got %d", tr.Length())
+	}
+	sum := tr.Map(func(v int) int { return v * 2 }).
+		Reduce(func(res, v int) int { return res + v }, 0)
+	if sum != 20 {
+		t.Fatalf("expected 20, got %v", sum)
+	}
+}
+
+func TestFilterMapSignatures(t *testing.T) {
+	expectPanic := func(err error, fn func()) {
+		defer func() {
+			if r := recover(); r != err {
+				t.Fatalf("expected %v, got %v", err, r)
+			}
+		}()
+		fn()
+	}
+	expectPanic(errFilterSig, func() { New(1).Filter(func(int) int { return 0 }) })
+	expectPanic(errMapSig, func() { New(1).Map(1) })
+	expectPanic(errReduceSig, func() { New(1).Reduce(func(int) int { return 0 }, 0) })
+}
+
+func TestTransformForms(t *testing.T) {
+	s := New(1, 2, 3, 4).Transform(
+		func(t *TSet) { t.Delete(1).Add(5) },
+		func(t *TSet) *TSet {
+			return t.Filter(func(elem int) bool { return elem%2 == 1 })
+		},
+		func(t *TSet) *TSet {
+			t.Add(7)
+			return nil
+		},
+	)
+	if s.String() != "{ 3 5 7 }" {
+		t.Fatalf("unexpected set %s", s)
+	}
+	defer func() {
+		if r := recover(); r != errTransformSig {
+			t.Fatalf("expected errTransformSig, got %v", r)
+		}
+	}()
+	s.Transform(func(t *TSet) Iterator { return t.ReverseIterator() })
+}
+
+func TestComparator(t *testing.T) {
+	reverse := func(a, b interface{}) int {
+		return dyn.Compare(b, a)
+	}
+	s := Empty(Compare(reverse)).Add(1).Add(2)
+	sorted := Empty(Compare(s.Comparator())).Add(0).Add(-1)
+	if sorted.String() != "{ 0 -1 }" {
+		t.Fatalf("derived set did not keep the comparator %s", sorted)
+	}
+	func() {
+		defer func() {
+			if r := recover(); r != errCompareMismatch {
+				t.Fatal("expected Compare to make a new ordering", r)
+			}
+		}()
+		Join(s, sorted)
+	}()
+	derived := Empty(CompareOf(s)).Add(0).Add(-1)
+	if j := Join(s, derived); j.String() != "{ 2 1 0 -1 }" {
+		t.Fatal("sets made with CompareOf could not be joined", j)
+	}
+}
+
+func TestNodeSize(t *testing.T) {
+	s := Empty(NodeSize(1024)).AsTransient()
+	for i := 0; i < 10000; i++ {
+		s = s.Add(i)
+	}
+	p := s.AsPersistent()
+	for i := 0; i < 10000; i++ {
+		if !p.Contains(i) {
+			t.Fatalf("expected set to contain %d", i)
+		}
+	}
+	if p.Length() != 10000 {
+		t.Fatalf("expected 10000 elements, got %d", p.Length())
+	}
+	defer func() {
+		if r := recover(); r == nil {
+			t.Fatal("expected invalid node size to panic")
+		}
+	}()
+	Empty(NodeSize(2048))
+}
@@ -83,6 +83,14 @@ func (s *TSet) Iterator() Iterator {
 	}
 }
 
+// ReverseIterator provides a mutable iterator over the set that
+// visits the elements from largest to smallest.
+func (s *TSet) ReverseIterator() Iterator {
+	return Iterator{
+		impl: s.root.ReverseIterator(),
+	}
+}
+
 // Apply takes an arbitrary number of arguments and returns the
 // value At the first argument.  Apply allows set to be called
 // as a function by the 'dyn' library.
@@ -189,3 +197,62 @@ func (s *TSet) Range(do interface{}) {
 		cont = rangefn(elem)
 	}
 }
+
+// Reduce is a fast mechanism for reducing a TSet. Reduce takes the
+// same function types as Set.Reduce.
+func (s *TSet) Reduce(fn interface{}, init interface{}) interface{} {
+	// NOTE: Update other functions using the same pattern
+	//       when modifying the below.
+	//       This code is inlined to avoid heap allocation of
+	//       the closure.
+	var rFn func(r, v interface{}) interface{}
+	switch f := fn.(type) {
+	case func(res, val interface{}) interface{}:
+		rFn = f
+	default:
+		rFn = genReduceFunc(fn)
+	}
+	res := init
+	iter := s.Iterator()
+	for iter.HasNext() {
+		res = rFn(res, iter.Next())
+	}
+	return res
+}
+
+// Filter removes the elements for which pred returns false from the
+// set. Filter takes the same function types as Set.Filter.
+func (s *TSet) Filter(pred interface{}) *TSet {
+	var keep func(interface{}) bool
+	switch f := pred.(type) {
+	case func(value interface{}) bool:
+		keep = f
+	default:
+		keep = genFilterFunc(pred)
+	}
+	var drop []interface{}
+	iter := s.Iterator()
+	for iter.HasNext() {
+		elem := iter.Next()
+		if !keep(elem) {
+			drop = append(drop, elem)
+		}
+	}
+	for _, elem := range drop {
+		s.Delete(elem)
+	}
+	return s
+}
+
+// Map returns a new transient set holding the result of calling fn
+// on each element of s. Map takes the same function types and options
+// as Set.Map.
+func (s *TSet) Map(fn interface{}, options ...Option) *TSet {
+	mapFn := genMapFunc(fn)
+	out := s.orig.emptyLike(options).AsTransient()
+	iter := s.Iterator()
+	for iter.HasNext() {
+		out = out.Add(mapFn(iter.Next()))
+	}
+	return out
+}
@@ -0,0 +1,188 @@
+package treeset
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+
+	"jsouthworth.net/go/dyn"
+	"jsouthworth.net/go/immutable/internal/btree"
+	"jsouthworth.net/go/seq"
+)
+
+// View is a lazy, read-only window onto the elements of a Set that
+// lie between two bounds. Creating a view copies nothing; iteration
+// over a view seeks directly to the lower bound and stops at the
+// upper bound.
+type View struct {
+	root   *btree.BTree
+	eq     eqFunc
+	lo, hi btree.Bound
+}
+
+// Subset returns a view of the elements that lie between from and
+// to. The inclusive flags determine whether from and to themselves
+// are part of the view.
+func (s *Set) Subset(
+	from, to interface{},
+	fromInclusive, toInclusive bool,
+) *View {
+	return &View{
+		root: s.root,
+		eq:   s.eq,
+		lo:   makeBound(from, fromInclusive),
+		hi:   makeBound(to, toInclusive),
+	}
+}
+
+// HeadSet returns a view of the elements that are strictly less
+// than to.
+func (s *Set) HeadSet(to interface{}) *View {
+	return &View{
+		root: s.root,
+		eq:   s.eq,
+		lo:   btree.Unbounded(),
+		hi:   btree.Exclusive(to),
+	}
+}
+
+// TailSet returns a view of the elements that are greater than or
+// equal to from.
+func (s *Set) TailSet(from interface{}) *View {
+	return &View{
+		root: s.root,
+		eq:   s.eq,
+		lo:   btree.Inclusive(from),
+		hi:   btree.Unbounded(),
+	}
+}
+
+func makeBound(elem interface{}, inclusive bool) btree.Bound {
+	if inclusive {
+		return btree.Inclusive(elem)
+	}
+	return btree.Exclusive(elem)
+}
+
+// At returns the elem if it exists in the view otherwise it returns nil.
+func (v *View) At(elem interface{}) interface{} {
+	out, _ := v.Find(elem)
+	return out
+}
+
+// Contains returns true if the element is in the view, false otherwise.
+func (v *View) Contains(elem interface{}) bool {
+	return v.root.InRange(elem, v.lo, v.hi) && v.root.Contains(elem)
+}
+
+// Find will return the key if it exists in the view and whether the
+// key exists in the view. If the key is not in the view, (nil, false)
+// is returned.
+func (v *View) Find(elem interface{}) (interface{}, bool) {
+	if !v.root.InRange(elem, v.lo, v.hi) {
+		return nil, false
+	}
+	return v.root.Find(elem)
+}
+
+// Length returns the number of elements in the view.
+func (v *View) Length() int {
+	return v.root.CountRange(v.lo, v.hi)
+}
+
+// Range calls the passed in function on each element of the view.
+// The function passed in may be of any of the types accepted by
+// Set.Range.
+func (v *View) Range(do interface{}) {
+	var rangefn func(interface{}) bool
+	switch fn := do.(type) {
+	case func(value interface{}) bool:
+		rangefn = fn
+	case func(value interface{}):
+		rangefn = func(val interface{}) bool {
+			fn(val)
+			return true
+		}
+	default:
+		rv := reflect.ValueOf(do)
+		if rv.Kind() != reflect.Func {
+			panic(errRangeSig)
+		}
+		rt := rv.Type()
+		if rt.NumIn() != 1 || rt.NumOut() > 1 {
+			panic(errRangeSig)
+		}
+		if rt.NumOut() == 1 &&
+			rt.Out(0).Kind() != reflect.Bool {
+			panic(errRangeSig)
+		}
+		rangefn = func(val interface{}) bool {
+			cont := true
+			out := dyn.Apply(do, val)
+			if out != nil {
+				cont = out.(bool)
+			}
+			return cont
+		}
+	}
+	iter := v.Iterator()
+	var cont = true
+	for iter.HasNext() && cont {
+		elem := iter.Next()
+		cont = rangefn(elem)
+	}
+}
+
+// Iterator provides a mutable iterator over the view. The iterator
+// starts at the first element in the view and stops after the last.
+func (v *View) Iterator() Iterator {
+	return Iterator{
+		impl: v.root.IteratorRange(v.lo, v.hi),
+	}
+}
+
+// ReverseIterator provides a mutable iterator over the view that
+// starts at the last element in the view and stops after the first.
+func (v *View) ReverseIterator() Iterator {
+	return Iterator{
+		impl: v.root.ReverseIteratorRange(v.lo, v.hi),
+	}
+}
+
+// Seq returns a seralized sequence of interface{}
+// corresponding to the view's elements.
+func (v *View) Seq() seq.Sequence {
+	iter := v.root.IteratorRange(v.lo, v.hi)
+	if !iter.HasNext() {
+		return nil
+	}
+	return sequenceNew(iter)
+}
+
+// String returns a string serialization of the view.
+func (v *View) String() string {
+	var b strings.Builder
+	fmt.Fprint(&b, "{ ")
+	iter := v.Iterator()
+	for iter.HasNext() {
+		elem := iter.Next()
+		fmt.Fprintf(&b, "%v ", elem)
+	}
+	fmt.Fprint(&b, "}")
+	return b.String()
+}
+
+// AsSet copies the elements of the view into a new persistent set
+// that uses the same comparison function as the set the view was
+// taken from.
+func (v *View) AsSet() *Set {
+	out := v.root.Clear().AsTransient()
+	iter := v.root.IteratorRange(v.lo, v.hi)
+	for iter.HasNext() {
+		out = out.Add(iter.Next())
+	}
+	return &Set{
+		root: out.AsPersistent(),
+		eq:   v.eq,
+	}
+}
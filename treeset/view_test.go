@@ -0,0 +1,116 @@
+package treeset
+
+import (
+	"testing"
+
+	"github.com/leanovate/gopter"
+	"github.com/leanovate/gopter/gen"
+	"github.com/leanovate/gopter/prop"
+)
+
+func makeIntSet(n int) *Set {
+	s := Empty().AsTransient()
+	for i := 0; i < n; i++ {
+		s = s.Add(i)
+	}
+	return s.AsPersistent()
+}
+
+func TestSubset(t *testing.T) {
+	s := makeIntSet(1000)
+	parameters := gopter.DefaultTestParameters()
+	properties := gopter.NewProperties(parameters)
+	properties.Property("Subset(a, b, true, false) has elements in [a, b)", prop.ForAll(
+		func(a, b int) bool {
+			v := s.Subset(a, b, true, false)
+			expected := a
+			if expected < 0 {
+				expected = 0
+			}
+			ok := true
+			v.Range(func(e int) bool {
+				ok = e == expected && e < b
+				expected++
+				return ok
+			})
+			return ok && (expected >= b || expected == 1000)
+		},
+		gen.IntRange(-10, 1010),
+		gen.IntRange(-10, 1010),
+	))
+	properties.Property("view.Contains(e) respects bounds", prop.ForAll(
+		func(a, b, e int) bool {
+			v := s.Subset(a, b, false, false)
+			inRange := e > a && e < b && e >= 0 && e < 1000
+			_, found := v.Find(e)
+			return v.Contains(e) == inRange && found == inRange &&
+				(v.At(e) != nil) == inRange
+		},
+		gen.IntRange(-10, 1010),
+		gen.IntRange(-10, 1010),
+		gen.IntRange(-10, 1010),
+	))
+	properties.Property("HeadSet(e).Length()+TailSet(e).Length()==s.Length()", prop.ForAll(
+		func(e int) bool {
+			return s.HeadSet(e).Length()+s.TailSet(e).Length() ==
+				s.Length()
+		},
+		gen.IntRange(-10, 1010),
+	))
+	properties.Property("view.AsSet() equals the filtered set", prop.ForAll(
+		func(a, b int) bool {
+			expected := Empty().AsTransient()
+			for i := a; i <= b; i++ {
+				if i >= 0 && i < 1000 {
+					expected.Add(i)
+				}
+			}
+			return s.Subset(a, b, true, true).AsSet().
+				Equal(expected.AsPersistent())
+		},
+		gen.IntRange(-10, 1010),
+		gen.IntRange(-10, 1010),
+	))
+	properties.TestingRun(t)
+}
+
+func TestViewString(t *testing.T) {
+	v := makeIntSet(10).TailSet(7)
+	if v.String() != "{ 7 8 9 }" {
+		t.Fatal("unexpected view string", v.String())
+	}
+	if makeIntSet(10).HeadSet(0).Seq() != nil {
+		t.Fatal("expected empty view to have nil sequence")
+	}
+}
+
+func TestIteratorSeek(t *testing.T) {
+	s := makeIntSet(100)
+	iter := s.Iterator()
+	iter.Seek(50)
+	clone := iter.Clone()
+	if e := iter.Next(); e != 50 {
+		t.Fatalf("expected 50 after Seek(50), got %v", e)
+	}
+	if e := iter.Prev(); e != 50 {
+		t.Fatalf("expected Prev to return 50, got %v", e)
+	}
+	if e := iter.Prev(); e != 49 {
+		t.Fatalf("expected Prev to return 49, got %v", e)
+	}
+	if e := clone.Next(); e != 50 {
+		t.Fatalf("expected clone to remain at 50, got %v", e)
+	}
+	rev := s.ReverseIterator()
+	rev.Seek(10)
+	var elems []interface{}
+	for rev.HasNext() {
+		elems = append(elems, rev.Next())
+	}
+	if len(elems) != 11 || elems[0] != 10 || elems[10] != 0 {
+		t.Fatalf("unexpected reverse iteration %v", elems)
+	}
+	if rev.HasPrev() && rev.Prev() != 0 {
+		t.Fatal("expected Prev on reverse iterator to move forward")
+	}
+}
@@ -0,0 +1,52 @@
+package vector
+
+import (
+	"encoding/gob"
+
+	"jsouthworth.net/go/immutable/internal/gobenc"
+)
+
+func init() {
+	gob.Register(&Vector{})
+}
+
+// GobEncode encodes the vector for encoding/gob as its length
+// followed by its elements. Elements held in interfaces must be of
+// types registered with gob.Register; the collections of this module
+// register themselves.
+func (v *Vector) GobEncode() ([]byte, error) {
+	return gobenc.EncodeElems(func(yield func(interface{}) bool) {
+		v.Range(func(_ int, elem interface{}) bool {
+			return yield(elem)
+		})
+	})
+}
+
+// GobDecode replaces the contents of the vector with data produced
+// by GobEncode. Like UnmarshalJSON it overwrites the vector in place.
+func (v *Vector) GobDecode(data []byte) error {
+	if v == Empty() {
+		return errDecodeEmpty
+	}
+	out := Empty().AsTransient()
+	err := gobenc.DecodeElems(data, func(elem interface{}) {
+		out = out.Append(elem)
+	})
+	if err != nil {
+		return err
+	}
+	*v = *out.AsPersistent()
+	return nil
+}
+
+// MarshalBinary implements encoding.BinaryMarshaler with the
+// encoding used by GobEncode.
+func (v *Vector) MarshalBinary() ([]byte, error) {
+	return v.GobEncode()
+}
+
+// UnmarshalBinary implements encoding.BinaryUnmarshaler. It is the
+// same as GobDecode.
+func (v *Vector) UnmarshalBinary(data []byte) error {
+	return v.GobDecode(data)
+}
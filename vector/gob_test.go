@@ -0,0 +1,83 @@
+package vector
+
+import (
+	"bytes"
+	"encoding/gob"
+	"testing"
+
+	"github.com/leanovate/gopter"
+	"github.com/leanovate/gopter/gen"
+	"github.com/leanovate/gopter/prop"
+	"jsouthworth.net/go/immutable/hashset"
+	"jsouthworth.net/go/immutable/treemap"
+)
+
+func TestGob(t *testing.T) {
+	parameters := gopter.DefaultTestParameters()
+	properties := gopter.NewProperties(parameters)
+	properties.Property("decode(encode(v)) == v", prop.ForAll(
+		func(elems []int) bool {
+			v := From(elems)
+			var buf bytes.Buffer
+			if err := gob.NewEncoder(&buf).Encode(v); err != nil {
+				return false
+			}
+			var out *Vector
+			if err := gob.NewDecoder(&buf).Decode(&out); err != nil {
+				return false
+			}
+			return out.Equal(v)
+		},
+		gen.SliceOf(gen.Int()),
+	))
+	properties.TestingRun(t)
+}
+
+func TestGobNested(t *testing.T) {
+	v := New(1, "a", nil, New(2),
+		treemap.New("k", hashset.New(1, "b", New(3))))
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(struct{ V *Vector }{v}); err != nil {
+		t.Fatal(err)
+	}
+	var out struct{ V *Vector }
+	if err := gob.NewDecoder(&buf).Decode(&out); err != nil {
+		t.Fatal(err)
+	}
+	if !out.V.Equal(v) {
+		t.Fatal("unexpected vector", out.V)
+	}
+	m, ok := out.V.At(4).(*treemap.Map)
+	if !ok {
+		t.Fatal("expected a nested map", out.V.At(4))
+	}
+	s, ok := m.At("k").(*hashset.Set)
+	if !ok || !s.Contains(1) || !s.Contains("b") {
+		t.Fatal("expected a set nested in the map", m.At("k"))
+	}
+}
+
+func TestBinary(t *testing.T) {
+	b, err := Empty().MarshalBinary()
+	if err != nil || len(b) != 1 {
+		t.Fatal("expected the empty vector to encode as its count", b, err)
+	}
+	v := New(1, 2)
+	b, err = v.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	out := new(Vector)
+	if err := out.UnmarshalBinary(b); err != nil || !out.Equal(v) {
+		t.Fatal("unexpected decoding", out, err)
+	}
+	if err := out.UnmarshalBinary(b[:1]); err == nil {
+		t.Fatal("expected an error for truncated data")
+	}
+	if err := out.UnmarshalBinary(nil); err == nil {
+		t.Fatal("expected an error for no data")
+	}
+	if err := Empty().UnmarshalBinary(b); err != errDecodeEmpty {
+		t.Fatal("expected decoding into Empty() to fail", err)
+	}
+}
@@ -0,0 +1,50 @@
+package vector
+
+import (
+	"errors"
+
+	"jsouthworth.net/go/immutable/internal/jsonenc"
+)
+
+var errDecodeEmpty = errors.New("can not decode into the shared empty vector")
+
+// MarshalJSON encodes the vector as a JSON array of its elements.
+func (v *Vector) MarshalJSON() ([]byte, error) {
+	return jsonenc.EncodeArray(func(yield func(interface{}) bool) {
+		v.Range(func(_ int, elem interface{}) bool {
+			return yield(elem)
+		})
+	}, false)
+}
+
+// UnmarshalJSON replaces the contents of the vector with the
+// elements of a JSON array. Elements are decoded as encoding/json
+// decodes into an empty interface. Decoding null leaves the vector
+// unchanged.
+//
+// UnmarshalJSON overwrites the vector in place, so it must only be
+// used on a vector that is not shared, such as the one encoding/json
+// allocates when decoding into a nil *Vector.
+func (v *Vector) UnmarshalJSON(data []byte) error {
+	return v.DecodeJSON(data, nil)
+}
+
+// DecodeJSON is UnmarshalJSON with each element decoded by decode
+// instead. A nil decode behaves like UnmarshalJSON.
+func (v *Vector) DecodeJSON(data []byte, decode func(data []byte) (interface{}, error)) error {
+	if v == Empty() {
+		return errDecodeEmpty
+	}
+	if jsonenc.IsNull(data) {
+		return nil
+	}
+	out := Empty().AsTransient()
+	err := jsonenc.DecodeArray(data, decode, func(elem interface{}) {
+		out = out.Append(elem)
+	})
+	if err != nil {
+		return err
+	}
+	*v = *out.AsPersistent()
+	return nil
+}
@@ -0,0 +1,68 @@
+package vector
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/leanovate/gopter"
+	"github.com/leanovate/gopter/gen"
+	"github.com/leanovate/gopter/prop"
+)
+
+func TestJSON(t *testing.T) {
+	parameters := gopter.DefaultTestParameters()
+	properties := gopter.NewProperties(parameters)
+	properties.Property("decode(encode(v)) == v", prop.ForAll(
+		func(elems []string) bool {
+			v := From(elems)
+			b, err := json.Marshal(v)
+			if err != nil {
+				return false
+			}
+			var out *Vector
+			if err := json.Unmarshal(b, &out); err != nil {
+				return false
+			}
+			return out.Equal(v)
+		},
+		gen.SliceOf(gen.AlphaString()),
+	))
+	properties.TestingRun(t)
+}
+
+func TestJSONNested(t *testing.T) {
+	var out struct {
+		V *Vector `json:"v"`
+	}
+	err := json.Unmarshal([]byte(`{"v":[1,"a",[2],{"b":3},null]}`), &out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out.V.Length() != 5 || out.V.At(0) != 1.0 || out.V.At(1) != "a" ||
+		out.V.At(4) != nil {
+		t.Fatal("unexpected vector", out.V)
+	}
+	if _, ok := out.V.At(2).([]interface{}); !ok {
+		t.Fatal("expected nested arrays to decode natively")
+	}
+	b, err := json.Marshal(New(1, New("a"), nil))
+	if err != nil || string(b) != `[1,["a"],null]` {
+		t.Fatal("unexpected encoding", string(b), err)
+	}
+}
+
+func TestJSONErrors(t *testing.T) {
+	if err := json.Unmarshal([]byte(`{}`), new(Vector)); err == nil {
+		t.Fatal("expected an error decoding an object")
+	}
+	if err := Empty().UnmarshalJSON([]byte(`[1]`)); err != errDecodeEmpty {
+		t.Fatal("expected decoding into Empty() to fail", err)
+	}
+	if Empty().Length() != 0 {
+		t.Fatal("the empty vector was modified")
+	}
+	v := New(1)
+	if err := json.Unmarshal([]byte(`null`), v); err != nil || v.Length() != 1 {
+		t.Fatal("expected null to leave the vector unchanged")
+	}
+}
@@ -17,6 +17,7 @@ var errEmptyVector = errors.New("empty vector")
 var errTafterP = errors.New("transient used after persistent call")
 var errRangeSig = errors.New("Range requires a function: func(v vT) bool or func(v vT)")
 var errReduceSig = errors.New("Reduce requires a function: func(init iT, v vT) oT")
+var errTransformSig = errors.New("Transform requires functions: func(t *TVector) *TVector or func(t *TVector)")
 
 const (
 	bits  = 5
@@ -475,10 +476,22 @@ func (v *Vector) Apply(args ...interface{}) interface{} {
 // on the persistent vector. It does this by making a transient
 // vector and calling each action on it, then converting it back
 // to a persistent vector.
-func (v *Vector) Transform(actions ...func(*TVector) *TVector) *Vector {
+// Each action may be a func(*TVector) *TVector, whose result is passed to
+// the following action unless it is nil, or a func(*TVector).
+// Transform will panic if given any other type.
+func (v *Vector) Transform(actions ...interface{}) *Vector {
 	out := v.AsTransient()
 	for _, action := range actions {
-		out = action(out)
+		switch fn := action.(type) {
+		case func(*TVector) *TVector:
+			if next := fn(out); next != nil {
+				out = next
+			}
+		case func(*TVector):
+			fn(out)
+		default:
+			panic(errTransformSig)
+		}
 	}
 	return out.AsPersistent()
 }
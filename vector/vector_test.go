@@ -1256,3 +1256,23 @@ func TestReduce(t *testing.T) {
 		}
 	})
 }
+
+func TestTransformForms(t *testing.T) {
+	v := New("a", "b", "c").Transform(
+		func(t *TVector) *TVector { return t.Delete(0).Insert(1, "x") },
+		func(t *TVector) { t.Assoc(0, "B").Pop() },
+		func(t *TVector) *TVector {
+			t.Append("d")
+			return nil
+		},
+	)
+	if !v.Equal(New("B", "x", "d")) {
+		t.Fatalf("unexpected vector %s", v)
+	}
+	defer func() {
+		if r := recover(); r != errTransformSig {
+			t.Fatalf("expected errTransformSig, got %v", r)
+		}
+	}()
+	v.Transform(func(t *TVector) int { return t.Length() })
+}